@@ -0,0 +1,157 @@
+// Command atmsim plays an ATM switch against the server's ISO 8583
+// listener.
+//
+//	atmsim echo
+//	atmsim balance  (-pan PAN -expiry YYMM | -account NUMBER)
+//	atmsim withdraw -pan PAN -expiry YYMM -amount 500 [-rrn RRN]
+//	atmsim reverse  -rrn RRN -amount 500
+//
+// The listener address comes from ATMSIM_ADDR (default localhost:8583).
+// With ATMSIM_TLS_CERT, ATMSIM_TLS_KEY and ATMSIM_TLS_CA it connects over
+// mutual TLS; otherwise the listener must run with ISO8583_PLAINTEXT.
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/atmswitch"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/iso8583"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  atmsim echo")
+	fmt.Fprintln(os.Stderr, "  atmsim balance  (-pan PAN -expiry YYMM | -account NUMBER)")
+	fmt.Fprintln(os.Stderr, "  atmsim withdraw -pan PAN -expiry YYMM -amount N [-rrn RRN]")
+	fmt.Fprintln(os.Stderr, "  atmsim reverse  -rrn RRN -amount N")
+	fmt.Fprintln(os.Stderr, "common flags: -terminal ID -currency 356")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	config.LoadDotEnv()
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	pan := fs.String("pan", "", "card number")
+	expiry := fs.String("expiry", "", "card expiry as YYMM")
+	account := fs.String("account", "", "account number, used when no card is given")
+	amount := fs.Float64("amount", 0, "amount")
+	currency := fs.String("currency", "356", "ISO 4217 numeric currency code")
+	terminal := fs.String("terminal", "ATMSIM01", "terminal id")
+	rrn := fs.String("rrn", "", "retrieval reference number (default random)")
+	fs.Parse(os.Args[2:])
+
+	now := time.Now()
+	req := iso8583.NewMessage("")
+	req.Set(iso8583.FieldTransmissionDateTime, now.UTC().Format("0102150405"))
+	req.Set(iso8583.FieldSTAN, randomDigits(6))
+
+	switch os.Args[1] {
+	case "echo":
+		req.MTI = iso8583.MTINetworkRequest
+		req.Set(iso8583.FieldNetworkManagementCode, iso8583.NetworkEcho)
+
+	case "balance", "withdraw":
+		req.MTI = iso8583.MTIFinancialRequest
+		procCode := iso8583.ProcBalanceInquiry + "0000"
+		if os.Args[1] == "withdraw" {
+			procCode = iso8583.ProcCashWithdrawal + "0000"
+			req.Set(iso8583.FieldAmount, iso8583.FormatAmount(*amount))
+		}
+		req.Set(iso8583.FieldProcessingCode, procCode)
+		setCardholder(req, *pan, *expiry, *account)
+		setTerminal(req, now, *terminal, *rrn, *currency)
+
+	case "reverse":
+		if *rrn == "" {
+			usage()
+		}
+		req.MTI = iso8583.MTIReversalRequest
+		req.Set(iso8583.FieldProcessingCode, iso8583.ProcCashWithdrawal+"0000")
+		req.Set(iso8583.FieldAmount, iso8583.FormatAmount(*amount))
+		setTerminal(req, now, *terminal, *rrn, *currency)
+
+	default:
+		usage()
+	}
+
+	client, err := dial(config.String("ATMSIM_ADDR", "localhost:8583"))
+	if err != nil {
+		log.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	printMessage(">>", req)
+	resp, err := client.Send(req)
+	if err != nil {
+		log.Fatalf("send: %v", err)
+	}
+	printMessage("<<", resp)
+	if resp.Get(iso8583.FieldResponseCode) != iso8583.RespApproved {
+		os.Exit(1)
+	}
+}
+
+func dial(addr string) (*atmswitch.Client, error) {
+	certFile := config.String("ATMSIM_TLS_CERT", "")
+	if certFile == "" {
+		return atmswitch.Dial(addr, 10*time.Second)
+	}
+	tlsConfig, err := atmswitch.ClientTLSConfig(certFile, config.String("ATMSIM_TLS_KEY", ""), config.String("ATMSIM_TLS_CA", ""))
+	if err != nil {
+		return nil, err
+	}
+	return atmswitch.DialTLS(addr, 10*time.Second, tlsConfig)
+}
+
+func setCardholder(req *iso8583.Message, pan, expiry, account string) {
+	switch {
+	case pan != "":
+		req.Set(iso8583.FieldPAN, pan)
+		if expiry != "" {
+			req.Set(iso8583.FieldExpiry, expiry)
+		}
+	case account != "":
+		req.Set(iso8583.FieldAccount, account)
+	default:
+		usage()
+	}
+}
+
+func setTerminal(req *iso8583.Message, now time.Time, terminal, rrn, currency string) {
+	if rrn == "" {
+		rrn = randomDigits(12)
+	}
+	req.Set(iso8583.FieldLocalTime, now.Format("150405"))
+	req.Set(iso8583.FieldLocalDate, now.Format("0102"))
+	req.Set(iso8583.FieldRRN, rrn)
+	req.Set(iso8583.FieldTerminalID, terminal)
+	req.Set(iso8583.FieldCurrency, currency)
+}
+
+func printMessage(prefix string, m *iso8583.Message) {
+	fmt.Printf("%s %s\n", prefix, m.MTI)
+	for _, n := range m.Fields() {
+		fmt.Printf("   %3d: %s\n", n, m.Get(n))
+	}
+}
+
+func randomDigits(n int) string {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return fmt.Sprintf("%0*d", n, v)
+}
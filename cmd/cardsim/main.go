@@ -0,0 +1,129 @@
+// Command cardsim plays the card network against a running server's
+// /network API.
+//
+//	cardsim authorize -pan 4000001234567890 -expiry 10/29 -cvv 123 -amount 25.50 [-mcc 5411] [-merchant "Corner Shop"]
+//	cardsim capture [-amount 20] <authorization id>
+//	cardsim reverse <authorization id>
+//
+// The server address and key come from CARDSIM_URL (default
+// http://localhost:8080) and CARD_NETWORK_KEY.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Mahesh252k/banking-api/internal/config"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  cardsim authorize -pan PAN -expiry MM/YY [-cvv CVV] -amount N [-currency INR] [-mcc 5411] [-merchant NAME] [-ref REF]")
+	fmt.Fprintln(os.Stderr, "  cardsim capture [-amount N] <authorization id>")
+	fmt.Fprintln(os.Stderr, "  cardsim reverse <authorization id>")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	config.LoadDotEnv()
+	baseURL := config.String("CARDSIM_URL", "http://localhost:8080")
+	key := config.String("CARD_NETWORK_KEY", "")
+
+	switch os.Args[1] {
+	case "authorize":
+		fs := flag.NewFlagSet("authorize", flag.ExitOnError)
+		pan := fs.String("pan", "", "card number")
+		expiry := fs.String("expiry", "", "expiry as MM/YY")
+		cvv := fs.String("cvv", "", "card verification value (omit for card-present)")
+		amount := fs.Float64("amount", 0, "amount to authorize")
+		currency := fs.String("currency", "INR", "ISO 4217 currency code")
+		mcc := fs.String("mcc", "5411", "merchant category code")
+		merchant := fs.String("merchant", "Card Simulator", "merchant name")
+		ref := fs.String("ref", "", "network reference (default random)")
+		fs.Parse(os.Args[2:])
+
+		if *ref == "" {
+			*ref = randomReference()
+		}
+		post(baseURL+"/network/authorizations", key, map[string]any{
+			"pan":               *pan,
+			"expiry":            *expiry,
+			"cvv":               *cvv,
+			"amount":            *amount,
+			"currency":          *currency,
+			"mcc":               *mcc,
+			"merchant_name":     *merchant,
+			"network_reference": *ref,
+		})
+
+	case "capture":
+		fs := flag.NewFlagSet("capture", flag.ExitOnError)
+		amount := fs.Float64("amount", 0, "amount to capture (default full authorization)")
+		fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			usage()
+		}
+		post(baseURL+"/network/authorizations/"+fs.Arg(0)+"/capture", key, map[string]any{"amount": *amount})
+
+	case "reverse":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		post(baseURL+"/network/authorizations/"+os.Args[2]+"/reversal", key, nil)
+
+	default:
+		usage()
+	}
+}
+
+func post(url, key string, body any) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Network-Key", key)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s\n%s\n", resp.Status, out)
+	if resp.StatusCode >= 300 {
+		os.Exit(1)
+	}
+}
+
+func randomReference() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return "SIM" + hex.EncodeToString(b)
+}
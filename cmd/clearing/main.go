@@ -0,0 +1,185 @@
+// Command clearing exchanges ISO 20022 files with the clearing bank.
+//
+//	clearing export-pain001 [-o file.xml] [-limit 500]
+//	clearing write-pain001 [-o file.xml] MESSAGE_ID
+//	clearing import-status file.xml
+//	clearing import-camt054 file.xml
+//	clearing import-csv file.csv
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/db"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/outbox"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"gorm.io/gorm"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  clearing export-pain001 [-o file.xml] [-limit 500]")
+	fmt.Fprintln(os.Stderr, "  clearing write-pain001 [-o file.xml] MESSAGE_ID")
+	fmt.Fprintln(os.Stderr, "  clearing import-status file.xml")
+	fmt.Fprintln(os.Stderr, "  clearing import-camt054 file.xml")
+	fmt.Fprintln(os.Stderr, "  clearing import-csv file.csv")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	config.LoadDotEnv()
+	dbConn := db.Connect()
+	clearingSvc, inboundSvc := newServices(dbConn)
+
+	switch os.Args[1] {
+	case "export-pain001":
+		fs := flag.NewFlagSet("export-pain001", flag.ExitOnError)
+		out := fs.String("o", "", "output file (default stdout)")
+		limit := fs.Int("limit", 500, "maximum number of payments in the file")
+		fs.Parse(os.Args[2:])
+
+		var file *models.ClearingFile
+		err := writeOutput(*out, func(w io.Writer) (err error) {
+			file, err = clearingSvc.ExportPain001(w, *limit)
+			return err
+		})
+		if err != nil {
+			if file != nil {
+				log.Fatalf("export of %s committed but not written, run write-pain001 %s: %v", file.MessageID, file.MessageID, err)
+			}
+			log.Fatalf("export failed: %v", err)
+		}
+		if file == nil {
+			log.Println("no initiated payments to export")
+			return
+		}
+		log.Printf("exported %s with %d payments totalling %.2f", file.MessageID, file.EntryCount, file.ControlSum)
+
+	case "write-pain001":
+		fs := flag.NewFlagSet("write-pain001", flag.ExitOnError)
+		out := fs.String("o", "", "output file (default stdout)")
+		fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			usage()
+		}
+
+		err := writeOutput(*out, func(w io.Writer) error {
+			_, err := clearingSvc.WritePain001(w, fs.Arg(0))
+			return err
+		})
+		if err != nil {
+			log.Fatalf("write failed: %v", err)
+		}
+
+	case "import-status":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		f, err := os.Open(os.Args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		result, err := clearingSvc.ImportStatusReport(f)
+		if err != nil {
+			log.Fatalf("import failed: %v", err)
+		}
+		json.NewEncoder(os.Stdout).Encode(result)
+
+	case "import-camt054", "import-csv":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		f, err := os.Open(os.Args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		var result *services.InboundImportResult
+		if os.Args[1] == "import-csv" {
+			result, err = inboundSvc.ImportCSV(f)
+		} else {
+			result, err = inboundSvc.ImportCamt054(f)
+		}
+		if err != nil {
+			log.Fatalf("import failed: %v", err)
+		}
+		json.NewEncoder(os.Stdout).Encode(result)
+
+	default:
+		usage()
+	}
+}
+
+// writeOutput runs write against stdout, or against a temporary file next
+// to path that is renamed into place once write succeeds, so a partly
+// written file never appears under path.
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func newServices(dbConn *gorm.DB) (services.ClearingService, services.InboundPaymentService) {
+	accountRepo := repositories.NewAccountRepo(dbConn)
+	txRepo := repositories.NewTransactionRepo(dbConn)
+	paymentRepo := repositories.NewOutgoingPaymentRepo(dbConn)
+	beneficiarySvc := services.NewBeneficiaryService(dbConn, repositories.NewBeneficiaryRepo(dbConn), paymentRepo, services.BeneficiaryPolicy{}, nil)
+
+	// no rail: this command only moves payments through files
+	paymentSvc := services.NewOutgoingPaymentService(
+		dbConn, paymentRepo, accountRepo, txRepo, beneficiarySvc, nil,
+		nil, config.Int("OUTBOUND_CLEARING_ACCOUNT_ID", 0),
+	)
+
+	fileRepo := repositories.NewClearingFileRepo(dbConn)
+	clearingSvc := services.NewClearingService(
+		dbConn, fileRepo, paymentRepo, paymentSvc,
+		services.BankIdentity{
+			Name: config.String("BANK_NAME", "Banking API"),
+			BIC:  config.String("BANK_BIC", "NOTPROVIDED"),
+		},
+	)
+
+	recorder := outbox.NewWriter(repositories.NewOutboxRepo(dbConn))
+	auditLog := audit.NewLog(repositories.NewAuditRepo(dbConn))
+	accountSvc := services.NewAccountService(
+		dbConn, accountRepo, txRepo, recorder, auditLog,
+		nil, // inbound credits are not screened
+	)
+	inboundSvc := services.NewInboundPaymentService(
+		dbConn, repositories.NewInboundCreditRepo(dbConn), fileRepo, accountRepo, accountSvc,
+		recorder, auditLog,
+	)
+	return clearingSvc, inboundSvc
+}
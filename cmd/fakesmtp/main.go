@@ -0,0 +1,33 @@
+// Command fakesmtp runs a local SMTP server that accepts every message and
+// prints it, for trying out email notifications without a real relay.
+//
+//	fakesmtp [-addr 127.0.0.1:2525]
+//
+// Point the API at it with SMTP_ADDR=127.0.0.1:2525.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/notifications/smtpfake"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "listen address")
+	flag.Parse()
+
+	server := &smtpfake.Server{
+		OnMessage: func(m smtpfake.Message) {
+			fmt.Printf("---- from %s to %s\n%s\n", m.From, strings.Join(m.To, ", "), m.Data)
+		},
+	}
+	bound, err := server.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("fake SMTP server listening on %s", bound)
+	select {}
+}
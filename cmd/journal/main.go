@@ -0,0 +1,102 @@
+// Command journal checks and maintains the transaction hash chain.
+//
+//	journal verify       walk the chain; exits 1 at the first broken link
+//	journal checkpoint   sign the current chain head
+//	journal seal         chain transactions posted before the journal existed
+//	journal keygen       print a new signing seed and its public key
+//
+// Checkpoints are signed with JOURNAL_SIGNING_KEY; JOURNAL_VERIFY_KEYS
+// lists retired public keys that older checkpoints were signed with.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/db"
+	"github.com/Mahesh252k/banking-api/internal/journal"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/services"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  journal verify")
+	fmt.Fprintln(os.Stderr, "  journal checkpoint")
+	fmt.Fprintln(os.Stderr, "  journal seal")
+	fmt.Fprintln(os.Stderr, "  journal keygen")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		usage()
+	}
+	if os.Args[1] == "keygen" {
+		keygen()
+		return
+	}
+
+	config.LoadDotEnv()
+	signer, keys, err := journal.LoadKeys(config.String("JOURNAL_SIGNING_KEY", ""), config.String("JOURNAL_VERIFY_KEYS", ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+	dbConn := db.Connect()
+	journalSvc := services.NewJournalService(
+		repositories.NewTransactionRepo(dbConn), repositories.NewJournalCheckpointRepo(dbConn),
+		services.JournalConfig{Signer: signer, Keys: keys},
+	)
+
+	switch os.Args[1] {
+	case "verify":
+		report, err := journalSvc.Verify()
+		if err != nil {
+			log.Fatalf("verify failed: %v", err)
+		}
+		json.NewEncoder(os.Stdout).Encode(report)
+		if !report.OK {
+			os.Exit(1)
+		}
+
+	case "checkpoint":
+		cp, err := journalSvc.Checkpoint()
+		if err != nil {
+			log.Fatalf("checkpoint failed: %v", err)
+		}
+		if cp == nil {
+			log.Println("no transactions since the last checkpoint")
+			return
+		}
+		json.NewEncoder(os.Stdout).Encode(cp)
+
+	case "seal":
+		n, err := journalSvc.Seal()
+		if err != nil {
+			log.Fatalf("seal failed after %d transactions: %v", n, err)
+		}
+		log.Printf("sealed %d transactions", n)
+
+	default:
+		usage()
+	}
+}
+
+func keygen() {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		log.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(seed)
+	signer, err := journal.NewSigner(encoded)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("JOURNAL_SIGNING_KEY=%s\n", encoded)
+	fmt.Printf("# public key, for JOURNAL_VERIFY_KEYS after rotation: %s\n", signer.PublicKey())
+}
@@ -1,87 +1,129 @@
 package main
 
 import (
+	"crypto/subtle"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
 
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/config"
 	"github.com/Mahesh252k/banking-api/internal/db"
 	"github.com/Mahesh252k/banking-api/internal/handlers"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/ratelimit"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 func main() {
 	// Load environment variables from .env file
-	dir, err := os.Getwd()
-	if err != nil {
-		log.Fatal("cannot get working directory:", err)
-	}
-
-	candidates := []string{
-		filepath.Join(dir, ".env"),
-		filepath.Join(dir, "..", ".env"),
-		filepath.Join(dir, "..", "..", ".env"),
-	}
-
-	loaded := false
-	for _, p := range candidates {
-		log.Printf("Loading environment variables from %s", p)
-		if err := godotenv.Overload(p); err == nil {
-			log.Println("successfully loaded .env file")
-			loaded = true
-			break
-		} else {
-			log.Printf("warning: could not load .env from %s: %v", p, err)
-		}
-	}
-
-	if !loaded {
-		log.Println("warning: .env file was not loaded from any known location")
-	}
+	config.LoadDotEnv()
 
 	dsn := os.Getenv("DB_DSN")
-	jwtSecret := os.Getenv("JWT_SECRET")
 	port := os.Getenv("PORT")
 
 	if dsn == "" {
 		log.Fatal("DB_DSN is not loaded")
 	}
 
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET is not loaded")
-	}
-
 	if port == "" {
 		port = "8080"
 	}
 
 	dbConn := db.Connect()
 	handlers.InitHandlers(dbConn)
+	handlers.StartBackgroundJobs()
+	handlers.StartSwitchListener()
 
 	r := gin.Default()
 	r.Use(gin.Logger()) // logging middleware
 
+	// X-Forwarded-For is honoured only from these proxies; by default
+	// ClientIP is the peer address, so lockouts, rate limits and the
+	// audit trail cannot be keyed on a spoofed header
+	if err := r.SetTrustedProxies(trustedProxies(config.String("TRUSTED_PROXIES", ""))); err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+
 	// CORS Middleware - applied globally for all routes
 	r.Use(corsMiddleware())
 
-	// public routes
-	r.POST("/auth/register", handlers.Register)
-	r.POST("/auth/login", handlers.Login)
+	// request IDs and the audit trail of state-changing requests
+	r.Use(handlers.AuditMiddleware())
+
+	// public routes, rate limited per client address
+	public := r.Group("")
+	public.Use(handlers.RateLimit("auth", 20, 10, ratelimit.ByIP))
+
+	public.POST("/auth/register", handlers.Register)
+	public.POST("/auth/login", handlers.Login)
+	public.POST("/auth/forgot-password", handlers.ForgotPassword)
+	public.POST("/auth/reset-password", handlers.ResetPassword)
+	public.POST("/auth/login/mfa", handlers.LoginMFA)
+	public.POST("/auth/refresh", handlers.RefreshToken)
+	public.POST("/staff/login", handlers.StaffLogin)
+	public.POST("/oauth/token", handlers.IssueClientToken)
+	public.GET("/.well-known/jwks.json", handlers.JWKS)
 
 	// protected routes
 	protected := r.Group("")
-	protected.Use(authMiddleware())
+	protected.Use(
+		handlers.AuthMiddleware(),
+		handlers.RateLimit("api", 300, 60, handlers.ByCustomer),
+		handlers.ClientRateLimit(),
+	)
+	// API clients reach only the routes granting one of their scopes
+	scope := auth.RequireScope
+
+	// sessions
+	protected.POST("/auth/logout", handlers.Logout)
+	protected.POST("/auth/step-up", handlers.StepUp)
+	protected.GET("/auth/sessions", handlers.ListSessions)
+	protected.DELETE("/auth/sessions/:id", handlers.RevokeSession)
 
 	// accounts
 	protected.POST("/accounts", handlers.CreateAccount)
-	protected.GET("/accounts", handlers.ListAccounts)
-	protected.POST("/transfers/:from_id", handlers.Transfer)
+	protected.GET("/accounts", scope(models.ScopeAccountsRead), handlers.ListAccounts)
+	protected.POST("/transfers/:from_id", scope(models.ScopeTransfersWrite), handlers.Transfer)
 	protected.POST("/deposits/:account_id", handlers.Deposit)
 	protected.POST("/accounts/:id/statement", handlers.GetStatement)
 
+	// profile
+	protected.GET("/me", handlers.GetProfile)
+	protected.PATCH("/me", handlers.UpdateProfile)
+	protected.POST("/me/email", handlers.ChangeEmail)
+	protected.POST("/me/phone", handlers.ChangePhone)
+	protected.POST("/me/contact-changes/:id/confirm", handlers.ConfirmContactChange)
+	protected.POST("/me/password", handlers.ChangePassword)
+	protected.GET("/me/history", handlers.ListProfileChanges)
+	protected.GET("/me/mfa", handlers.GetMFAStatus)
+	protected.POST("/me/mfa/totp", handlers.BeginTOTP)
+	protected.POST("/me/mfa/totp/confirm", handlers.ConfirmTOTP)
+	protected.POST("/me/mfa/totp/disable", handlers.DisableTOTP)
+	protected.POST("/me/mfa/recovery-codes", handlers.RegenerateRecoveryCodes)
+	protected.GET("/me/consents", handlers.ListMyConsents)
+	protected.GET("/me/consents/:id", handlers.GetMyConsent)
+	protected.POST("/me/consents/:id/authorize", handlers.AuthorizeConsent)
+	protected.POST("/me/consents/:id/reject", handlers.RejectConsent)
+	protected.DELETE("/me/consents/:id", handlers.RevokeMyConsent)
+
+	// identity verification
+	protected.GET("/kyc", handlers.GetKYCProfile)
+	protected.POST("/kyc/documents", handlers.UploadKYCDocument)
+
+	// outgoing payments to beneficiaries at other banks
+	protected.POST("/accounts/:id/payments", scope(models.ScopePaymentsWrite), handlers.CreateOutgoingPayment)
+	protected.GET("/accounts/:id/payments", scope(models.ScopePaymentsRead), handlers.ListOutgoingPayments)
+	protected.GET("/payments/:id", scope(models.ScopePaymentsRead), handlers.GetOutgoingPayment)
+
+	// bulk transfers (payroll)
+	protected.POST("/accounts/:id/bulk-transfers", scope(models.ScopePaymentsWrite), handlers.CreateBulkTransfer)
+	protected.GET("/bulk-transfers/:id", scope(models.ScopePaymentsRead), handlers.GetBulkTransfer)
+	protected.GET("/bulk-transfers/:id/report", scope(models.ScopePaymentsRead), handlers.DownloadBulkTransferReport)
+
 	// loans
 	protected.POST("/loans", handlers.CreateLoan)
 	protected.GET("/loans", handlers.ListLoans)
@@ -90,15 +132,158 @@ func main() {
 
 	// beneficiaries
 	protected.POST("/beneficiaries", handlers.AddBeneficiary)
+	protected.GET("/beneficiaries", scope(models.ScopeBeneficiariesRead), handlers.ListBeneficiaries)
+	protected.PATCH("/beneficiaries/:id", handlers.UpdateBeneficiary)
+	protected.DELETE("/beneficiaries/:id", handlers.DeleteBeneficiary)
+
+	// bill payments
+	protected.GET("/billers", handlers.ListBillers)
+	protected.POST("/biller-registrations", handlers.RegisterBiller)
+	protected.GET("/biller-registrations", handlers.ListBillerRegistrations)
+	protected.DELETE("/biller-registrations/:id", handlers.DeleteBillerRegistration)
+	protected.POST("/bill-payments", handlers.PayBill)
+	protected.GET("/bill-payments", handlers.ListBillPayments)
+	protected.GET("/bill-payments/:id", handlers.GetBillPayment)
+	protected.POST("/bill-payments/:id/cancel", handlers.CancelBillPayment)
+
+	// debit cards
+	protected.POST("/accounts/:id/cards", handlers.IssueCard)
+	protected.GET("/cards", handlers.ListCards)
+	protected.GET("/cards/:id", handlers.GetCard)
+	protected.PATCH("/cards/:id", handlers.UpdateCard)
+	protected.POST("/cards/:id/block", handlers.BlockCard)
+	protected.POST("/cards/:id/unblock", handlers.UnblockCard)
+	protected.GET("/cards/:id/authorizations", handlers.ListCardAuthorizations)
+
+	// notifications
+	protected.GET("/notifications", handlers.ListNotifications)
+	protected.POST("/notifications/:id/read", handlers.MarkNotificationRead)
+	protected.GET("/notification-preferences", handlers.GetNotificationPreferences)
+	protected.PATCH("/notification-preferences", handlers.UpdateNotificationPreferences)
+
+	// webhooks
+	protected.POST("/webhooks", scope(models.ScopeWebhooksManage), handlers.CreateWebhook)
+	protected.GET("/webhooks", scope(models.ScopeWebhooksManage), handlers.ListWebhooks)
+	protected.DELETE("/webhooks/:id", scope(models.ScopeWebhooksManage), handlers.DeleteWebhook)
+	protected.GET("/webhooks/:id/deliveries", scope(models.ScopeWebhooksManage), handlers.ListWebhookDeliveries)
+	protected.GET("/webhook-deliveries/:id", scope(models.ScopeWebhooksManage), handlers.GetWebhookDelivery)
+	protected.POST("/webhook-deliveries/:id/redeliver", scope(models.ScopeWebhooksManage), handlers.RedeliverWebhook)
+
+	// open banking: third-party providers request consents with their
+	// client token, then use each with a token bound to it
+	providers := r.Group("/open-banking/consents")
+	providers.Use(handlers.ProviderAuthMiddleware(), handlers.ClientRateLimit())
+
+	providers.POST("", handlers.CreateConsent)
+	providers.GET("/:id", handlers.GetConsent)
+	providers.DELETE("/:id", handlers.RevokeConsent)
+
+	openBanking := r.Group("/open-banking")
+	openBanking.Use(handlers.ConsentAuthMiddleware(), handlers.ClientRateLimit())
+	consent := handlers.RequireConsent
+
+	openBanking.GET("/accounts", consent(models.ConsentAccounts), handlers.OBListAccounts)
+	openBanking.GET("/accounts/:id/balances", consent(models.ConsentBalances), handlers.OBGetBalances)
+	openBanking.GET("/accounts/:id/transactions", consent(models.ConsentTransactions), handlers.OBListTransactions)
+	openBanking.POST("/payments", consent(models.ConsentPayments), handlers.OBCreatePayment)
+
+	// back office
+	admin := r.Group("/admin")
+	admin.Use(handlers.StaffAuthMiddleware(), handlers.RateLimit("admin", 300, 60, handlers.ByStaff))
+	can := auth.RequirePermission
+
+	admin.GET("/me", handlers.GetCurrentStaff)
+
+	admin.GET("/staff", can(models.PermStaffManage), handlers.ListStaff)
+	admin.POST("/staff", can(models.PermStaffManage), handlers.CreateStaff)
+	admin.GET("/staff/:id", can(models.PermStaffManage), handlers.GetStaff)
+	admin.PATCH("/staff/:id", can(models.PermStaffManage), handlers.UpdateStaff)
+	admin.GET("/roles", can(models.PermStaffManage), handlers.ListRoles)
+	admin.POST("/roles", can(models.PermStaffManage), handlers.CreateRole)
+	admin.PATCH("/roles/:id", can(models.PermStaffManage), handlers.UpdateRole)
+
+	admin.POST("/inbound-files", can(models.PermPaymentsOperate), handlers.ImportInboundFile)
+	admin.GET("/inbound-credits/suspense", can(models.PermPaymentsOperate), handlers.ListSuspenseCredits)
+	admin.POST("/inbound-credits/:id/assign", can(models.PermPaymentsOperate), handlers.AssignSuspenseCredit)
+
+	admin.POST("/billers", can(models.PermBillersManage), handlers.CreateBiller)
+	admin.GET("/billers", can(models.PermBillersManage), handlers.ListAllBillers)
+	admin.PATCH("/billers/:id", can(models.PermBillersManage), handlers.UpdateBiller)
+
+	admin.GET("/audit-log", can(models.PermAuditRead), handlers.ListAuditLog)
+
+	admin.GET("/lockouts", can(models.PermSecurityManage), handlers.ListLockouts)
+	admin.POST("/lockouts/unlock", can(models.PermSecurityManage), handlers.UnlockLogin)
+	admin.POST("/signing-keys/rotate", can(models.PermSecurityManage), handlers.RotateSigningKey)
+
+	admin.GET("/api-clients", can(models.PermClientsManage), handlers.ListAPIClients)
+	admin.POST("/api-clients", can(models.PermClientsManage), handlers.CreateAPIClient)
+	admin.GET("/api-clients/:id", can(models.PermClientsManage), handlers.GetAPIClient)
+	admin.PATCH("/api-clients/:id", can(models.PermClientsManage), handlers.UpdateAPIClient)
+	admin.POST("/api-clients/:id/rotate-secret", can(models.PermClientsManage), handlers.RotateAPIClientSecret)
+
+	admin.GET("/fraud/rules", can(models.PermFraudRules), handlers.ListFraudRules)
+	admin.POST("/fraud/rules", can(models.PermFraudRules), handlers.CreateFraudRule)
+	admin.PATCH("/fraud/rules/:id", can(models.PermFraudRules), handlers.UpdateFraudRule)
+	admin.GET("/fraud/held-transfers", can(models.PermFraudReview), handlers.ListHeldTransfers)
+	admin.GET("/fraud/held-transfers/:id", can(models.PermFraudReview), handlers.GetHeldTransfer)
+	admin.POST("/fraud/held-transfers/:id/approve", can(models.PermFraudReview), handlers.ApproveHeldTransfer)
+	admin.POST("/fraud/held-transfers/:id/reject", can(models.PermFraudReview), handlers.RejectHeldTransfer)
+
+	admin.GET("/kyc/customers", can(models.PermKYCReview), handlers.ListKYCCustomers)
+	admin.GET("/kyc/customers/:id", can(models.PermKYCReview), handlers.GetCustomerKYC)
+	admin.POST("/kyc/customers/:id/approve", can(models.PermKYCReview), handlers.ApproveKYC)
+	admin.POST("/kyc/customers/:id/reject", can(models.PermKYCReview), handlers.RejectKYC)
+	admin.GET("/kyc/documents/:id/file", can(models.PermKYCReview), handlers.DownloadKYCDocument)
+
+	admin.GET("/sanctions/list", can(models.PermSanctionsReview), handlers.GetSanctionsList)
+	admin.POST("/sanctions/rescreen", can(models.PermSanctionsReview), handlers.RescreenSanctions)
+	admin.GET("/sanctions/hits", can(models.PermSanctionsReview), handlers.ListSanctionsHits)
+	admin.GET("/sanctions/hits/:id", can(models.PermSanctionsReview), handlers.GetSanctionsHit)
+	admin.POST("/sanctions/hits/:id/confirm", can(models.PermSanctionsReview), handlers.ConfirmSanctionsHit)
+	admin.POST("/sanctions/hits/:id/dismiss", can(models.PermSanctionsReview), handlers.DismissSanctionsHit)
+
+	admin.GET("/journal/verify", can(models.PermJournalRead), handlers.VerifyJournal)
+	admin.GET("/journal/checkpoints", can(models.PermJournalRead), handlers.ListJournalCheckpoints)
+	admin.POST("/journal/checkpoints", can(models.PermJournalWrite), handlers.CreateJournalCheckpoint)
+
+	// card network
+	network := r.Group("/network")
+	network.Use(
+		sharedKeyMiddleware("CARD_NETWORK_KEY", "X-Network-Key"),
+		audit.As(models.ActorNetwork),
+		// off unless RATE_LIMIT_NETWORK_PER_MINUTE is set
+		handlers.RateLimit("network", 0, 0, ratelimit.ByIP),
+	)
+
+	network.POST("/authorizations", handlers.AuthorizeCard)
+	network.POST("/authorizations/:id/capture", handlers.CaptureCardAuthorization)
+	network.POST("/authorizations/:id/reversal", handlers.ReverseCardAuthorization)
 
 	log.Printf("server starting on %s", port)
 	r.Run(":" + port)
 }
 
-func authMiddleware() gin.HandlerFunc {
+// trustedProxies splits a comma-separated list of proxy addresses and
+// CIDR ranges. An empty list trusts no proxy.
+func trustedProxies(list string) []string {
+	var proxies []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			proxies = append(proxies, entry)
+		}
+	}
+	return proxies
+}
+
+// sharedKeyMiddleware protects a route group with the shared key from
+// envVar, sent in header. With no key configured the routes are closed.
+func sharedKeyMiddleware(envVar, header string) gin.HandlerFunc {
+	want := os.Getenv(envVar)
 	return func(c *gin.Context) {
-		if c.GetHeader("Authorization") == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		key := c.GetHeader(header)
+		if want == "" || subtle.ConstantTimeCompare([]byte(key), []byte(want)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 			return
 		}
 		c.Next()
@@ -108,9 +293,10 @@ func authMiddleware() gin.HandlerFunc {
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "***")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Requested-With, X-Network-Key, X-Device-ID, X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After")
 
 		// handle preflight OPTIONS requests
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,62 @@
+package atmswitch
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/iso8583"
+)
+
+// Client is the switch side of a connection, used by the ATM simulator.
+// Requests are sent one at a time and wait for their response.
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+	mu      sync.Mutex
+}
+
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, timeout: timeout}, nil
+}
+
+// DialTLS connects over TLS, e.g. with a config from ClientTLSConfig.
+func DialTLS(addr string, timeout time.Duration, config *tls.Config) (*Client, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, timeout: timeout}, nil
+}
+
+// Send packs req, writes it and reads the response.
+func (c *Client) Send(req *iso8583.Message) (*iso8583.Message, error) {
+	data, err := req.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err := iso8583.WriteFrame(c.conn, data); err != nil {
+		return nil, err
+	}
+	out, err := iso8583.ReadFrame(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	return iso8583.Unpack(out)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,401 @@
+// Package atmswitch connects the ATM/POS switch to the bank over ISO 8583.
+// A Server accepts TCP connections carrying length-prefixed messages and a
+// Processor maps them to balance inquiries, withdrawals and reversals on
+// the linked account.
+package atmswitch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/cards"
+	"github.com/Mahesh252k/banking-api/internal/iso8583"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"gorm.io/gorm"
+)
+
+// respExceedsLimit is "exceeds withdrawal amount limit".
+const respExceedsLimit = "61"
+
+type Processor struct {
+	db        *gorm.DB
+	repo      repositories.SwitchTransactionRepository
+	accounts  services.AccountService
+	cards     services.CardService
+	customers repositories.CustomerRepository
+	fraud     services.FraudService
+	kyc       services.KYCService
+}
+
+// NewProcessor builds the processor. fraud and kyc may be nil, in which
+// case withdrawals are not screened or held to the KYC limits.
+func NewProcessor(
+	db *gorm.DB,
+	repo repositories.SwitchTransactionRepository,
+	accounts services.AccountService,
+	cards services.CardService,
+	customers repositories.CustomerRepository,
+	fraud services.FraudService,
+	kyc services.KYCService,
+) *Processor {
+	return &Processor{db: db, repo: repo, accounts: accounts, cards: cards, customers: customers, fraud: fraud, kyc: kyc}
+}
+
+// Handle answers one request. It always returns a response; failures are
+// reported through the response code.
+func (p *Processor) Handle(req *iso8583.Message) *iso8583.Message {
+	resp := req.Response()
+
+	var code string
+	switch req.MTI {
+	case iso8583.MTINetworkRequest:
+		code = p.networkManagement(req)
+	case iso8583.MTIAuthorizationRequest:
+		if processingType(req) == iso8583.ProcBalanceInquiry {
+			code = p.balanceInquiry(req, resp)
+		} else {
+			code = iso8583.RespInvalidTransaction
+		}
+	case iso8583.MTIFinancialRequest:
+		switch processingType(req) {
+		case iso8583.ProcBalanceInquiry:
+			code = p.balanceInquiry(req, resp)
+		case iso8583.ProcCashWithdrawal, iso8583.ProcPurchase:
+			code = p.withdrawal(req, resp)
+		default:
+			code = iso8583.RespInvalidTransaction
+		}
+	case iso8583.MTIReversalRequest, iso8583.MTIReversalAdvice:
+		code = p.reversal(req)
+	default:
+		code = iso8583.RespInvalidTransaction
+	}
+
+	resp.Set(iso8583.FieldResponseCode, code)
+	return resp
+}
+
+func processingType(req *iso8583.Message) string {
+	code := req.Get(iso8583.FieldProcessingCode)
+	if len(code) < 2 {
+		return ""
+	}
+	return code[:2]
+}
+
+func (p *Processor) networkManagement(req *iso8583.Message) string {
+	switch req.Get(iso8583.FieldNetworkManagementCode) {
+	case iso8583.NetworkSignOn, iso8583.NetworkSignOff, iso8583.NetworkEcho:
+		return iso8583.RespApproved
+	}
+	return iso8583.RespInvalidTransaction
+}
+
+// resolveAccount finds the account a request is for: by card number
+// (field 2) when present, otherwise by account number (field 102) if
+// withoutCard allows it. Only balance inquiries may come without a card;
+// anything that moves money needs one.
+func (p *Processor) resolveAccount(req *iso8583.Message, withoutCard bool) (*models.Account, *models.Card, string) {
+	var accountID int
+	var card *models.Card
+
+	switch {
+	case req.Has(iso8583.FieldPAN):
+		var err error
+		card, err = p.cards.CardForPAN(req.Get(iso8583.FieldPAN))
+		if errors.Is(err, models.ErrCardNotFound) {
+			return nil, nil, iso8583.RespInvalidCard
+		}
+		if err != nil {
+			log.Printf("iso8583: card lookup: %v", err)
+			return nil, nil, iso8583.RespSystemError
+		}
+
+		switch card.Status {
+		case models.CardStatusBlocked:
+			return nil, nil, iso8583.RespRestrictedCard
+		case models.CardStatusExpired:
+			return nil, nil, iso8583.RespExpiredCard
+		}
+		if expiry := req.Get(iso8583.FieldExpiry); expiry != "" &&
+			expiry != fmt.Sprintf("%02d%02d", card.ExpiryYear%100, card.ExpiryMonth) {
+			return nil, nil, iso8583.RespExpiredCard
+		}
+		accountID = card.AccountID
+
+	case req.Has(iso8583.FieldAccount) && withoutCard:
+		id, ok := models.ParseAccountNumber(req.Get(iso8583.FieldAccount))
+		if !ok {
+			return nil, nil, iso8583.RespInvalidCard
+		}
+		accountID = id
+
+	default:
+		return nil, nil, iso8583.RespFormatError
+	}
+
+	account, err := p.accounts.GetAccount(accountID)
+	if errors.Is(err, models.ErrAccountNotFound) {
+		return nil, nil, iso8583.RespInvalidCard
+	}
+	if err != nil {
+		log.Printf("iso8583: account lookup: %v", err)
+		return nil, nil, iso8583.RespSystemError
+	}
+	return account, card, ""
+}
+
+func (p *Processor) balanceInquiry(req, resp *iso8583.Message) string {
+	account, _, code := p.resolveAccount(req, true)
+	if code != "" {
+		return code
+	}
+
+	currency := iso8583.NumericCurrency(account.Currency)
+	if currency == "" {
+		currency = req.Get(iso8583.FieldCurrency)
+	}
+	resp.Set(iso8583.FieldAdditionalAmounts,
+		iso8583.AdditionalAmount("00", "01", currency, account.Balance)+
+			iso8583.AdditionalAmount("00", "02", currency, account.Balance-account.HeldAmount))
+	return iso8583.RespApproved
+}
+
+// withdrawal posts a cash withdrawal or card-present purchase. A message
+// retransmitted with the same terminal and RRN gets the original answer.
+func (p *Processor) withdrawal(req, resp *iso8583.Message) string {
+	terminalID := req.Get(iso8583.FieldTerminalID)
+	rrn := req.Get(iso8583.FieldRRN)
+	if terminalID == "" || rrn == "" || !req.Has(iso8583.FieldAmount) {
+		return iso8583.RespFormatError
+	}
+
+	existing, err := p.repo.GetByTerminalRRN(terminalID, rrn)
+	if err != nil {
+		log.Printf("iso8583: duplicate check: %v", err)
+		return iso8583.RespSystemError
+	}
+	if existing != nil {
+		if existing.AuthCode != "" {
+			resp.Set(iso8583.FieldAuthCode, existing.AuthCode)
+		}
+		return existing.ResponseCode
+	}
+
+	amount, err := iso8583.ParseAmount(req.Get(iso8583.FieldAmount))
+	if err != nil || amount <= 0 {
+		return iso8583.RespInvalidAmount
+	}
+
+	record := &models.SwitchTransaction{
+		MTI:            req.MTI,
+		ProcessingCode: req.Get(iso8583.FieldProcessingCode),
+		TerminalID:     terminalID,
+		RRN:            rrn,
+		STAN:           req.Get(iso8583.FieldSTAN),
+		Amount:         amount,
+	}
+
+	account, card, code := p.resolveAccount(req, false)
+	if card != nil {
+		record.CardID = &card.ID
+	}
+	if account != nil {
+		record.AccountID = &account.ID
+	}
+
+	switch {
+	case code != "":
+	case card != nil && !cards.MCCAllowed(merchantType(req), card.AllowedMCCs, card.BlockedMCCs):
+		code = iso8583.RespRestrictedCard
+	case card != nil && card.PerTransactionLimit > 0 && amount > card.PerTransactionLimit:
+		code = respExceedsLimit
+	case req.Has(iso8583.FieldCurrency) && iso8583.NumericCurrency(account.Currency) != "" &&
+		req.Get(iso8583.FieldCurrency) != iso8583.NumericCurrency(account.Currency):
+		code = iso8583.RespInvalidTransaction
+	default:
+		code = p.checkCustomer(account, amount)
+	}
+	if code != "" {
+		record.ResponseCode = code
+		if err := p.repo.Create(record); err != nil {
+			log.Printf("iso8583: record declined withdrawal: %v", err)
+		}
+		return code
+	}
+
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		// a terminal cannot challenge the cardholder or wait for review, so
+		// anything but allow is declined
+		if p.fraud != nil {
+			decision, err := p.fraud.Assess(tx, services.FraudInput{
+				Operation: models.FraudOpWithdrawal,
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+			if err != nil {
+				return err
+			}
+			if decision.Action != models.FraudAllow {
+				log.Printf("iso8583: withdrawal %s/%s declined by fraud rules: %v", terminalID, rrn, decision.Rules)
+				return models.ErrSuspectedFraud
+			}
+		}
+
+		// posting locks the account, which serialises the daily limit
+		// check for the card on it
+		posted, err := p.accounts.PostWithdrawal(tx, account.ID, amount)
+		if err != nil {
+			return err
+		}
+		if err := p.checkDailyLimit(tx, card, amount); err != nil {
+			return err
+		}
+		authCode, err := cards.RandomDigits(6)
+		if err != nil {
+			return err
+		}
+		record.ResponseCode = iso8583.RespApproved
+		record.AuthCode = authCode
+		record.TransactionID = &posted.ID
+		return p.repo.WithTx(tx).Create(record)
+	})
+
+	switch {
+	case err == nil:
+		resp.Set(iso8583.FieldAuthCode, record.AuthCode)
+		return iso8583.RespApproved
+	case errors.Is(err, models.ErrInsufficientFunds), errors.Is(err, models.ErrSuspectedFraud),
+		errors.Is(err, errDailyLimit):
+		code := iso8583.RespInsufficientFunds
+		switch {
+		case errors.Is(err, models.ErrSuspectedFraud):
+			code = iso8583.RespSuspectedFraud
+		case errors.Is(err, errDailyLimit):
+			code = respExceedsLimit
+		}
+		record.ResponseCode = code
+		record.AuthCode = ""
+		record.TransactionID = nil
+		if err := p.repo.Create(record); err != nil {
+			log.Printf("iso8583: record declined withdrawal: %v", err)
+		}
+		return code
+	default:
+		log.Printf("iso8583: withdrawal %s/%s: %v", terminalID, rrn, err)
+		return iso8583.RespSystemError
+	}
+}
+
+// merchantType is the request's merchant category (field 18). A cash
+// withdrawal sent without one is taken to come from an ATM.
+func merchantType(req *iso8583.Message) string {
+	if mcc := req.Get(iso8583.FieldMerchantType); mcc != "" {
+		return mcc
+	}
+	if processingType(req) == iso8583.ProcCashWithdrawal {
+		return cards.MCCCashWithdrawal
+	}
+	return ""
+}
+
+// checkCustomer turns away an account owner who is blocked or under
+// sanctions review, and holds them to the limits on customers who are not
+// verified, returning the decline code or "".
+func (p *Processor) checkCustomer(account *models.Account, amount float64) string {
+	err := services.CheckScreening(p.customers, account.CustomerID)
+	switch {
+	case errors.Is(err, models.ErrSanctionsBlocked), errors.Is(err, models.ErrSanctionsUnderReview):
+		return iso8583.RespNotPermitted
+	case err != nil:
+		log.Printf("iso8583: screening check: %v", err)
+		return iso8583.RespSystemError
+	}
+
+	if p.kyc == nil {
+		return ""
+	}
+	err = p.kyc.CheckTransfer(account.CustomerID, amount)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, models.ErrKYCLimitExceeded):
+		return respExceedsLimit
+	case errors.Is(err, models.ErrKYCRejected):
+		return iso8583.RespRestrictedCard
+	}
+	log.Printf("iso8583: kyc check: %v", err)
+	return iso8583.RespSystemError
+}
+
+var errDailyLimit = errors.New("exceeds the card's daily limit")
+
+// checkDailyLimit refuses amount when, with what the card already spent
+// today at terminals and in card authorizations, it goes over the card's
+// daily limit.
+func (p *Processor) checkDailyLimit(tx *gorm.DB, card *models.Card, amount float64) error {
+	if card.DailyLimit <= 0 {
+		return nil
+	}
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	withdrawn, err := p.repo.WithTx(tx).SpentSince(card.ID, startOfDay)
+	if err != nil {
+		return err
+	}
+	authorized, err := p.cards.SpentSince(card.ID, startOfDay)
+	if err != nil {
+		return err
+	}
+	if withdrawn+authorized+amount > card.DailyLimit {
+		return errDailyLimit
+	}
+	return nil
+}
+
+// reversal undoes the withdrawal with the same terminal and RRN. Reversing
+// something that was declined or already reversed is acknowledged without
+// further effect, so the switch can safely repeat reversal advices.
+func (p *Processor) reversal(req *iso8583.Message) string {
+	terminalID := req.Get(iso8583.FieldTerminalID)
+	rrn := req.Get(iso8583.FieldRRN)
+	if terminalID == "" || rrn == "" {
+		return iso8583.RespFormatError
+	}
+
+	code := iso8583.RespApproved
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		repo := p.repo.WithTx(tx)
+
+		original, err := repo.LockByTerminalRRN(terminalID, rrn)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			code = iso8583.RespOriginalNotFound
+			return nil
+		}
+		if original.TransactionID == nil || original.ReversedAt != nil {
+			return nil
+		}
+
+		reversal, err := p.accounts.PostWithdrawalReversal(tx, *original.TransactionID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		original.ReversalTransactionID = &reversal.ID
+		original.ReversedAt = &now
+		return repo.Update(original)
+	})
+	if err != nil {
+		log.Printf("iso8583: reversal %s/%s: %v", terminalID, rrn, err)
+		return iso8583.RespSystemError
+	}
+	return code
+}
@@ -0,0 +1,126 @@
+package atmswitch
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/iso8583"
+)
+
+// Server accepts switch connections. Each connection carries a sequence
+// of length-prefixed requests answered in order.
+type Server struct {
+	Addr      string
+	Processor *Processor
+	// TLSConfig, when set, makes the listener speak TLS; with
+	// ServerTLSConfig only switches holding a trusted client certificate
+	// get through the handshake.
+	TLSConfig *tls.Config
+	// AllowedPeers, when not empty, are the only addresses connections
+	// are accepted from.
+	AllowedPeers []*net.IPNet
+	// IdleTimeout closes connections that send nothing for this long;
+	// switches keep links alive with 0800 echo messages.
+	IdleTimeout time.Duration
+}
+
+// handshakeTimeout bounds how long a TLS client has to authenticate.
+const handshakeTimeout = 10 * time.Second
+
+func (s *Server) ListenAndServe() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if s.TLSConfig != nil {
+		ln = tls.NewListener(ln, s.TLSConfig)
+	}
+	log.Printf("iso8583 listener on %s", ln.Addr())
+	return s.Serve(ln)
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	defer ln.Close()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		if !allowed(s.AllowedPeers, conn.RemoteAddr()) {
+			log.Printf("iso8583: refused connection from %s", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		// fail an unauthenticated peer now rather than on its first read
+		conn.SetDeadline(time.Now().Add(handshakeTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			log.Printf("iso8583 %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+		conn.SetDeadline(time.Time{})
+	}
+	for {
+		if s.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		}
+		data, err := iso8583.ReadFrame(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("iso8583 %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		resp := s.handle(data)
+		if resp == nil {
+			continue
+		}
+		out, err := resp.Pack()
+		if err != nil {
+			log.Printf("iso8583 %s: pack %s: %v", conn.RemoteAddr(), resp.MTI, err)
+			continue
+		}
+		if err := iso8583.WriteFrame(conn, out); err != nil {
+			log.Printf("iso8583 %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// handle answers one frame. A message that cannot be decoded gets a
+// format-error response if at least its MTI is readable.
+func (s *Server) handle(data []byte) *iso8583.Message {
+	req, err := iso8583.Unpack(data)
+	if err == nil {
+		return s.Processor.Handle(req)
+	}
+
+	log.Printf("iso8583: %v", err)
+	if len(data) < 4 {
+		return nil
+	}
+	for _, b := range data[:4] {
+		if b < '0' || b > '9' {
+			return nil
+		}
+	}
+	resp := iso8583.NewMessage(string(data[:4])).Response()
+	resp.Set(iso8583.FieldResponseCode, iso8583.RespFormatError)
+	return resp
+}
@@ -0,0 +1,95 @@
+// Package audit keeps the append-only record of state-changing operations.
+// Middleware gives every request an ID and writes one entry per mutating
+// request; services write further entries, with before and after values,
+// inside the same transaction as the change, tagged with the Meta of the
+// request that caused it.
+package audit
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Meta says who is acting and from where. The zero Meta is the system
+// itself, e.g. a background job.
+type Meta struct {
+	ActorType string
+	ActorID   int
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// Entry is a change to one entity. Before and After are marshalled to
+// JSON; either may be nil.
+type Entry struct {
+	Action     string
+	EntityType string
+	EntityID   int
+	Before     any
+	After      any
+}
+
+// Recorder writes audit entries as part of a transaction.
+type Recorder interface {
+	Record(tx *gorm.DB, meta Meta, entry Entry) error
+}
+
+// Log is the Recorder backed by the audit table.
+type Log struct {
+	repo repositories.AuditRepository
+}
+
+func NewLog(repo repositories.AuditRepository) *Log {
+	return &Log{repo: repo}
+}
+
+func (l *Log) Record(tx *gorm.DB, meta Meta, entry Entry) error {
+	before, err := marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshal(entry.After)
+	if err != nil {
+		return err
+	}
+
+	row := newRow(meta)
+	row.Action = entry.Action
+	row.EntityType = entry.EntityType
+	if entry.EntityID != 0 {
+		row.EntityID = strconv.Itoa(entry.EntityID)
+	}
+	row.Before = before
+	row.After = after
+	return l.repo.WithTx(tx).Append(row)
+}
+
+func newRow(meta Meta) *models.AuditLog {
+	actorType := meta.ActorType
+	if actorType == "" {
+		actorType = models.ActorSystem
+	}
+	return &models.AuditLog{
+		ActorType: actorType,
+		ActorID:   meta.ActorID,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		RequestID: meta.RequestID,
+	}
+}
+
+func marshal(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions. A caller's
+// own ID is kept so that its logs and ours line up.
+const RequestIDHeader = "X-Request-ID"
+
+const metaKey = "audit.meta"
+
+// ActorFunc identifies the caller of a request. It returns an empty type
+// for anonymous callers.
+type ActorFunc func(c *gin.Context) (actorType string, actorID int)
+
+// Middleware assigns the request ID, makes the request's Meta available to
+// handlers through FromContext, and after a POST, PUT, PATCH or DELETE
+// appends an entry with the route and response status. Failing to write
+// that entry is logged; the response has already been sent.
+func (l *Log) Middleware(actor ActorFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		meta := &Meta{
+			ActorType: models.ActorAnonymous,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: requestID,
+		}
+		if actorType, actorID := actor(c); actorType != "" {
+			meta.ActorType, meta.ActorID = actorType, actorID
+		}
+		c.Set(metaKey, meta)
+
+		c.Next()
+
+		if !mutating(c.Request.Method) {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		row := newRow(*meta)
+		row.Action = "http.request"
+		row.Method = c.Request.Method
+		row.Path = route
+		row.Status = c.Writer.Status()
+		if err := l.repo.Append(row); err != nil {
+			log.Printf("audit %s %s (request %s): %v", row.Method, row.Path, requestID, err)
+		}
+	}
+}
+
+// As overrides the actor for a route group whose callers are not
+// customers, such as the back office.
+func As(actorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		SetActor(c, actorType, 0)
+		c.Next()
+	}
+}
+
+// SetActor records who the caller turned out to be, e.g. after login.
+func SetActor(c *gin.Context, actorType string, actorID int) {
+	if meta, ok := c.Get(metaKey); ok {
+		m := meta.(*Meta)
+		m.ActorType, m.ActorID = actorType, actorID
+	}
+}
+
+// FromContext returns the request's Meta, or a Meta with just the client
+// address when the middleware is not installed.
+func FromContext(c *gin.Context) Meta {
+	if meta, ok := c.Get(metaKey); ok {
+		return *meta.(*Meta)
+	}
+	return Meta{ActorType: models.ActorAnonymous, IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
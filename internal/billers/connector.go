@@ -0,0 +1,26 @@
+// Package billers connects bill payments to the billers that receive them.
+// A Connector accepts a payment and reports later, through a ResultHandler,
+// whether the biller confirmed or rejected it.
+package billers
+
+import "github.com/Mahesh252k/banking-api/internal/models"
+
+// Result is the biller's answer for a submitted payment.
+type Result struct {
+	Reference string
+	Confirmed bool
+	Reason    string
+}
+
+// ResultHandler is called by a connector when a biller answers.
+type ResultHandler func(result Result) error
+
+type Connector interface {
+	Name() string
+	// Submit sends the payment to the biller under payment.ConnectorReference,
+	// which the biller echoes in its Result. Submitting the same reference
+	// again must not pay twice.
+	Submit(payment *models.BillPayment, biller *models.Biller, registration *models.BillerRegistration) error
+	// OnResult registers the handler that receives confirmations and rejections.
+	OnResult(handler ResultHandler)
+}
@@ -0,0 +1,57 @@
+package billers
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// FakeConnector is a local Connector for development. It confirms every
+// payment after Delay, except payments for consumer numbers ending in
+// "000", which it rejects as an unknown consumer.
+type FakeConnector struct {
+	Delay time.Duration
+
+	mu      sync.RWMutex
+	handler ResultHandler
+}
+
+func NewFakeConnector(delay time.Duration) *FakeConnector {
+	return &FakeConnector{Delay: delay}
+}
+
+func (f *FakeConnector) Name() string {
+	return "fake"
+}
+
+func (f *FakeConnector) OnResult(handler ResultHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handler = handler
+}
+
+func (f *FakeConnector) Submit(payment *models.BillPayment, biller *models.Biller, registration *models.BillerRegistration) error {
+	ref := payment.ConnectorReference
+
+	result := Result{Reference: ref, Confirmed: true}
+	if strings.HasSuffix(registration.ConsumerNumber, "000") {
+		result = Result{Reference: ref, Reason: "unknown consumer number"}
+	}
+
+	time.AfterFunc(f.Delay, func() {
+		f.mu.RLock()
+		handler := f.handler
+		f.mu.RUnlock()
+
+		if handler == nil {
+			return
+		}
+		if err := handler(result); err != nil {
+			log.Printf("fake biller connector: failed to apply result for %s: %v", ref, err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,44 @@
+package cards
+
+import (
+	"sort"
+	"strings"
+)
+
+// MCCCashWithdrawal is the merchant category of ATM cash withdrawals.
+const MCCCashWithdrawal = "6011"
+
+// ParseMCCList turns "5411, 5812" into its set of codes.
+func ParseMCCList(list string) map[string]bool {
+	codes := map[string]bool{}
+	for _, code := range strings.Split(list, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes[code] = true
+		}
+	}
+	return codes
+}
+
+// NormalizeMCCList validates a comma-separated MCC list and returns it in
+// canonical form. Every code must be four digits.
+func NormalizeMCCList(list string) (string, bool) {
+	var out []string
+	for code := range ParseMCCList(list) {
+		if len(code) != 4 || !isDigits(code) {
+			return "", false
+		}
+		out = append(out, code)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ","), true
+}
+
+// MCCAllowed applies a card's restrictions: a blocked code always loses,
+// and a non-empty allow list admits only the codes it names.
+func MCCAllowed(mcc, allowed, blocked string) bool {
+	if ParseMCCList(blocked)[mcc] {
+		return false
+	}
+	allow := ParseMCCList(allowed)
+	return len(allow) == 0 || allow[mcc]
+}
@@ -0,0 +1,106 @@
+// Package cards holds the card-number primitives used by the card
+// subsystem: PAN generation with a Luhn check digit, masking, keyed
+// hashing of PAN and CVV, and merchant category code lists.
+package cards
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// LuhnValid reports whether number (digits only) has a valid Luhn check digit.
+func LuhnValid(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// luhnCheckDigit returns the digit that makes partial+digit Luhn-valid.
+func luhnCheckDigit(partial string) byte {
+	for d := byte('0'); d <= '9'; d++ {
+		if LuhnValid(partial + string(d)) {
+			return d
+		}
+	}
+	return '0' // unreachable for digit-only input
+}
+
+// RandomDigits returns n cryptographically random decimal digits.
+func RandomDigits(n int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + d.Int64()))
+	}
+	return b.String(), nil
+}
+
+// GeneratePAN returns a random 16-digit PAN starting with bin.
+func GeneratePAN(bin string) (string, error) {
+	if len(bin) < 6 || len(bin) > 8 || !isDigits(bin) {
+		return "", errors.New("card BIN must be 6 to 8 digits")
+	}
+	body, err := RandomDigits(15 - len(bin))
+	if err != nil {
+		return "", err
+	}
+	partial := bin + body
+	return partial + string(luhnCheckDigit(partial)), nil
+}
+
+// MaskPAN keeps the first six and last four digits.
+func MaskPAN(pan string) string {
+	if len(pan) < 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// Hash returns a hex HMAC-SHA256 of the joined parts under key. PANs and
+// CVVs are stored this way so a card can be looked up by PAN without
+// keeping the PAN itself.
+func Hash(key []byte, parts ...string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizePAN strips spaces and dashes from a card number.
+func NormalizePAN(pan string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(pan))
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// LoadDotEnv loads the first .env file found in the working directory or
+// up to two parent directories. It only logs when nothing was found, so
+// binaries can still be configured purely through the environment.
+func LoadDotEnv() {
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal("cannot get working directory:", err)
+	}
+
+	candidates := []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, "..", ".env"),
+		filepath.Join(dir, "..", "..", ".env"),
+	}
+
+	for _, p := range candidates {
+		log.Printf("Loading environment variables from %s", p)
+		if err := godotenv.Overload(p); err == nil {
+			log.Println("successfully loaded .env file")
+			return
+		} else {
+			log.Printf("warning: could not load .env from %s: %v", p, err)
+		}
+	}
+
+	log.Println("warning: .env file was not loaded from any known location")
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// String returns the value of key, or def when it is unset.
+func String(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Int returns key parsed as an int, or def when it is unset or invalid.
+func Int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("warning: %s=%q is not an integer, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// Float returns key parsed as a float64, or def when it is unset or invalid.
+func Float(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("warning: %s=%q is not a number, using %v", key, v, def)
+		return def
+	}
+	return f
+}
+
+// Duration returns key parsed with time.ParseDuration (e.g. "24h"), or def
+// when it is unset or invalid.
+func Duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("warning: %s=%q is not a duration, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// Bool returns key parsed with strconv.ParseBool, or def when it is unset or invalid.
+func Bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("warning: %s=%q is not a boolean, using %v", key, v, def)
+		return def
+	}
+	return b
+}
@@ -15,7 +15,10 @@ func Connect() *gorm.DB {
 		log.Fatal("DB_DSN not loaded. Ensure .env is loaded")
 	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// unique-index violations come back as gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
@@ -29,6 +32,51 @@ func Connect() *gorm.DB {
 		&models.Loan{},
 		&models.LoanPayment{},
 		&models.Beneficiary{},
+		&models.OutgoingPayment{},
+		&models.ClearingFile{},
+		&models.InboundCredit{},
+		&models.BulkTransferBatch{},
+		&models.BulkTransferItem{},
+		&models.Biller{},
+		&models.BillerRegistration{},
+		&models.BillPayment{},
+		&models.Card{},
+		&models.CardAuthorization{},
+		&models.SwitchTransaction{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.CustomerDevice{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.OutboxEvent{},
+		&models.OutboxConsumer{},
+		&models.AuditLog{},
+		&models.JournalHead{},
+		&models.JournalCheckpoint{},
+		&models.FraudRule{},
+		&models.HeldTransfer{},
+		&models.SanctionsHit{},
+		&models.SanctionsList{},
+		&models.KYCDocument{},
+		&models.ContactChange{},
+		&models.ProfileChange{},
+		&models.PasswordResetToken{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.Role{},
+		&models.RolePermission{},
+		&models.StaffUser{},
+		&models.StaffRole{},
+		&models.TOTPFactor{},
+		&models.RecoveryCode{},
+		&models.LoginThrottle{},
+		&models.RateLimitBucket{},
+		&models.APIClient{},
+		&models.APIClientScope{},
+		&models.Consent{},
+		&models.ConsentPermission{},
+		&models.ConsentAccount{},
 	); err != nil {
 		log.Fatalf("failed to migrate database schema: %v", err)
 	}
@@ -0,0 +1,58 @@
+// Package events describes the domain events services record when state
+// changes, for the subsystems that react to them (notifications, webhooks,
+// external consumers). Events travel through the transactional outbox; see
+// package outbox.
+package events
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event types. The names are part of the public webhook contract.
+const (
+	TransferCompleted = "transfer.completed"
+	TransferReceived  = "transfer.received"
+	DepositReceived   = "deposit.received"
+	LoanCreated       = "loan.created"
+	LoanPaymentPaid   = "loan_payment.paid"
+	LoanEMIDue        = "loan_emi.due"
+	LoanEMIOverdue    = "loan_emi.overdue"
+	LoginNewDevice    = "login.new_device"
+	KYCExpiring       = "kyc.expiring"
+	KYCExpired        = "kyc.expired"
+	ProfileUpdated    = "profile.updated"
+	PasswordChanged   = "password.changed"
+	MFAEnabled        = "mfa.enabled"
+	MFADisabled       = "mfa.disabled"
+)
+
+// WebhookTypes are the event types partners may subscribe to.
+var WebhookTypes = []string{
+	TransferCompleted, TransferReceived, DepositReceived,
+	LoanCreated, LoanPaymentPaid, LoanEMIDue, LoanEMIOverdue,
+}
+
+// Event is something that happened to one customer's data.
+type Event struct {
+	// ID is assigned when the event is recorded. IDs increase, and
+	// consumers track their position by the last ID they handled.
+	ID         int64          `json:"id"`
+	Type       string         `json:"type"`
+	CustomerID int            `json:"customer_id"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// New builds an event stamped with the current time.
+func New(eventType string, customerID int, data map[string]any) Event {
+	return Event{Type: eventType, CustomerID: customerID, OccurredAt: time.Now().UTC(), Data: data}
+}
+
+// Recorder stores an event as part of the caller's database transaction,
+// so the event exists if and only if the state change commits. A relay
+// delivers recorded events to their consumers afterwards.
+type Recorder interface {
+	Record(tx *gorm.DB, e Event) error
+}
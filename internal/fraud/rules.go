@@ -0,0 +1,106 @@
+// Package fraud evaluates the configurable fraud rules against a transfer
+// or withdrawal before it is posted. The strictest outcome of all rules
+// that fire wins: hold over challenge over allow.
+package fraud
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// Operation is what is about to be posted.
+type Operation struct {
+	Type   string
+	Amount float64
+	// Now is the local time the unusual-hour rules compare against.
+	Now time.Time
+}
+
+// Facts answers questions about the account's recent activity. They are
+// only asked for when a rule needs them.
+type Facts interface {
+	// Debits counts and sums money leaving the account since the given time.
+	Debits(since time.Time) (int64, float64, error)
+	// Credits sums money arriving in the account since the given time.
+	Credits(since time.Time) (float64, error)
+	// NewPayee reports whether the account has never paid the destination.
+	NewPayee() (bool, error)
+}
+
+var severity = map[string]int{
+	models.FraudAllow:     1,
+	models.FraudChallenge: 2,
+	models.FraudHold:      3,
+}
+
+// Evaluate runs the active rules for op and combines the ones that fire.
+// With none firing the decision is allow with no rules.
+func Evaluate(rules []models.FraudRule, op Operation, facts Facts) (*models.FraudDecision, error) {
+	decision := &models.FraudDecision{Action: models.FraudAllow}
+	for i := range rules {
+		rule := &rules[i]
+		if !applies(rule, op) {
+			continue
+		}
+		fired, err := fires(rule, op, facts)
+		if err != nil {
+			return nil, err
+		}
+		if !fired {
+			continue
+		}
+		decision.Rules = append(decision.Rules, rule.Name)
+		if severity[rule.Action] > severity[decision.Action] {
+			decision.Action = rule.Action
+		}
+	}
+	return decision, nil
+}
+
+func applies(rule *models.FraudRule, op Operation) bool {
+	if !rule.Active || op.Amount < rule.MinAmount {
+		return false
+	}
+	return rule.Operation == models.FraudOpAny || rule.Operation == op.Type
+}
+
+func fires(rule *models.FraudRule, op Operation, facts Facts) (bool, error) {
+	since := op.Now.Add(-rule.Window())
+
+	switch rule.Type {
+	case models.FraudVelocityCount:
+		count, _, err := facts.Debits(since)
+		return float64(count+1) > rule.Threshold, err
+
+	case models.FraudVelocityAmount:
+		_, total, err := facts.Debits(since)
+		return total+op.Amount > rule.Threshold, err
+
+	case models.FraudNewPayee:
+		if op.Type != models.FraudOpTransfer {
+			return false, nil
+		}
+		return facts.NewPayee()
+
+	case models.FraudUnusualHour:
+		return inHours(op.Now.Hour(), rule.StartHour, rule.EndHour), nil
+
+	case models.FraudRapidInOut:
+		credits, err := facts.Credits(since)
+		if err != nil || credits <= 0 {
+			return false, err
+		}
+		return op.Amount >= rule.Threshold*credits, nil
+	}
+	return false, nil
+}
+
+// inHours reports whether hour falls in [start, end), wrapping past
+// midnight when start > end.
+func inHours(hour, start, end int) bool {
+	if start <= end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
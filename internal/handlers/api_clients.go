@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API CLIENTS
+
+func apiClientErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrAPIClientNotFound), errors.Is(err, models.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrUnknownScope), errors.Is(err, models.ErrProviderScopes),
+		errors.Is(err, models.ErrNoScopes):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+const apiClientKey = "api_client"
+
+// ClientRateLimit limits each API client to its own rate, or to
+// RATE_LIMIT_CLIENT_PER_MINUTE and RATE_LIMIT_CLIENT_BURST when it has
+// none. Other callers pass through. It must run after AuthMiddleware.
+func ClientRateLimit() gin.HandlerFunc {
+	fallback := ratelimit.PerMinute(
+		config.Float("RATE_LIMIT_CLIENT_PER_MINUTE", 600),
+		config.Int("RATE_LIMIT_CLIENT_BURST", 100),
+	)
+	return ratelimit.Dynamic(rateLimitStore, "client", func(c *gin.Context) (string, ratelimit.Limit) {
+		v, ok := c.Get(apiClientKey)
+		if !ok {
+			return "", ratelimit.Limit{}
+		}
+		client := v.(*models.APIClient)
+		limit := fallback
+		if client.RateLimitPerMinute > 0 {
+			limit = ratelimit.PerMinute(client.RateLimitPerMinute, fallback.Burst)
+		}
+		if client.RateLimitBurst > 0 {
+			limit.Burst = client.RateLimitBurst
+		}
+		return client.ClientID, limit
+	})
+}
+
+// oauthError writes an error response in the form of RFC 6749 section 5.2.
+func oauthError(c *gin.Context, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="api"`)
+	}
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// IssueClientToken is the OAuth2 token endpoint. It takes a form with
+// grant_type=client_credentials and an optional scope, or a consent_id
+// for a third-party provider's consent-bound token; the client
+// authenticates with HTTP Basic or client_id and client_secret fields.
+func IssueClientToken(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if grant := c.PostForm("grant_type"); grant != "client_credentials" {
+		if grant == "" {
+			oauthError(c, http.StatusBadRequest, "invalid_request", "grant_type is required")
+			return
+		}
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+	clientID, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "client credentials are required")
+		return
+	}
+
+	if consentID := c.PostForm("consent_id"); consentID != "" {
+		issueConsentToken(c, clientID, secret, consentID)
+		return
+	}
+
+	token, err := apiClientSvc.WithAudit(audit.FromContext(c)).Token(clientID, secret, c.PostForm("scope"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidClient):
+			oauthError(c, http.StatusUnauthorized, "invalid_client", err.Error())
+		case errors.Is(err, models.ErrInvalidScope):
+			oauthError(c, http.StatusBadRequest, "invalid_scope", err.Error())
+		case errors.Is(err, models.ErrSanctionsBlocked):
+			oauthError(c, http.StatusBadRequest, "unauthorized_client", err.Error())
+		default:
+			oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		}
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// admin: every registered client
+func ListAPIClients(c *gin.Context) {
+	clients, err := apiClientSvc.ListClients()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clients)
+}
+
+func GetAPIClient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	client, err := apiClientSvc.GetClient(id)
+	if err != nil {
+		c.JSON(apiClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
+
+// admin: register a client for a customer; the secret is only shown here
+func CreateAPIClient(c *gin.Context) {
+	var req models.CreateAPIClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := apiClientSvc.WithAudit(audit.FromContext(c)).CreateClient(&req)
+	if err != nil {
+		c.JSON(apiClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func UpdateAPIClient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	var req models.UpdateAPIClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := apiClientSvc.WithAudit(audit.FromContext(c)).UpdateClient(id, &req)
+	if err != nil {
+		c.JSON(apiClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
+
+// admin: issue a new secret; the old one works until the grace period ends
+func RotateAPIClientSecret(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	rotated, err := apiClientSvc.WithAudit(audit.FromContext(c)).RotateSecret(id)
+	if err != nil {
+		c.JSON(apiClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rotated)
+}
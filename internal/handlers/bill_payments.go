@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BILLERS AND BILL PAYMENTS
+
+func billErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBillerNotFound),
+		errors.Is(err, models.ErrBillerRegistrationNotFound),
+		errors.Is(err, models.ErrBillPaymentNotFound),
+		errors.Is(err, models.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicateBiller),
+		errors.Is(err, models.ErrDuplicateBillerRegistration),
+		errors.Is(err, models.ErrBillPaymentNotCancellable):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidConsumerNumber),
+		errors.Is(err, models.ErrBillerInactive):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrSuspectedFraud):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// admin: biller directory
+
+func CreateBiller(c *gin.Context) {
+	var req models.CreateBillerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biller, err := billerSvc.CreateBiller(&req)
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, biller)
+}
+
+func UpdateBiller(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid biller id"})
+		return
+	}
+
+	var req models.UpdateBillerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	biller, err := billerSvc.UpdateBiller(id, &req)
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, biller)
+}
+
+func ListAllBillers(c *gin.Context) {
+	billers, err := billerSvc.ListBillers(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, billers)
+}
+
+// customer: directory and registrations
+
+func ListBillers(c *gin.Context) {
+	billers, err := billerSvc.ListBillers(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, billers)
+}
+
+func RegisterBiller(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.RegisterBillerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	registration, err := billerSvc.RegisterBiller(&req, userID)
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, registration)
+}
+
+func ListBillerRegistrations(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	registrations, err := billerSvc.ListRegistrations(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, registrations)
+}
+
+func DeleteBillerRegistration(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid registration id"})
+		return
+	}
+
+	if err := billerSvc.DeleteRegistration(id, userID); err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "biller registration deleted"})
+}
+
+// customer: bill payments
+
+func PayBill(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.CreateBillPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.CheckScreening(customerRepo, userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err := kycSvc.CheckTransfer(userID, req.Amount); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	fresh, method, err := steppedUp(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount > stepUpTransferThreshold && !fresh {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrStepUpRequired.Error(), "step_up": method})
+		return
+	}
+	opts := services.TransferOptions{StepUpVerified: fresh}
+
+	payment, err := billPaymentSvc.WithAudit(audit.FromContext(c)).PayBill(&req, userID, opts)
+	var challenge *models.StepUpRequiredError
+	if errors.As(err, &challenge) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "step_up": method, "rules": challenge.Rules})
+		return
+	}
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
+
+func ListBillPayments(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	payments, err := billPaymentSvc.ListPayments(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payments)
+}
+
+func GetBillPayment(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bill payment id"})
+		return
+	}
+
+	payment, err := billPaymentSvc.GetPayment(id, userID)
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+func CancelBillPayment(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bill payment id"})
+		return
+	}
+
+	payment, err := billPaymentSvc.CancelScheduled(id, userID)
+	if err != nil {
+		c.JSON(billErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BULK TRANSFERS
+
+func bulkTransferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound), errors.Is(err, models.ErrBulkBatchNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrSuspectedFraud):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrBulkBatchTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateBulkTransfer accepts either a JSON body ({"items": [...]}), a raw
+// text/csv body, or a multipart upload with the CSV in the "file" field.
+func CreateBulkTransfer(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+		return
+	}
+
+	if err := services.CheckScreening(customerRepo, userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	// bulk payouts are for verified customers only
+	if err := kycSvc.RequireVerified(userID); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []models.BulkTransferRow
+	fileName := ""
+	contentType := c.ContentType()
+	switch {
+	case strings.HasPrefix(contentType, "multipart/"):
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		fileName = fh.Filename
+		rows, err = services.ParseBulkTransferCSV(f)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	case contentType == "text/csv":
+		rows, err = services.ParseBulkTransferCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+	default:
+		var req models.CreateBulkTransferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rows = req.Items
+	}
+
+	// a batch is held to the same step-up threshold as a single transfer
+	var total float64
+	for _, row := range rows {
+		total += row.Amount
+	}
+	fresh, method, err := steppedUp(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if total > stepUpTransferThreshold && !fresh {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrStepUpRequired.Error(), "step_up": method})
+		return
+	}
+	opts := services.TransferOptions{StepUpVerified: fresh}
+
+	batch, err := bulkTransferSvc.WithAudit(audit.FromContext(c)).CreateBatch(userID, accountID, fileName, rows, opts)
+	if err != nil {
+		var validationErr *models.BulkValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "rows": validationErr.Rows})
+			return
+		}
+		var challenge *models.StepUpRequiredError
+		if errors.As(err, &challenge) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "step_up": method, "rules": challenge.Rules})
+			return
+		}
+		c.JSON(bulkTransferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, batch)
+}
+
+func GetBulkTransfer(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch id"})
+		return
+	}
+
+	batch, err := bulkTransferSvc.GetBatch(id, userID)
+	if err != nil {
+		c.JSON(bulkTransferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+func DownloadBulkTransferReport(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch id"})
+		return
+	}
+
+	// check access before any CSV is written, so errors can still be JSON
+	if _, err := bulkTransferSvc.GetBatch(id, userID); err != nil {
+		c.JSON(bulkTransferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=bulk-transfer-%d.csv", id))
+	if err := bulkTransferSvc.WriteReport(id, userID, c.Writer); err != nil {
+		c.Status(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DEBIT CARDS
+
+func cardErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrCardNotFound),
+		errors.Is(err, models.ErrCardAuthorizationNotFound),
+		errors.Is(err, models.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrCardNotBlockable),
+		errors.Is(err, models.ErrCardNotUnblockable),
+		errors.Is(err, models.ErrCardAuthorizationState):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrCaptureExceedsAuthorized),
+		errors.Is(err, models.ErrInvalidMCCList):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrCardsNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// customer: card management
+
+func IssueCard(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return
+	}
+
+	var req models.IssueCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card, err := cardSvc.IssueCard(&req, userID, accountID)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, card)
+}
+
+func ListCards(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	cards, err := cardSvc.ListCards(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cards)
+}
+
+func GetCard(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid card id"})
+		return
+	}
+
+	card, err := cardSvc.GetCard(id, userID)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+func UpdateCard(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid card id"})
+		return
+	}
+
+	var req models.UpdateCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card, err := cardSvc.UpdateCard(id, userID, &req)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+func BlockCard(c *gin.Context) {
+	changeCardStatus(c, true)
+}
+
+func UnblockCard(c *gin.Context) {
+	changeCardStatus(c, false)
+}
+
+func changeCardStatus(c *gin.Context, block bool) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid card id"})
+		return
+	}
+
+	var card *models.Card
+	if block {
+		card, err = cardSvc.BlockCard(id, userID)
+	} else {
+		card, err = cardSvc.UnblockCard(id, userID)
+	}
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+func ListCardAuthorizations(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid card id"})
+		return
+	}
+
+	auths, err := cardSvc.ListAuthorizations(id, userID)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, auths)
+}
+
+// card network: authorization, capture and reversal
+
+// AuthorizeCard always answers 200 with the authorization; a decline is a
+// normal outcome carried in its status and decline_reason.
+func AuthorizeCard(c *gin.Context) {
+	var req models.CardAuthorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorization, err := cardSvc.Authorize(&req)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, authorization)
+}
+
+func CaptureCardAuthorization(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid authorization id"})
+		return
+	}
+
+	var req models.CardCaptureRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	authorization, err := cardSvc.Capture(id, req.Amount)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, authorization)
+}
+
+func ReverseCardAuthorization(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid authorization id"})
+		return
+	}
+
+	authorization, err := cardSvc.Reverse(id)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, authorization)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FRAUD RULES AND REVIEW QUEUE
+
+func fraudErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrFraudRuleNotFound),
+		errors.Is(err, models.ErrHeldTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicateFraudRule),
+		errors.Is(err, models.ErrHeldTransferNotPending):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// admin: rules
+
+func ListFraudRules(c *gin.Context) {
+	rules, err := fraudSvc.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func CreateFraudRule(c *gin.Context) {
+	var req models.CreateFraudRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := fraudSvc.CreateRule(&req)
+	if err != nil {
+		c.JSON(fraudErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+func UpdateFraudRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule id"})
+		return
+	}
+
+	var req models.UpdateFraudRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := fraudSvc.UpdateRule(id, &req)
+	if err != nil {
+		c.JSON(fraudErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// admin: review queue
+
+func ListHeldTransfers(c *gin.Context) {
+	status := c.DefaultQuery("status", models.HeldTransferPending)
+	if status == "all" {
+		status = ""
+	}
+
+	held, err := fraudSvc.ListHeldTransfers(status, 200)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, held)
+}
+
+func GetHeldTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid held transfer id"})
+		return
+	}
+
+	held, err := fraudSvc.GetHeldTransfer(id)
+	if err != nil {
+		c.JSON(fraudErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, held)
+}
+
+func ApproveHeldTransfer(c *gin.Context) {
+	reviewHeldTransfer(c, true)
+}
+
+func RejectHeldTransfer(c *gin.Context) {
+	reviewHeldTransfer(c, false)
+}
+
+func reviewHeldTransfer(c *gin.Context, approve bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid held transfer id"})
+		return
+	}
+
+	// the note is optional, and so is the body
+	var req models.ReviewHeldTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	held, err := accountSvc.WithAudit(audit.FromContext(c)).ReviewHeldTransfer(id, approve, req.Note)
+	if err != nil {
+		c.JSON(fraudErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, held)
+}
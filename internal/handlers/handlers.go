@@ -1,12 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
-
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/billers"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/journal"
+	"github.com/Mahesh252k/banking-api/internal/kyc"
 	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/notifications"
+	"github.com/Mahesh252k/banking-api/internal/outbox"
+	"github.com/Mahesh252k/banking-api/internal/paymentrail"
+	"github.com/Mahesh252k/banking-api/internal/ratelimit"
 	"github.com/Mahesh252k/banking-api/internal/repositories"
 	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/internal/totp"
+	"github.com/Mahesh252k/banking-api/internal/webhooks"
 	"github.com/Mahesh252k/banking-api/pkg/auth"
 
 	"github.com/gin-gonic/gin"
@@ -22,21 +38,389 @@ var loanRepo repositories.LoanRepository
 var loanPaymentRepo repositories.LoanPaymentRepository
 var loanSvc services.LoanService
 var loanPaymentSvc services.LoanPaymentService
+var beneficiaryRepo repositories.BeneficiaryRepository
+var beneficiarySvc services.BeneficiaryService
+var outgoingPaymentRepo repositories.OutgoingPaymentRepository
+var outgoingPaymentSvc services.OutgoingPaymentService
+var clearingFileRepo repositories.ClearingFileRepository
+var inboundPaymentSvc services.InboundPaymentService
+var bulkTransferSvc services.BulkTransferService
+var billerSvc services.BillerService
+var billPaymentSvc services.BillPaymentService
+var cardSvc services.CardService
+var outboxRepo repositories.OutboxRepository
+var eventRecorder events.Recorder
+var outboxRelay *outbox.Relay
+var notificationSvc services.NotificationService
+var deviceSvc services.DeviceService
+var webhookSvc services.WebhookService
+var auditRepo repositories.AuditRepository
+var auditLog *audit.Log
+var journalSvc services.JournalService
+var fraudSvc services.FraudService
+var customerRepo repositories.CustomerRepository
+var sanctionsSvc services.SanctionsService
+var kycSvc services.KYCService
+var profileSvc services.ProfileService
+var passwordResetSvc services.PasswordResetService
+var sessionSvc services.SessionService
+var staffSvc services.StaffService
+var apiClientSvc services.APIClientService
+var consentSvc services.ConsentService
+var mfaSvc services.MFAService
+var lockoutSvc services.LockoutService
+var throttleRepo repositories.ThrottleRepository
+var rateLimitStore ratelimit.Store
+var signingKeys *auth.KeySet
+
+// step-up settings: how recent an authentication sensitive actions need,
+// and the transfer amount above which one is always needed
+var stepUpMaxAge time.Duration
+var stepUpTransferThreshold float64
+var mfaTokenTTL time.Duration
 
 // InitHandlers initializes all handlers with database connection
 func InitHandlers(db *gorm.DB) {
 	dbConn = db
+	var err error
+	signingKeys, err = auth.LoadKeySet(config.String("JWT_KEYS_DIR", "keys"), config.String("JWT_SIGNING_ALG", auth.AlgEdDSA))
+	if err != nil {
+		log.Fatalf("load token signing keys: %v", err)
+	}
+	if secret := config.String("JWT_LEGACY_SECRET", ""); secret != "" {
+		signingKeys.AcceptLegacySecret([]byte(secret))
+	}
+	auth.UseKeys(signingKeys)
+	outboxRepo = repositories.NewOutboxRepo(dbConn)
+	eventRecorder = outbox.NewWriter(outboxRepo)
+	auditRepo = repositories.NewAuditRepo(dbConn)
+	auditLog = audit.NewLog(auditRepo)
 
 	accountRepo = repositories.NewAccountRepo(dbConn)
 	txRepo = repositories.NewTransactionRepo(dbConn)
-	accountSvc = services.NewAccountService(dbConn, accountRepo, txRepo)
+	signer, keys, err := journal.LoadKeys(config.String("JOURNAL_SIGNING_KEY", ""), config.String("JOURNAL_VERIFY_KEYS", ""))
+	if err != nil {
+		log.Fatalf("load journal keys: %v", err)
+	}
+	journalSvc = services.NewJournalService(txRepo, repositories.NewJournalCheckpointRepo(dbConn), services.JournalConfig{
+		Signer: signer,
+		Keys:   keys,
+	})
+	location, err := time.LoadLocation(config.String("FRAUD_TIMEZONE", "Local"))
+	if err != nil {
+		log.Fatalf("load FRAUD_TIMEZONE: %v", err)
+	}
+	fraudSvc = services.NewFraudService(repositories.NewFraudRepo(dbConn), txRepo, location)
+	if err := fraudSvc.SeedDefaultRules(); err != nil {
+		log.Fatalf("seed fraud rules: %v", err)
+	}
+	accountSvc = services.NewAccountService(dbConn, accountRepo, txRepo, eventRecorder, auditLog, fraudSvc)
 
 	loanRepo = repositories.NewLoanRepo(dbConn)
 	loanPaymentRepo = repositories.NewLoanPaymentRepo(dbConn)
-	loanSvc = services.NewLoanService(dbConn, loanRepo, loanPaymentRepo)
+	loanSvc = services.NewLoanService(dbConn, loanRepo, loanPaymentRepo, eventRecorder, auditLog)
+
+	// correct order: (db, loanRepo, paymentRepo, recorder, auditor)
+	loanPaymentSvc = services.NewLoanPaymentService(dbConn, loanRepo, loanPaymentRepo, eventRecorder, auditLog)
+
+	customerRepo = repositories.NewCustomerRepo(dbConn)
+
+	throttleRepo = repositories.NewThrottleRepo(dbConn)
+	rateLimitStore = newRateLimitStore()
+	userLockout := services.LockoutPolicy{
+		FreeAttempts: config.Int("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:    config.Duration("LOGIN_BASE_DELAY", time.Second),
+		MaxDelay:     config.Duration("LOGIN_MAX_DELAY", 30*time.Second),
+		LockAfter:    config.Int("LOGIN_LOCK_AFTER", 10),
+		LockDuration: config.Duration("LOGIN_LOCK_DURATION", 15*time.Minute),
+		Window:       config.Duration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+	}
+	lockoutSvc = services.NewLockoutService(dbConn, throttleRepo, auditLog, map[string]services.LockoutPolicy{
+		services.LoginKeyUser:  userLockout,
+		services.LoginKeyStaff: userLockout,
+		// addresses are shared behind NAT, so they get far more room
+		services.LoginKeyIP: {
+			FreeAttempts: config.Int("LOGIN_IP_FREE_ATTEMPTS", 20),
+			BaseDelay:    config.Duration("LOGIN_BASE_DELAY", time.Second),
+			MaxDelay:     config.Duration("LOGIN_MAX_DELAY", 30*time.Second),
+			LockAfter:    config.Int("LOGIN_IP_LOCK_AFTER", 100),
+			LockDuration: config.Duration("LOGIN_LOCK_DURATION", 15*time.Minute),
+			Window:       config.Duration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		},
+	})
+
+	staffSvc = services.NewStaffService(dbConn, repositories.NewStaffRepo(dbConn), auditLog,
+		config.Duration("STAFF_TOKEN_TTL", 8*time.Hour))
+	if err := staffSvc.SeedRoles(); err != nil {
+		log.Fatalf("seed staff roles: %v", err)
+	}
+	if username := config.String("STAFF_BOOTSTRAP_USERNAME", ""); username != "" {
+		if err := staffSvc.Bootstrap(username, config.String("STAFF_BOOTSTRAP_PASSWORD", "")); err != nil {
+			log.Fatalf("bootstrap staff user: %v", err)
+		}
+	}
+	apiClientSvc = services.NewAPIClientService(dbConn, repositories.NewAPIClientRepo(dbConn), customerRepo, auditLog,
+		services.APIClientConfig{
+			TokenTTL:    config.Duration("CLIENT_TOKEN_TTL", time.Hour),
+			SecretGrace: config.Duration("CLIENT_SECRET_GRACE", 24*time.Hour),
+		})
+	consentSvc = services.NewConsentService(dbConn, repositories.NewConsentRepo(dbConn), accountRepo, auditLog,
+		services.ConsentConfig{
+			MaxValidity: config.Duration("CONSENT_MAX_VALIDITY", 90*24*time.Hour),
+			TokenTTL:    config.Duration("CONSENT_TOKEN_TTL", time.Hour),
+		})
+	beneficiaryRepo = repositories.NewBeneficiaryRepo(dbConn)
+	sanctionsSvc = services.NewSanctionsService(
+		dbConn, repositories.NewSanctionsRepo(dbConn), customerRepo, beneficiaryRepo, auditLog,
+		services.SanctionsConfig{
+			Path:            config.String("SANCTIONS_LIST_PATH", ""),
+			ReviewThreshold: config.Float("SANCTIONS_REVIEW_THRESHOLD", 0.88),
+			BlockThreshold:  config.Float("SANCTIONS_BLOCK_THRESHOLD", 0.97),
+		},
+	)
+	if _, err := sanctionsSvc.Reload(); err != nil {
+		log.Fatalf("load sanctions list: %v", err)
+	}
+	kycSvc = services.NewKYCService(
+		dbConn, repositories.NewKYCRepo(dbConn), customerRepo, accountRepo, txRepo,
+		&kyc.DirStore{Dir: config.String("KYC_DOCUMENT_DIR", "data/kyc")}, eventRecorder, auditLog,
+		services.KYCPolicy{
+			Validity:                 config.Duration("KYC_VALIDITY", 2*365*24*time.Hour),
+			ReminderLead:             config.Duration("KYC_REMINDER_LEAD", 30*24*time.Hour),
+			UnverifiedMaxAccounts:    config.Int("KYC_UNVERIFIED_MAX_ACCOUNTS", 1),
+			UnverifiedTransferLimit:  config.Float("KYC_UNVERIFIED_TRANSFER_LIMIT", 500),
+			UnverifiedTransferWindow: config.Duration("KYC_UNVERIFIED_TRANSFER_WINDOW", 24*time.Hour),
+			MaxDocumentBytes:         int64(config.Int("KYC_MAX_DOCUMENT_BYTES", 10<<20)),
+		},
+	)
+	outgoingPaymentRepo = repositories.NewOutgoingPaymentRepo(dbConn)
+	beneficiarySvc = services.NewBeneficiaryService(dbConn, beneficiaryRepo, outgoingPaymentRepo, services.BeneficiaryPolicy{
+		CoolingOff:      config.Duration("BENEFICIARY_COOLING_OFF", 24*time.Hour),
+		CoolingOffLimit: config.Float("BENEFICIARY_COOLING_OFF_LIMIT", 1000),
+	}, sanctionsSvc)
+
+	outgoingPaymentSvc = services.NewOutgoingPaymentService(
+		dbConn, outgoingPaymentRepo, accountRepo, txRepo, beneficiarySvc, fraudSvc,
+		newPaymentRail(), config.Int("OUTBOUND_CLEARING_ACCOUNT_ID", 0),
+	)
+
+	clearingFileRepo = repositories.NewClearingFileRepo(dbConn)
+	inboundPaymentSvc = services.NewInboundPaymentService(
+		dbConn, repositories.NewInboundCreditRepo(dbConn), clearingFileRepo, accountRepo, accountSvc,
+		eventRecorder, auditLog,
+	)
+
+	bulkTransferSvc = services.NewBulkTransferService(dbConn, repositories.NewBulkTransferRepo(dbConn), accountRepo, txRepo, fraudSvc, eventRecorder, auditLog)
+
+	billerSvc = services.NewBillerService(
+		dbConn, repositories.NewBillerRepo(dbConn), repositories.NewBillerRegistrationRepo(dbConn), accountRepo,
+	)
+	billPaymentSvc = services.NewBillPaymentService(
+		dbConn, repositories.NewBillPaymentRepo(dbConn), billerSvc, accountRepo, accountSvc,
+		customerRepo, billers.NewFakeConnector(config.Duration("BILLER_FAKE_DELAY", 5*time.Second)), fraudSvc, kycSvc, auditLog,
+	)
+
+	cardSvc = services.NewCardService(
+		dbConn, repositories.NewCardRepo(dbConn), repositories.NewCardAuthorizationRepo(dbConn), accountRepo, txRepo, customerRepo,
+		fraudSvc, kycSvc,
+		services.CardConfig{
+			BIN:                 config.String("CARD_BIN", "400000"),
+			HashKey:             []byte(config.String("CARD_HASH_KEY", "")),
+			ValidityYears:       config.Int("CARD_VALIDITY_YEARS", 3),
+			SettlementAccountID: config.Int("CARD_SETTLEMENT_ACCOUNT_ID", 0),
+		},
+	)
+
+	notificationRepo := repositories.NewNotificationRepo(dbConn)
+	notificationPrefRepo := repositories.NewNotificationPreferenceRepo(dbConn)
+	notificationSvc = services.NewNotificationService(notificationRepo, notificationPrefRepo)
+	deviceSvc = services.NewDeviceService(dbConn, repositories.NewDeviceRepo(dbConn), eventRecorder)
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		log.Fatalf("load notification templates: %v", err)
+	}
+	notifiers := newNotifiers(notificationRepo)
+	dispatcher := notifications.NewDispatcher(
+		customerRepo, notificationPrefRepo, templates,
+		notifications.DispatcherConfig{
+			Workers:      config.Int("NOTIFY_WORKERS", 2),
+			QueueSize:    config.Int("NOTIFY_QUEUE_SIZE", 1000),
+			MaxAttempts:  config.Int("NOTIFY_MAX_ATTEMPTS", 5),
+			RetryBackoff: config.Duration("NOTIFY_RETRY_BACKOFF", 30*time.Second),
+			SendTimeout:  config.Duration("NOTIFY_SEND_TIMEOUT", 10*time.Second),
+		},
+		notifiers...,
+	)
+	dispatcher.Start()
+
+	profileSvc = services.NewProfileService(
+		dbConn, repositories.NewProfileRepo(dbConn), customerRepo, dispatcher, sanctionsSvc, eventRecorder, auditLog,
+		services.ProfileConfig{
+			CodeTTL:         config.Duration("CONTACT_CODE_TTL", 15*time.Minute),
+			MaxCodeAttempts: config.Int("CONTACT_CODE_MAX_ATTEMPTS", 5),
+		},
+	)
+	sessionRepo := repositories.NewSessionRepo(dbConn)
+	sessionSvc = services.NewSessionService(dbConn, sessionRepo, customerRepo, auditLog, services.SessionConfig{
+		AccessTTL:  config.Duration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTTL: config.Duration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	})
+	// secrets enrolled before MFA_ENCRYPTION_KEY existed were sealed with
+	// JWT_SECRET, which moves to JWT_LEGACY_SECRET when the signing keys
+	// take over
+	mfaKey := config.String("MFA_ENCRYPTION_KEY", config.String("JWT_LEGACY_SECRET", config.String("JWT_SECRET", "")))
+	sealer, err := totp.NewSealer(mfaKey)
+	if err != nil {
+		log.Fatalf("MFA_ENCRYPTION_KEY: %v", err)
+	}
+	mfaSvc = services.NewMFAService(
+		dbConn, repositories.NewMFARepo(dbConn), customerRepo, sealer, eventRecorder, auditLog,
+		services.MFAConfig{
+			Issuer:        config.String("MFA_ISSUER", "Banking API"),
+			Skew:          config.Int("MFA_TOTP_SKEW", 1),
+			RecoveryCodes: config.Int("MFA_RECOVERY_CODES", 10),
+		},
+	)
+	mfaTokenTTL = config.Duration("MFA_TOKEN_TTL", 5*time.Minute)
+	stepUpMaxAge = config.Duration("STEP_UP_MAX_AGE", 5*time.Minute)
+	stepUpTransferThreshold = config.Float("STEP_UP_TRANSFER_THRESHOLD", 1000)
+	passwordResetSvc = services.NewPasswordResetService(
+		dbConn, repositories.NewPasswordResetRepo(dbConn), customerRepo, repositories.NewProfileRepo(dbConn), sessionRepo,
+		newResetSender(templates, notifiers), eventRecorder, auditLog,
+		config.Duration("PASSWORD_RESET_TTL", 30*time.Minute),
+	)
+
+	webhookConfig := services.WebhookConfig{
+		AllowHTTP:    config.Bool("WEBHOOK_ALLOW_HTTP", false),
+		AllowPrivate: config.Bool("WEBHOOK_ALLOW_PRIVATE", false),
+		MaxAttempts:  config.Int("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryBackoff: config.Duration("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+		MaxBackoff:   config.Duration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+		SendTimeout:  config.Duration("WEBHOOK_SEND_TIMEOUT", 10*time.Second),
+		Workers:      config.Int("WEBHOOK_WORKERS", 4),
+	}
+	webhookSvc = services.NewWebhookService(
+		repositories.NewWebhookRepo(dbConn),
+		&webhooks.Sender{Client: webhooks.NewClient(webhookConfig.SendTimeout, webhookConfig.AllowPrivate)},
+		webhookConfig,
+	)
+
+	outboxRelay = newOutboxRelay(dispatcher)
+}
+
+// newOutboxRelay sets up delivery of recorded events: always to the
+// notification dispatcher and webhooks, and optionally to the log
+// (OUTBOX_LOG), a JSON-lines file (OUTBOX_FILE) and an HTTP endpoint
+// (OUTBOX_HTTP_URL, signed with OUTBOX_HTTP_SECRET).
+func newOutboxRelay(dispatcher *notifications.Dispatcher) *outbox.Relay {
+	relay := outbox.NewRelay(outboxRepo, outbox.RelayConfig{
+		PollInterval: config.Duration("OUTBOX_POLL_INTERVAL", time.Second),
+		BatchSize:    config.Int("OUTBOX_BATCH_SIZE", 100),
+		GapTimeout:   config.Duration("OUTBOX_GAP_TIMEOUT", 10*time.Second),
+		SendTimeout:  config.Duration("OUTBOX_SEND_TIMEOUT", 10*time.Second),
+	})
+
+	relay.Add("notifications", outbox.SinkFunc(func(_ context.Context, e events.Event) error {
+		dispatcher.HandleEvent(e)
+		return nil
+	}))
+	relay.Add("webhooks", outbox.SinkFunc(func(_ context.Context, e events.Event) error {
+		return webhookSvc.Enqueue(e)
+	}))
+
+	if config.Bool("OUTBOX_LOG", false) {
+		relay.Add("log", outbox.LogSink{})
+	}
+	if path := config.String("OUTBOX_FILE", ""); path != "" {
+		relay.Add("file", &outbox.FileSink{Path: path})
+	}
+	if url := config.String("OUTBOX_HTTP_URL", ""); url != "" {
+		relay.Add("http", &outbox.HTTPSink{
+			URL:    url,
+			Secret: config.String("OUTBOX_HTTP_SECRET", ""),
+			Client: &http.Client{Timeout: config.Duration("OUTBOX_SEND_TIMEOUT", 10*time.Second)},
+		})
+	}
+	return relay
+}
 
-	// correct order: (db, loanRepo, paymentRepo)
-	loanPaymentSvc = services.NewLoanPaymentService(dbConn, loanRepo, loanPaymentRepo)
+// newNotifiers builds the delivery channels. Email needs SMTP_ADDR; SMS
+// goes to SMS_GATEWAY_URL, or to the log when that is unset.
+func newNotifiers(inbox repositories.NotificationRepository) []notifications.Notifier {
+	notifiers := []notifications.Notifier{&notifications.InboxNotifier{Repo: inbox}}
+
+	if addr := config.String("SMTP_ADDR", ""); addr != "" {
+		notifiers = append(notifiers, &notifications.SMTPNotifier{
+			Addr:     addr,
+			From:     config.String("SMTP_FROM", "no-reply@bank.local"),
+			Username: config.String("SMTP_USERNAME", ""),
+			Password: config.String("SMTP_PASSWORD", ""),
+		})
+	}
+
+	if url := config.String("SMS_GATEWAY_URL", ""); url != "" {
+		notifiers = append(notifiers, &notifications.HTTPSMSNotifier{
+			URL:    url,
+			Token:  config.String("SMS_GATEWAY_TOKEN", ""),
+			Client: &http.Client{Timeout: 10 * time.Second},
+		})
+	} else {
+		notifiers = append(notifiers, notifications.LogSMSNotifier{})
+	}
+	return notifiers
+}
+
+// newResetSender picks how password reset links reach customers from
+// PASSWORD_RESET_SENDER: "log" (default, for development), "email" or
+// "sms". Links point at PASSWORD_RESET_URL.
+func newResetSender(templates *notifications.Templates, notifiers []notifications.Notifier) services.ResetSender {
+	url := config.String("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+	channel := config.String("PASSWORD_RESET_SENDER", "log")
+	if channel == "log" {
+		return notifications.LogResetSender{URL: url}
+	}
+	for _, n := range notifiers {
+		if n.Channel() == channel && channel != models.ChannelInApp {
+			return &notifications.NotifierResetSender{
+				Notifier:  n,
+				Templates: templates,
+				URL:       url,
+				Timeout:   config.Duration("NOTIFY_SEND_TIMEOUT", 10*time.Second),
+			}
+		}
+	}
+	log.Fatalf("PASSWORD_RESET_SENDER %q is not a configured channel", channel)
+	return nil
+}
+
+// newRateLimitStore picks where rate limit buckets live from
+// RATE_LIMIT_STORE: "memory" (default) for a single instance, or "db" to
+// share them between instances.
+func newRateLimitStore() ratelimit.Store {
+	switch store := config.String("RATE_LIMIT_STORE", "memory"); store {
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	case "db":
+		return ratelimit.NewDBStore(dbConn, throttleRepo)
+	default:
+		log.Fatalf("unknown RATE_LIMIT_STORE %q", store)
+		return nil
+	}
+}
+
+// newPaymentRail picks the rail for outbound payments from PAYMENT_RAIL.
+// "none" leaves payments initiated for batch processing.
+func newPaymentRail() paymentrail.Rail {
+	switch rail := config.String("PAYMENT_RAIL", "simulator"); rail {
+	case "simulator":
+		return paymentrail.NewSimulator(config.Duration("PAYMENT_RAIL_SIMULATOR_DELAY", 5*time.Second))
+	case "none":
+		return nil
+	default:
+		log.Fatalf("unknown PAYMENT_RAIL %q", rail)
+		return nil
+	}
 }
 
 // -------------------- AUTH --------------------
@@ -48,34 +432,68 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidatePassword(req.Password, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), 14)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
 		return
 	}
 
-	customer := &models.Customer{
-		Username:     req.Username,
-		PasswordHash: string(hashed),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Email:        req.Email,
-		Phone:        req.Phone,
-		Address:      req.Address,
+	screening := sanctionsSvc.Screen(req.FirstName + " " + req.LastName)
+	if screening.Status == models.ScreeningBlocked {
+		_, err := sanctionsSvc.RecordHits(dbConn, models.SanctionsSubjectCustomer, 0, 0, screening, models.SanctionsHitBlocked)
+		if err != nil {
+			log.Printf("record blocked registration %q: %v", req.Username, err)
+		}
+		// deliberately vague: the applicant is not told why
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration could not be completed"})
+		return
 	}
 
-	if err := dbConn.Create(customer).Error; err != nil {
+	customer := &models.Customer{
+		Username:        req.Username,
+		PasswordHash:    string(hashed),
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		Email:           req.Email,
+		Phone:           req.Phone,
+		Address:         req.Address,
+		ScreeningStatus: screening.Status,
+	}
+
+	err = dbConn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(customer).Error; err != nil {
+			return err
+		}
+		_, err := sanctionsSvc.RecordHits(tx, models.SanctionsSubjectCustomer, customer.ID, customer.ID, screening, models.SanctionsHitPending)
+		if err != nil {
+			return err
+		}
+		return auditLog.Record(tx, audit.FromContext(c), audit.Entry{
+			Action:     "customer.register",
+			EntityType: "customer",
+			EntityID:   customer.ID,
+			After:      customer,
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit.SetActor(c, models.ActorCustomer, customer.ID)
+	recordDevice(c, customer.ID)
 
-	token, err := auth.GenerateToken(customer.ID)
+	tokens, err := sessionSvc.WithAudit(audit.FromContext(c)).Start(customer.ID, []string{auth.MethodPassword})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"token": token})
+	c.JSON(http.StatusCreated, tokens)
 }
 
 func Login(c *gin.Context) {
@@ -88,24 +506,89 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// throttled by username whether or not it exists, so lockouts do not
+	// reveal which usernames are real
+	userKey := services.LoginKey(services.LoginKeyUser, loginReq.Username)
+	ipKey := services.LoginKey(services.LoginKeyIP, c.ClientIP())
+	if !checkLoginThrottle(c, userKey, ipKey) {
+		return
+	}
+
 	var customer models.Customer
 	if err := dbConn.Where("username = ?", loginReq.Username).First(&customer).Error; err != nil {
+		recordLogin(c, "auth.login_failed", 0)
+		failLogin(c, userKey, ipKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(loginReq.Password)); err != nil {
+		recordLogin(c, "auth.login_failed", customer.ID)
+		failLogin(c, userKey, ipKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	token, err := auth.GenerateToken(customer.ID)
+	if customer.ScreeningStatus == models.ScreeningBlocked {
+		recordLogin(c, "auth.login_blocked", customer.ID)
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrSanctionsBlocked.Error()})
+		return
+	}
+
+	enabled, err := mfaSvc.Enabled(customer.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if enabled {
+		// the password was right; the session starts at /auth/login/mfa,
+		// and failures are only forgotten once the second factor passes
+		recordLogin(c, "auth.login_mfa_required", customer.ID)
+		mfaToken, err := auth.GenerateMFAToken(customer.ID, mfaTokenTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, models.MFAChallenge{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(mfaTokenTTL.Seconds()),
+			Methods:     []string{"totp", "recovery_code"},
+		})
+		return
+	}
+
+	audit.SetActor(c, models.ActorCustomer, customer.ID)
+	recordLogin(c, "auth.login", customer.ID)
+	recordDevice(c, customer.ID)
+	if err := lockoutSvc.Succeed(userKey); err != nil {
+		log.Printf("clear failed sign-ins for %s: %v", userKey, err)
+	}
+
+	tokens, err := sessionSvc.WithAudit(audit.FromContext(c)).Start(customer.ID, []string{auth.MethodPassword})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// recordLogin writes a sign-in attempt to the audit log. Failures are
+// logged; they must not change the response.
+func recordLogin(c *gin.Context, action string, customerID int) {
+	entry := audit.Entry{Action: action, EntityType: "customer", EntityID: customerID}
+	if err := auditLog.Record(dbConn, audit.FromContext(c), entry); err != nil {
+		log.Printf("audit %s for customer %d: %v", action, customerID, err)
+	}
+}
+
+// recordDevice notes the device a customer signed in from. Failures are
+// logged; they must not stop the sign-in.
+func recordDevice(c *gin.Context, customerID int) {
+	if err := deviceSvc.RecordLogin(customerID, c.GetHeader("X-Device-ID"), c.Request.UserAgent(), c.ClientIP()); err != nil {
+		log.Printf("record login device for customer %d: %v", customerID, err)
+	}
 }
 
 // ACCOUNTS
@@ -123,8 +606,13 @@ func CreateAccount(c *gin.Context) {
 		return
 	}
 
+	if err := kycSvc.CheckAccountOpening(userID); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	branchID := 1
-	account, err := accountSvc.CreateAccount(&req, userID, branchID)
+	account, err := accountSvc.WithAudit(audit.FromContext(c)).CreateAccount(&req, userID, branchID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -149,6 +637,12 @@ func ListAccounts(c *gin.Context) {
 }
 
 func Transfer(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
 	fromID, err := strconv.Atoi(c.Param("from_id"))
 	if err != nil || fromID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_id"})
@@ -166,15 +660,85 @@ func Transfer(c *gin.Context) {
 		return
 	}
 
-	if err := accountSvc.Transfer(fromID, req.ToAccountID, req.Amount); err != nil {
+	from, ok := customerAccount(c, fromID, userID)
+	if !ok {
+		return
+	}
+	if err := services.CheckScreening(customerRepo, from.CustomerID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err := kycSvc.CheckTransfer(from.CustomerID, req.Amount); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	fresh, method, err := steppedUp(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount > stepUpTransferThreshold && !fresh {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrStepUpRequired.Error(), "step_up": method})
+		return
+	}
+	opts := services.TransferOptions{StepUpVerified: fresh}
+
+	result, err := accountSvc.WithAudit(audit.FromContext(c)).TransferWithOptions(fromID, req.ToAccountID, req.Amount, opts)
+	var challenge *models.StepUpRequiredError
+	switch {
+	case errors.As(err, &challenge):
+		// the client verifies at POST /auth/step-up and resends the
+		// transfer with the new token
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "step_up": method, "rules": challenge.Rules})
+		return
+	case err != nil:
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
+	case result.Status == models.TransferHeld:
+		c.JSON(http.StatusAccepted, gin.H{"message": "transfer held for review", "result": result})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "transfer successful"})
+	c.JSON(http.StatusOK, gin.H{"message": "transfer successful", "result": result})
+}
+
+// customerAccount loads the account for a customer route, answering 404
+// when it does not exist or belongs to someone else.
+func customerAccount(c *gin.Context, accountID, customerID int) (*models.Account, bool) {
+	account, err := services.OwnedAccount(accountRepo, accountID, customerID)
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return account, true
+}
+
+// customerLoan is customerAccount for loans.
+func customerLoan(c *gin.Context, loanID, customerID int) (*models.Loan, bool) {
+	loan, err := loanRepo.GetByID(loanID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if loan == nil || loan.CustomerID != customerID {
+		c.JSON(http.StatusNotFound, gin.H{"error": models.ErrLoanNotFound.Error()})
+		return nil, false
+	}
+	return loan, true
 }
 
 func Deposit(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
 	accountID, err := strconv.Atoi(c.Param("account_id"))
 	if err != nil || accountID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
@@ -186,8 +750,11 @@ func Deposit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, ok := customerAccount(c, accountID, userID); !ok {
+		return
+	}
 
-	if err := accountSvc.Deposit(accountID, req.Amount); err != nil {
+	if err := accountSvc.WithAudit(audit.FromContext(c)).Deposit(accountID, req.Amount); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -196,12 +763,22 @@ func Deposit(c *gin.Context) {
 
 // proper handler version (not service method)
 func GetStatement(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
 	accountID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || accountID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
 		return
 	}
 
+	if _, ok := customerAccount(c, accountID, userID); !ok {
+		return
+	}
+
 	statement, err := accountSvc.GetStatement(accountID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -226,7 +803,7 @@ func CreateLoan(c *gin.Context) {
 	}
 
 	branchID := 1
-	loan, err := loanSvc.CreateLoan(&req, userID, branchID)
+	loan, err := loanSvc.WithAudit(audit.FromContext(c)).CreateLoan(&req, userID, branchID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -250,6 +827,12 @@ func ListLoans(c *gin.Context) {
 }
 
 func MakePayment(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
 	loanID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || loanID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
@@ -262,8 +845,12 @@ func MakePayment(c *gin.Context) {
 		return
 	}
 
+	if _, ok := customerLoan(c, loanID, userID); !ok {
+		return
+	}
+
 	// service expects (paymentID, loanID)
-	if err := loanPaymentSvc.MakePayment(req.PaymentID, loanID); err != nil {
+	if err := loanPaymentSvc.WithAudit(audit.FromContext(c)).MakePayment(req.PaymentID, loanID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -272,12 +859,22 @@ func MakePayment(c *gin.Context) {
 }
 
 func ListPayments(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
 	loanID, err := strconv.Atoi(c.Param("id"))
 	if err != nil || loanID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan id"})
 		return
 	}
 
+	if _, ok := customerLoan(c, loanID, userID); !ok {
+		return
+	}
+
 	payments, err := loanPaymentSvc.ListPayments(loanID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -288,6 +885,21 @@ func ListPayments(c *gin.Context) {
 
 // BENEFICIARIES
 
+func beneficiaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBeneficiaryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicateBeneficiary), errors.Is(err, models.ErrBeneficiaryInUse):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidAccountNumber), errors.Is(err, models.ErrInvalidBankCode):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrSanctionsBlocked):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func AddBeneficiary(c *gin.Context) {
 	userID := auth.GetUserID(c)
 	if userID == 0 {
@@ -300,7 +912,76 @@ func AddBeneficiary(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !requireStepUp(c, userID) {
+		return
+	}
+
+	beneficiary, err := beneficiarySvc.AddBeneficiary(&req, userID)
+	if err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, beneficiary)
+}
+
+func ListBeneficiaries(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	beneficiaries, err := beneficiarySvc.ListBeneficiaries(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, beneficiaries)
+}
+
+func UpdateBeneficiary(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary id"})
+		return
+	}
+
+	var req models.UpdateBeneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// TODO: save beneficiary to DB via service/repo
-	c.JSON(http.StatusCreated, gin.H{"message": "beneficiary added successfully", "customer_id": userID})
+	beneficiary, err := beneficiarySvc.UpdateNickname(id, userID, req.Nickname)
+	if err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+func DeleteBeneficiary(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary id"})
+		return
+	}
+
+	if err := beneficiarySvc.DeleteBeneficiary(id, userID); err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiary deleted"})
 }
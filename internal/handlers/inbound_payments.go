@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// INBOUND PAYMENTS (back office)
+
+// ImportInboundFile accepts a multipart "file" upload. The format form
+// field selects the parser: "camt054" (default) or "csv".
+func ImportInboundFile(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	var result *services.InboundImportResult
+	switch c.DefaultPostForm("format", "camt054") {
+	case "camt054":
+		result, err = inboundPaymentSvc.ImportCamt054(f)
+	case "csv":
+		result, err = inboundPaymentSvc.ImportCSV(f)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be camt054 or csv"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func ListSuspenseCredits(c *gin.Context) {
+	credits, err := inboundPaymentSvc.ListSuspense()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credits)
+}
+
+func AssignSuspenseCredit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	var req models.AssignInboundCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credit, err := inboundPaymentSvc.WithAudit(audit.FromContext(c)).AssignSuspense(id, req.AccountID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrInboundCreditNotFound), errors.Is(err, models.ErrAccountNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, models.ErrInboundCreditNotInSuspense):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credit)
+}
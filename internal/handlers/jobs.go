@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/config"
+)
+
+// StartBackgroundJobs launches the periodic workers that keep asynchronous
+// flows moving. Call it once after InitHandlers.
+func StartBackgroundJobs() {
+	outboxRelay.Start()
+	every(config.Duration("OUTBOX_PRUNE_INTERVAL", time.Hour), "prune outbox", func() error {
+		_, err := outboxRepo.Prune(time.Now().Add(-config.Duration("OUTBOX_RETENTION", 7*24*time.Hour)))
+		return err
+	})
+
+	every(config.Duration("BULK_RESUME_INTERVAL", time.Minute), "resume stuck bulk transfers", func() error {
+		_, err := bulkTransferSvc.ResumeProcessing()
+		return err
+	})
+
+	every(config.Duration("PAYMENT_RESUBMIT_INTERVAL", time.Minute), "resubmit outgoing payments", func() error {
+		_, err := outgoingPaymentSvc.SubmitPending()
+		return err
+	})
+	every(config.Duration("PAYMENT_STATUS_RETRY_INTERVAL", time.Minute), "retry payment status updates", func() error {
+		_, err := outgoingPaymentSvc.RetryStatusUpdates()
+		return err
+	})
+	every(config.Duration("BILL_SCHEDULER_INTERVAL", time.Minute), "run scheduled bill payments", func() error {
+		_, err := billPaymentSvc.RunScheduled()
+		return err
+	})
+	every(config.Duration("BILL_RESUME_INTERVAL", time.Minute), "resume stuck bill payments", func() error {
+		_, err := billPaymentSvc.ResumeStuck()
+		return err
+	})
+	every(config.Duration("EMI_REMINDER_INTERVAL", time.Hour), "send EMI reminders", func() error {
+		_, err := loanPaymentSvc.SendReminders(config.Duration("EMI_REMINDER_LEAD", 72*time.Hour))
+		return err
+	})
+	every(config.Duration("WEBHOOK_POLL_INTERVAL", 5*time.Second), "deliver webhooks", func() error {
+		_, err := webhookSvc.DeliverDue()
+		return err
+	})
+	every(config.Duration("CARD_EXPIRY_INTERVAL", time.Hour), "expire cards", func() error {
+		_, err := cardSvc.ExpireCards()
+		return err
+	})
+	every(config.Duration("SESSION_PRUNE_INTERVAL", time.Hour), "prune sessions", func() error {
+		_, err := sessionSvc.Prune(time.Now().Add(-config.Duration("SESSION_RETENTION", 7*24*time.Hour)))
+		return err
+	})
+	every(config.Duration("THROTTLE_PRUNE_INTERVAL", time.Hour), "prune sign-in throttles", func() error {
+		if _, err := lockoutSvc.Prune(); err != nil {
+			return err
+		}
+		// an idle bucket has long since refilled, so it is as good as new
+		_, err := throttleRepo.PruneBuckets(time.Now().Add(-time.Hour))
+		return err
+	})
+	if maxAge := config.Duration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour); maxAge > 0 {
+		every(config.Duration("JWT_KEY_CHECK_INTERVAL", time.Hour), "rotate token signing keys", func() error {
+			rotated, err := signingKeys.RotateDue(maxAge, config.Duration("JWT_KEY_OVERLAP", 24*time.Hour))
+			if rotated {
+				log.Printf("rotated token signing key, now %s", signingKeys.Signing().ID)
+			}
+			return err
+		})
+	}
+	every(config.Duration("CONSENT_EXPIRY_INTERVAL", time.Hour), "expire consents", func() error {
+		_, err := consentSvc.Expire()
+		return err
+	})
+	every(config.Duration("KYC_EXPIRY_INTERVAL", time.Hour), "process KYC expiry", func() error {
+		_, err := kycSvc.ProcessExpiry()
+		return err
+	})
+	if config.String("SANCTIONS_LIST_PATH", "") != "" {
+		// a list that changed while the server was down is screened now
+		go func() {
+			if _, err := sanctionsSvc.RescreenIfChanged(); err != nil {
+				log.Printf("re-screen against sanctions list: %v", err)
+			}
+		}()
+		every(config.Duration("SANCTIONS_RELOAD_INTERVAL", time.Hour), "re-screen against sanctions list", func() error {
+			_, err := sanctionsSvc.RescreenIfChanged()
+			return err
+		})
+	}
+	if config.String("JOURNAL_SIGNING_KEY", "") != "" {
+		every(config.Duration("JOURNAL_CHECKPOINT_INTERVAL", time.Hour), "checkpoint transaction journal", func() error {
+			_, err := journalSvc.Checkpoint()
+			return err
+		})
+	}
+}
+
+// every runs job on a ticker in its own goroutine, logging failures. A
+// panicking run is logged too, and the job runs again on the next tick.
+func every(interval time.Duration, name string, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := runJob(job); err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+		}
+	}()
+}
+
+func runJob(job func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job()
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TRANSACTION JOURNAL
+
+// admin: walk the hash chain; 200 either way, with ok=false and the first
+// broken link when it fails
+func VerifyJournal(c *gin.Context) {
+	report, err := journalSvc.Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func ListJournalCheckpoints(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	checkpoints, err := journalSvc.ListCheckpoints(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, checkpoints)
+}
+
+func CreateJournalCheckpoint(c *gin.Context) {
+	checkpoint, err := journalSvc.Checkpoint()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrJournalSigningKeyMissing) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if checkpoint == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "no transactions since the last checkpoint"})
+		return
+	}
+	c.JSON(http.StatusCreated, checkpoint)
+}
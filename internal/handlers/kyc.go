@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KYC
+
+func kycErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrCustomerNotFound),
+		errors.Is(err, models.ErrKYCDocumentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrKYCNotPending),
+		errors.Is(err, models.ErrKYCDocumentExpired):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrKYCRequired),
+		errors.Is(err, models.ErrKYCRejected),
+		errors.Is(err, models.ErrKYCLimitExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidKYCDocument):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, models.ErrKYCDocumentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func GetKYCProfile(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	profile, err := kycSvc.GetProfile(userID)
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// UploadKYCDocument accepts a multipart "file" upload with the document's
+// details as form fields.
+func UploadKYCDocument(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.UploadKYCDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	doc, err := kycSvc.WithAudit(audit.FromContext(c)).UploadDocument(userID, &req, fh.Filename, f)
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, doc)
+}
+
+// admin: review
+
+// ListKYCCustomers lists customers waiting for review, or with ?status=
+// those in one verification state.
+func ListKYCCustomers(c *gin.Context) {
+	var customers []models.Customer
+	var err error
+	if status := c.Query("status"); status != "" {
+		customers, err = kycSvc.ListCustomers(status, 200)
+	} else {
+		customers, err = kycSvc.ListAwaitingReview(200)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, customers)
+}
+
+func GetCustomerKYC(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	profile, err := kycSvc.GetProfile(id)
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func DownloadKYCDocument(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+		return
+	}
+
+	doc, file, err := kycSvc.OpenDocument(id)
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(doc.FileName))
+	c.DataFromReader(http.StatusOK, doc.Size, doc.ContentType, file, nil)
+}
+
+func ApproveKYC(c *gin.Context) {
+	reviewKYC(c, true)
+}
+
+func RejectKYC(c *gin.Context) {
+	reviewKYC(c, false)
+}
+
+func reviewKYC(c *gin.Context, approve bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	// the note is optional, and so is the body
+	var req models.ReviewKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := kycSvc.WithAudit(audit.FromContext(c))
+	var profile *models.KYCProfile
+	if approve {
+		profile, err = svc.Approve(id, req.Note)
+	} else {
+		profile, err = svc.Reject(id, req.Note)
+	}
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TWO-FACTOR AUTHENTICATION
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrMFAAlreadyEnabled),
+		errors.Is(err, models.ErrMFANotPending),
+		errors.Is(err, models.ErrMFANotEnabled):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidMFACode),
+		errors.Is(err, models.ErrIncorrectPassword):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidMFAToken),
+		errors.Is(err, models.ErrSessionRevoked):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// stepUpMethod is how the customer proves themselves again: "totp" once
+// they have enrolled an authenticator, otherwise "password".
+func stepUpMethod(customerID int) (string, error) {
+	enabled, err := mfaSvc.Enabled(customerID)
+	if err != nil {
+		return "", err
+	}
+	if enabled {
+		return "totp", nil
+	}
+	return "password", nil
+}
+
+// steppedUp reports whether the request's token shows the customer
+// authenticated recently enough, with their second factor if they have
+// one, for a sensitive action. When not, it also returns the method to
+// step up with.
+func steppedUp(c *gin.Context, customerID int) (bool, string, error) {
+	method, err := stepUpMethod(customerID)
+	if err != nil {
+		return false, "", err
+	}
+	claims, err := auth.ParseToken(auth.BearerToken(c))
+	if err != nil {
+		return false, method, nil
+	}
+	fresh := !claims.AuthTime.IsZero() && time.Since(claims.AuthTime) <= stepUpMaxAge
+	if method == "totp" {
+		fresh = fresh && claims.HasMethod(auth.MethodOTP)
+	}
+	return fresh, method, nil
+}
+
+// requireStepUp writes the step-up challenge and returns false unless the
+// customer recently authenticated.
+func requireStepUp(c *gin.Context, customerID int) bool {
+	fresh, method, err := steppedUp(c, customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !fresh {
+		// the client verifies at POST /auth/step-up and retries with the
+		// new token
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrStepUpRequired.Error(), "step_up": method})
+		return false
+	}
+	return true
+}
+
+// LoginMFA completes a two-step sign-in with a code from the customer's
+// authenticator or a recovery code.
+func LoginMFA(c *gin.Context) {
+	var req models.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := auth.ParseToken(req.MFAToken)
+	if err != nil || claims.Kind != auth.KindMFA {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrInvalidMFAToken.Error()})
+		return
+	}
+	customerID := claims.UserID
+
+	customer, err := customerRepo.GetByID(customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if customer == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrInvalidMFAToken.Error()})
+		return
+	}
+	// codes are guessed against the same throttle as passwords
+	userKey := services.LoginKey(services.LoginKeyUser, customer.Username)
+	ipKey := services.LoginKey(services.LoginKeyIP, c.ClientIP())
+	if !checkLoginThrottle(c, userKey, ipKey) {
+		return
+	}
+
+	svc := mfaSvc.WithAudit(audit.FromContext(c))
+	if req.RecoveryCode != "" {
+		err = svc.VerifyRecoveryCode(customerID, req.RecoveryCode)
+	} else {
+		err = svc.VerifyTOTP(customerID, req.Code)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidMFACode) {
+			recordLogin(c, "auth.mfa_failed", customerID)
+			failLogin(c, userKey, ipKey)
+		}
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if customer.ScreeningStatus == models.ScreeningBlocked {
+		recordLogin(c, "auth.login_blocked", customerID)
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrSanctionsBlocked.Error()})
+		return
+	}
+	if err := lockoutSvc.Succeed(userKey); err != nil {
+		log.Printf("clear failed sign-ins for %s: %v", userKey, err)
+	}
+
+	audit.SetActor(c, models.ActorCustomer, customerID)
+	recordLogin(c, "auth.login", customerID)
+	recordDevice(c, customerID)
+
+	tokens, err := sessionSvc.WithAudit(audit.FromContext(c)).Start(customerID, []string{auth.MethodPassword, auth.MethodOTP})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// StepUp re-verifies a signed-in customer and returns an access token
+// that allows sensitive actions for a few minutes.
+func StepUp(c *gin.Context) {
+	claims, err := auth.ParseToken(auth.BearerToken(c))
+	if err != nil || claims.Kind != auth.KindCustomer {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := customerRepo.GetByID(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if customer == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	userKey := services.LoginKey(services.LoginKeyUser, customer.Username)
+	if !checkLoginThrottle(c, userKey) {
+		return
+	}
+
+	method, err := stepUpMethod(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	amr := auth.MethodPassword
+	if method == "totp" {
+		if req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required", "step_up": method})
+			return
+		}
+		err = mfaSvc.WithAudit(audit.FromContext(c)).VerifyTOTP(claims.UserID, req.Code)
+		amr = auth.MethodOTP
+	} else {
+		if req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password is required", "step_up": method})
+			return
+		}
+		err = verifyPassword(claims.UserID, req.Password)
+	}
+	if err != nil {
+		recordLogin(c, "auth.step_up_failed", claims.UserID)
+		if errors.Is(err, models.ErrInvalidMFACode) || errors.Is(err, models.ErrIncorrectPassword) {
+			failLogin(c, userKey)
+		}
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := sessionSvc.WithAudit(audit.FromContext(c)).StepUp(claims.UserID, claims.SessionID, amr)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// verifyPassword re-checks the customer's password for step-up.
+func verifyPassword(customerID int, password string) error {
+	customer, err := customerRepo.GetByID(customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil || bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(password)) != nil {
+		return models.ErrIncorrectPassword
+	}
+	return nil
+}
+
+func GetMFAStatus(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	status, err := mfaSvc.Status(userID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// BeginTOTP returns a secret and an otpauth:// URI for the customer's
+// authenticator app; the client shows the URI as a QR code.
+func BeginTOTP(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.BeginTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrolment, err := mfaSvc.WithAudit(audit.FromContext(c)).BeginTOTP(userID, req.Password)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrolment)
+}
+
+func ConfirmTOTP(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := mfaSvc.WithAudit(audit.FromContext(c)).ConfirmTOTP(userID, req.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled", "recovery_codes": codes})
+}
+
+func DisableTOTP(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := mfaSvc.WithAudit(audit.FromContext(c)).DisableTOTP(userID, req.Password, req.Code); err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := mfaSvc.WithAudit(audit.FromContext(c)).RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OPEN BANKING
+
+func consentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrConsentNotFound), errors.Is(err, models.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrConsentNotPending), errors.Is(err, models.ErrConsentNotAuthorized):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrNotThirdPartyProvider):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidConsent):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+const consentKey = "consent"
+
+// ProviderAuthMiddleware admits client tokens of active third-party
+// providers, for managing the consents they request.
+func ProviderAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := auth.ParseToken(auth.BearerToken(c))
+		if err != nil || claims.Kind != auth.KindClient {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		client, err := apiClientSvc.Check(claims.ClientID, claims.IssuedAt)
+		if err != nil {
+			if errors.Is(err, models.ErrSessionRevoked) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !client.Provider() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": models.ErrNotThirdPartyProvider.Error()})
+			return
+		}
+		auth.SetClaims(c, claims)
+		c.Set(apiClientKey, client)
+		audit.SetActor(c, models.ActorAPIClient, client.ID)
+		c.Next()
+	}
+}
+
+// ConsentAuthMiddleware admits consent-bound tokens while the consent is
+// authorized and unexpired and the provider is active.
+func ConsentAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := auth.ParseToken(auth.BearerToken(c))
+		if err != nil || claims.Kind != auth.KindConsent {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		client, err := apiClientSvc.Check(claims.ClientID, claims.IssuedAt)
+		if err != nil {
+			abortConsentAuth(c, err)
+			return
+		}
+		consent, err := consentSvc.Check(client.ID, claims.ConsentID)
+		if err != nil {
+			abortConsentAuth(c, err)
+			return
+		}
+		if consent.CustomerID != claims.UserID {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		auth.SetClaims(c, claims)
+		c.Set(apiClientKey, client)
+		c.Set(consentKey, consent)
+		audit.SetActor(c, models.ActorAPIClient, client.ID)
+		c.Next()
+	}
+}
+
+// abortConsentAuth refuses a consent-bound token whose client or consent
+// is no longer good.
+func abortConsentAuth(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrSessionRevoked), errors.Is(err, models.ErrConsentNotFound),
+		errors.Is(err, models.ErrConsentNotAuthorized):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RequireConsent admits requests whose consent grants permission. It must
+// run after ConsentAuthMiddleware.
+func RequireConsent(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !consentFrom(c).Allows(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": models.ErrConsentPermission.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+func consentFrom(c *gin.Context) *models.Consent {
+	return c.MustGet(consentKey).(*models.Consent)
+}
+
+// consentAccount returns the :id account if the consent covers it,
+// writing the error response otherwise.
+func consentAccount(c *gin.Context) (int, bool) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
+		return 0, false
+	}
+	if !consentFrom(c).Covers(accountID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrConsentAccount.Error()})
+		return 0, false
+	}
+	return accountID, true
+}
+
+// issueConsentToken is the token endpoint's answer to a provider that
+// sends consent_id: a token bound to that consent.
+func issueConsentToken(c *gin.Context, clientID, secret, consentID string) {
+	client, err := apiClientSvc.WithAudit(audit.FromContext(c)).Authenticate(clientID, secret)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidClient) {
+			oauthError(c, http.StatusUnauthorized, "invalid_client", err.Error())
+			return
+		}
+		oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	token, err := consentSvc.Token(client, consentID)
+	if err != nil {
+		if errors.Is(err, models.ErrConsentNotFound) || errors.Is(err, models.ErrConsentNotAuthorized) {
+			oauthError(c, http.StatusBadRequest, "invalid_grant", err.Error())
+			return
+		}
+		oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// provider: ask for a consent; the customer authorizes it at
+// /me/consents/:id/authorize
+func CreateConsent(c *gin.Context) {
+	var req models.CreateConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client := c.MustGet(apiClientKey).(*models.APIClient)
+	consent, err := consentSvc.WithAudit(audit.FromContext(c)).Request(client, &req)
+	if err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, consent)
+}
+
+func GetConsent(c *gin.Context) {
+	client := c.MustGet(apiClientKey).(*models.APIClient)
+	consent, err := consentSvc.GetForProvider(client.ID, c.Param("id"))
+	if err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+func RevokeConsent(c *gin.Context) {
+	client := c.MustGet(apiClientKey).(*models.APIClient)
+	if err := consentSvc.WithAudit(audit.FromContext(c)).RevokeForProvider(client.ID, c.Param("id")); err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "consent revoked"})
+}
+
+func ListMyConsents(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	consents, err := consentSvc.ListForCustomer(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, consents)
+}
+
+func GetMyConsent(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	consent, err := consentSvc.GetForCustomer(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// AuthorizeConsent grants a provider's consent over the accounts the
+// customer picks. It needs a recent step-up.
+func AuthorizeConsent(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.AuthorizeConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !requireStepUp(c, userID) {
+		return
+	}
+	consent, err := consentSvc.WithAudit(audit.FromContext(c)).Authorize(userID, c.Param("id"), req.AccountIDs)
+	if err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+func RejectConsent(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	consent, err := consentSvc.WithAudit(audit.FromContext(c)).Reject(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+func RevokeMyConsent(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	if err := consentSvc.WithAudit(audit.FromContext(c)).Revoke(userID, c.Param("id")); err != nil {
+		c.JSON(consentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "consent revoked"})
+}
+
+// AISP: the accounts the consent covers
+func OBListAccounts(c *gin.Context) {
+	consent := consentFrom(c)
+	accounts, err := accountRepo.ListByCustomerID(consent.CustomerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	covered := []models.OBAccount{}
+	for _, account := range accounts {
+		if consent.Covers(account.ID) {
+			covered = append(covered, models.OBAccount{ID: account.ID, Owner: account.Owner, Currency: account.Currency})
+		}
+	}
+	c.JSON(http.StatusOK, covered)
+}
+
+func OBGetBalances(c *gin.Context) {
+	accountID, ok := consentAccount(c)
+	if !ok {
+		return
+	}
+
+	account, err := accountRepo.GetByID(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.OBBalance{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Booked:    account.Balance,
+		Available: account.Balance - account.HeldAmount,
+		AsOf:      time.Now(),
+	})
+}
+
+func OBListTransactions(c *gin.Context) {
+	accountID, ok := consentAccount(c)
+	if !ok {
+		return
+	}
+
+	statement, err := accountSvc.GetStatement(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+// PISP: pay from a covered account, up to the consent's limit. Payments
+// the fraud rules would challenge are refused, since the customer is not
+// there to step up.
+func OBCreatePayment(c *gin.Context) {
+	var req models.OBPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consent := consentFrom(c)
+	if !consent.Covers(req.FromAccountID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrConsentAccount.Error()})
+		return
+	}
+	if req.Amount > consent.MaxPaymentAmount {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrConsentPaymentLimit.Error()})
+		return
+	}
+	if err := services.CheckScreening(customerRepo, consent.CustomerID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err := kycSvc.CheckTransfer(consent.CustomerID, req.Amount); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := accountSvc.WithAudit(audit.FromContext(c)).TransferWithOptions(req.FromAccountID, req.ToAccountID, req.Amount, services.TransferOptions{})
+	var challenge *models.StepUpRequiredError
+	switch {
+	case errors.As(err, &challenge):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "rules": challenge.Rules})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case result.Status == models.TransferHeld:
+		c.JSON(http.StatusAccepted, gin.H{"message": "payment held for review", "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "payment successful", "result": result})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OUTGOING PAYMENTS
+
+func outgoingPaymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound),
+		errors.Is(err, models.ErrBeneficiaryNotFound),
+		errors.Is(err, models.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInsufficientFunds),
+		errors.Is(err, models.ErrBeneficiaryCoolingOff):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrSanctionsBlocked),
+		errors.Is(err, models.ErrSanctionsUnderReview),
+		errors.Is(err, models.ErrSuspectedFraud):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrClearingAccountNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func CreateOutgoingPayment(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+		return
+	}
+
+	var req models.CreateOutgoingPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.CheckScreening(customerRepo, userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err := kycSvc.CheckTransfer(userID, req.Amount); err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	fresh, method, err := steppedUp(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount > stepUpTransferThreshold && !fresh {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrStepUpRequired.Error(), "step_up": method})
+		return
+	}
+	opts := services.TransferOptions{StepUpVerified: fresh}
+
+	payment, err := outgoingPaymentSvc.CreatePayment(&req, userID, accountID, opts)
+	var challenge *models.StepUpRequiredError
+	switch {
+	case errors.As(err, &challenge):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "step_up": method, "rules": challenge.Rules})
+		return
+	case err != nil:
+		c.JSON(outgoingPaymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
+
+func ListOutgoingPayments(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || accountID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+		return
+	}
+
+	payments, err := outgoingPaymentSvc.ListPayments(accountID, userID)
+	if err != nil {
+		c.JSON(outgoingPaymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payments)
+}
+
+func GetOutgoingPayment(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	payment, err := outgoingPaymentSvc.GetPayment(id, userID)
+	if err != nil {
+		c.JSON(outgoingPaymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
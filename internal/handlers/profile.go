@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/notifications"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PROFILE
+
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrCustomerNotFound),
+		errors.Is(err, models.ErrContactChangeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrEmailInUse),
+		errors.Is(err, models.ErrContactChangeNotPending):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrContactChangeExpired):
+		return http.StatusGone
+	case errors.Is(err, models.ErrInvalidVerificationCode),
+		errors.Is(err, models.ErrIncorrectPassword):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrBlankName),
+		errors.Is(err, models.ErrSamePassword),
+		errors.As(err, new(*models.PasswordPolicyError)):
+		return http.StatusBadRequest
+	case errors.Is(err, notifications.ErrChannelUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func GetProfile(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	customer, err := profileSvc.GetProfile(userID)
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateProfile changes name and address. Email and phone go through
+// ChangeEmail and ChangePhone, which verify the new value first.
+func UpdateProfile(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := profileSvc.WithAudit(audit.FromContext(c)).UpdateProfile(userID, &req)
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+func ChangeEmail(c *gin.Context) {
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	requestContactChange(c, models.ChannelEmail, req.Email)
+}
+
+func ChangePhone(c *gin.Context) {
+	var req models.ChangePhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	requestContactChange(c, models.ChannelSMS, req.Phone)
+}
+
+func requestContactChange(c *gin.Context, channel, value string) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	change, err := profileSvc.WithAudit(audit.FromContext(c)).RequestContactChange(userID, channel, value)
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, change)
+}
+
+func ConfirmContactChange(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid contact change id"})
+		return
+	}
+
+	var req models.ConfirmContactChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := profileSvc.WithAudit(audit.FromContext(c)).ConfirmContactChange(userID, id, req.Code)
+	if err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+func ChangePassword(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := profileSvc.WithAudit(audit.FromContext(c)).ChangePassword(userID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+func ListProfileChanges(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	changes, err := profileSvc.ListChanges(userID, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
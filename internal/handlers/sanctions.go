@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SANCTIONS SCREENING
+
+func sanctionsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrSanctionsHitNotFound),
+		errors.Is(err, models.ErrSanctionsListNotLoaded):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrSanctionsHitNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// admin: list
+
+func GetSanctionsList(c *gin.Context) {
+	list, err := sanctionsSvc.CurrentList()
+	if err != nil {
+		c.JSON(sanctionsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RescreenSanctions reloads the list file and screens everyone against it,
+// whether or not it changed.
+func RescreenSanctions(c *gin.Context) {
+	if _, err := sanctionsSvc.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := sanctionsSvc.Rescreen()
+	if err != nil {
+		c.JSON(sanctionsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// admin: review queue
+
+func ListSanctionsHits(c *gin.Context) {
+	status := c.DefaultQuery("status", models.SanctionsHitPending)
+	if status == "all" {
+		status = ""
+	}
+
+	hits, err := sanctionsSvc.ListHits(status, 200)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hits)
+}
+
+func GetSanctionsHit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sanctions hit id"})
+		return
+	}
+
+	hit, err := sanctionsSvc.GetHit(id)
+	if err != nil {
+		c.JSON(sanctionsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hit)
+}
+
+func ConfirmSanctionsHit(c *gin.Context) {
+	reviewSanctionsHit(c, true)
+}
+
+func DismissSanctionsHit(c *gin.Context) {
+	reviewSanctionsHit(c, false)
+}
+
+func reviewSanctionsHit(c *gin.Context, confirm bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sanctions hit id"})
+		return
+	}
+
+	// the note is optional, and so is the body
+	var req models.ReviewSanctionsHitRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hit, err := sanctionsSvc.WithAudit(audit.FromContext(c)).ReviewHit(id, confirm, req.Note)
+	if err != nil {
+		c.JSON(sanctionsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hit)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SESSIONS
+
+func sessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidRefreshToken), errors.Is(err, models.ErrRefreshTokenReused):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrSanctionsBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrSessionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := sessionSvc.WithAudit(audit.FromContext(c)).Refresh(req.RefreshToken)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout ends the session the token belongs to, or every session of the
+// customer with ?all=true.
+func Logout(c *gin.Context) {
+	claims, err := auth.ParseToken(auth.BearerToken(c))
+	if err != nil || claims.Kind != auth.KindCustomer {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	sessionID := claims.SessionID
+	if c.Query("all") == "true" {
+		sessionID = ""
+	}
+	if err := sessionSvc.WithAudit(audit.FromContext(c)).Logout(claims.UserID, sessionID); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "signed out"})
+}
+
+func ListSessions(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	sessions, err := sessionSvc.ListSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs out one of the customer's other devices.
+func RevokeSession(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	if err := sessionSvc.WithAudit(audit.FromContext(c)).Logout(userID, c.Param("id")); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TOKEN SIGNING KEYS
+
+// JWKS publishes the public keys tokens are verified with, so other
+// services can check them without sharing a secret.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, signingKeys.JWKS())
+}
+
+// admin: start signing with a new key now, e.g. when the current one may
+// have leaked; the old one keeps verifying until it is pruned
+func RotateSigningKey(c *gin.Context) {
+	key, err := signingKeys.Rotate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auditLog.Record(dbConn, audit.FromContext(c), audit.Entry{
+		Action:     "signing_key.rotate",
+		EntityType: "signing_key",
+		After:      gin.H{"kid": key.ID, "alg": key.Alg},
+	}); err != nil {
+		log.Printf("audit signing key rotation: %v", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "signing key rotated", "kid": key.ID, "alg": key.Alg})
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/services"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// STAFF
+
+func staffErrorStatus(err error) int {
+	var policy *models.PasswordPolicyError
+	switch {
+	case errors.Is(err, models.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrStaffNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrStaffUsernameTaken), errors.Is(err, models.ErrDuplicateRole):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrCannotModifySelf):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrRoleNotFound), errors.Is(err, models.ErrUnknownPermission),
+		errors.Is(err, models.ErrReservedRoleName), errors.As(err, &policy):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// StaffAuthMiddleware admits requests with a staff token from an active
+// staff user whose tokens have not been revoked since it was issued. The
+// staff user becomes the audit actor.
+func StaffAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := auth.ParseToken(auth.BearerToken(c))
+		if err != nil || claims.Kind != auth.KindStaff {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if err := staffSvc.Check(claims.UserID, claims.IssuedAt); err != nil {
+			if errors.Is(err, models.ErrSessionRevoked) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		auth.SetClaims(c, claims)
+		audit.SetActor(c, models.ActorStaff, claims.UserID)
+		c.Next()
+	}
+}
+
+func StaffLogin(c *gin.Context) {
+	var req models.StaffLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffKey := services.LoginKey(services.LoginKeyStaff, req.Username)
+	ipKey := services.LoginKey(services.LoginKeyIP, c.ClientIP())
+	if !checkLoginThrottle(c, staffKey, ipKey) {
+		return
+	}
+
+	token, err := staffSvc.WithAudit(audit.FromContext(c)).Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			failLogin(c, staffKey, ipKey)
+		}
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := lockoutSvc.Succeed(staffKey); err != nil {
+		log.Printf("clear failed sign-ins for %s: %v", staffKey, err)
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// GetCurrentStaff returns the signed-in staff user and what their token
+// allows.
+func GetCurrentStaff(c *gin.Context) {
+	claims := auth.ClaimsFrom(c)
+	staff, err := staffSvc.GetStaff(claims.UserID)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"staff": staff, "permissions": claims.Permissions})
+}
+
+func ListStaff(c *gin.Context) {
+	staff, err := staffSvc.ListStaff()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+func GetStaff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+
+	staff, err := staffSvc.GetStaff(id)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+func CreateStaff(c *gin.Context) {
+	var req models.CreateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staff, err := staffSvc.WithAudit(audit.FromContext(c)).CreateStaff(&req)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, staff)
+}
+
+func UpdateStaff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid staff id"})
+		return
+	}
+
+	var req models.UpdateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staff, err := staffSvc.WithAudit(audit.FromContext(c)).UpdateStaff(id, &req)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, staff)
+}
+
+func ListRoles(c *gin.Context) {
+	roles, err := staffSvc.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": models.AllPermissions})
+}
+
+func CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := staffSvc.WithAudit(audit.FromContext(c)).CreateRole(&req)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+func UpdateRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := staffSvc.WithAudit(audit.FromContext(c)).UpdateRole(id, &req)
+	if err != nil {
+		c.JSON(staffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/atmswitch"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+// StartSwitchListener starts the ISO 8583 listener for the ATM/POS switch
+// on ISO8583_ADDR (e.g. ":8583"). Without an address it does nothing.
+//
+// The switch authenticates with a client certificate (ISO8583_TLS_CERT,
+// ISO8583_TLS_KEY and the CA in ISO8583_CLIENT_CA), optionally restricted
+// further to the addresses in ISO8583_ALLOWED_PEERS. For a local simulator
+// ISO8583_PLAINTEXT=true drops TLS, and then only loopback may connect
+// unless ISO8583_ALLOWED_PEERS says otherwise.
+func StartSwitchListener() {
+	addr := config.String("ISO8583_ADDR", "")
+	if addr == "" {
+		return
+	}
+
+	server := &atmswitch.Server{
+		Addr:        addr,
+		Processor:   atmswitch.NewProcessor(dbConn, repositories.NewSwitchTransactionRepo(dbConn), accountSvc, cardSvc, customerRepo, fraudSvc, kycSvc),
+		IdleTimeout: config.Duration("ISO8583_IDLE_TIMEOUT", 5*time.Minute),
+	}
+
+	peers := config.String("ISO8583_ALLOWED_PEERS", "")
+	if config.Bool("ISO8583_PLAINTEXT", false) {
+		log.Printf("iso8583 listener without TLS")
+		if peers == "" {
+			peers = "127.0.0.1,::1"
+		}
+	} else {
+		certFile, keyFile, caFile := config.String("ISO8583_TLS_CERT", ""), config.String("ISO8583_TLS_KEY", ""), config.String("ISO8583_CLIENT_CA", "")
+		if certFile == "" || keyFile == "" || caFile == "" {
+			log.Fatal("iso8583 listener: ISO8583_TLS_CERT, ISO8583_TLS_KEY and ISO8583_CLIENT_CA are required")
+		}
+		tlsConfig, err := atmswitch.ServerTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			log.Fatalf("iso8583 listener: %v", err)
+		}
+		server.TLSConfig = tlsConfig
+	}
+	if peers != "" {
+		allowed, err := atmswitch.ParsePeers(peers)
+		if err != nil {
+			log.Fatalf("iso8583 listener: ISO8583_ALLOWED_PEERS: %v", err)
+		}
+		server.AllowedPeers = allowed
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			log.Fatalf("iso8583 listener: %v", err)
+		}
+	}()
+}
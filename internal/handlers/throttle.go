@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/config"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/ratelimit"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SIGN-IN THROTTLING AND RATE LIMITS
+
+// RateLimit limits a route group to RATE_LIMIT_<NAME>_PER_MINUTE requests
+// per key, in bursts of up to RATE_LIMIT_<NAME>_BURST. A rate of 0 turns
+// the limit off.
+func RateLimit(name string, perMinute float64, burst int, key ratelimit.KeyFunc) gin.HandlerFunc {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(name)
+	limit := ratelimit.PerMinute(
+		config.Float(prefix+"_PER_MINUTE", perMinute),
+		config.Int(prefix+"_BURST", burst),
+	)
+	return ratelimit.Middleware(rateLimitStore, name, limit, key)
+}
+
+// ByCustomer keys requests by the signed-in customer, falling back to the
+// client address. API clients are left to ClientRateLimit.
+func ByCustomer(c *gin.Context) string {
+	if claims := auth.ClaimsFrom(c); claims != nil && claims.Kind == auth.KindClient {
+		return ""
+	}
+	if userID := auth.GetUserID(c); userID != 0 {
+		return "customer:" + strconv.Itoa(userID)
+	}
+	return ratelimit.ByIP(c)
+}
+
+// ByStaff keys requests by the signed-in staff user. It must run after
+// StaffAuthMiddleware.
+func ByStaff(c *gin.Context) string {
+	if claims := auth.ClaimsFrom(c); claims != nil {
+		return "staff:" + strconv.Itoa(claims.UserID)
+	}
+	return ratelimit.ByIP(c)
+}
+
+// respondThrottled writes a 429 with Retry-After when err is a sign-in
+// throttle, and reports whether it did.
+func respondThrottled(c *gin.Context, err error) bool {
+	var throttled *models.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
+// checkLoginThrottle refuses the request when any key is throttled. It
+// writes the response and returns false when refused.
+func checkLoginThrottle(c *gin.Context, keys ...string) bool {
+	err := lockoutSvc.Check(keys...)
+	if err == nil {
+		return true
+	}
+	if !respondThrottled(c, err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return false
+}
+
+// failLogin records a failed attempt. Failures are logged; they must not
+// change the response.
+func failLogin(c *gin.Context, keys ...string) {
+	if err := lockoutSvc.WithAudit(audit.FromContext(c)).Fail(keys...); err != nil {
+		log.Printf("record failed sign-in for %v: %v", keys, err)
+	}
+}
+
+// admin: keys currently locked out
+func ListLockouts(c *gin.Context) {
+	locked, err := lockoutSvc.ListLocked()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, locked)
+}
+
+// admin: clear a key's failures, e.g. "user:alice" or "ip:203.0.113.7"
+func UnlockLogin(c *gin.Context) {
+	var req models.UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := lockoutSvc.WithAudit(audit.FromContext(c)).Unlock(req.Key); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrLockoutNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unlocked"})
+}
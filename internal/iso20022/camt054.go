@@ -0,0 +1,192 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InboundCredit is one booked credit from a camt.054 notification (or the
+// CSV variant). Reference is unique per credit and is what imports use to
+// stay idempotent.
+type InboundCredit struct {
+	Reference    string
+	EndToEndID   string
+	Amount       float64
+	Currency     string
+	CreditorAcct string
+	DebtorName   string
+	Remittance   string
+	BookingDate  time.Time
+}
+
+// CreditNotification is a parsed camt.054 document.
+type CreditNotification struct {
+	MessageID string
+	Credits   []InboundCredit
+}
+
+type camt054Document struct {
+	XMLName xml.Name `xml:"Document"`
+	Body    *struct {
+		GrpHdr struct {
+			MsgID string `xml:"MsgId"`
+		} `xml:"GrpHdr"`
+		Ntfctn []struct {
+			ID   string      `xml:"Id"`
+			Acct cashAccount `xml:"Acct"`
+			Ntry []camtEntry `xml:"Ntry"`
+		} `xml:"Ntfctn"`
+	} `xml:"BkToCstmrDbtCdtNtfctn"`
+}
+
+type camtEntry struct {
+	NtryRef     string         `xml:"NtryRef"`
+	Amt         currencyAmount `xml:"Amt"`
+	CdtDbtInd   string         `xml:"CdtDbtInd"`
+	Sts         entryStatus    `xml:"Sts"`
+	BookgDt     dateOrDateTime `xml:"BookgDt"`
+	AcctSvcrRef string         `xml:"AcctSvcrRef"`
+	NtryDtls    []struct {
+		TxDtls []camtTxDetails `xml:"TxDtls"`
+	} `xml:"NtryDtls"`
+}
+
+// entryStatus covers both <Sts>BOOK</Sts> (camt.054.001.02) and
+// <Sts><Cd>BOOK</Cd></Sts> (camt.054.001.08).
+type entryStatus struct {
+	Value string `xml:",chardata"`
+	Cd    string `xml:"Cd"`
+}
+
+func (s entryStatus) code() string {
+	if s.Cd != "" {
+		return strings.TrimSpace(s.Cd)
+	}
+	return strings.TrimSpace(s.Value)
+}
+
+type dateOrDateTime struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+func (d dateOrDateTime) time() time.Time {
+	if t, err := time.Parse("2006-01-02", d.Dt); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", strings.TrimSuffix(d.DtTm, "Z")); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+type camtTxDetails struct {
+	Refs struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+		EndToEndID  string `xml:"EndToEndId"`
+	} `xml:"Refs"`
+	Amt       *currencyAmount `xml:"Amt"`
+	RltdPties struct {
+		Dbtr     partyName    `xml:"Dbtr"`
+		CdtrAcct *cashAccount `xml:"CdtrAcct"`
+	} `xml:"RltdPties"`
+	RmtInf struct {
+		Ustrd []string `xml:"Ustrd"`
+	} `xml:"RmtInf"`
+}
+
+func (a cashAccount) identifier() string {
+	if a.ID.IBAN != "" {
+		return strings.TrimSpace(a.ID.IBAN)
+	}
+	if a.ID.Othr != nil {
+		return strings.TrimSpace(a.ID.Othr.ID)
+	}
+	return ""
+}
+
+// ParseCamt054 reads booked credit entries from a camt.054 notification.
+// Debits and entries that are not booked are ignored. An entry with
+// several TxDtls yields one credit per transaction.
+func ParseCamt054(r io.Reader) (*CreditNotification, error) {
+	var doc camt054Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse camt.054: %w", err)
+	}
+	if doc.Body == nil {
+		return nil, ErrUnsupportedMessage
+	}
+	if doc.Body.GrpHdr.MsgID == "" {
+		return nil, errors.New("parse camt.054: missing GrpHdr/MsgId")
+	}
+
+	n := &CreditNotification{MessageID: doc.Body.GrpHdr.MsgID}
+	for _, ntfctn := range doc.Body.Ntfctn {
+		for i, e := range ntfctn.Ntry {
+			if e.CdtDbtInd != "CRDT" {
+				continue
+			}
+			if st := e.Sts.code(); st != "" && st != "BOOK" {
+				continue
+			}
+
+			entryRef := e.AcctSvcrRef
+			if entryRef == "" {
+				entryRef = e.NtryRef
+			}
+			if entryRef == "" {
+				entryRef = fmt.Sprintf("%s/%d", ntfctn.ID, i+1)
+			}
+
+			var details []camtTxDetails
+			for _, d := range e.NtryDtls {
+				details = append(details, d.TxDtls...)
+			}
+			if len(details) == 0 {
+				details = []camtTxDetails{{}}
+			}
+
+			for j, d := range details {
+				credit := InboundCredit{
+					Reference:    entryRef,
+					EndToEndID:   strings.TrimSpace(d.Refs.EndToEndID),
+					Currency:     e.Amt.Ccy,
+					CreditorAcct: ntfctn.Acct.identifier(),
+					DebtorName:   strings.TrimSpace(d.RltdPties.Dbtr.Nm),
+					Remittance:   strings.TrimSpace(strings.Join(d.RmtInf.Ustrd, " ")),
+					BookingDate:  e.BookgDt.time(),
+				}
+				if len(details) > 1 {
+					credit.Reference = fmt.Sprintf("%s/%d", entryRef, j+1)
+				}
+				if d.Refs.AcctSvcrRef != "" {
+					credit.Reference = d.Refs.AcctSvcrRef
+				}
+				if d.RltdPties.CdtrAcct != nil && d.RltdPties.CdtrAcct.identifier() != "" {
+					credit.CreditorAcct = d.RltdPties.CdtrAcct.identifier()
+				}
+
+				amt := e.Amt
+				if d.Amt != nil {
+					amt = *d.Amt
+				}
+				v, err := strconv.ParseFloat(strings.TrimSpace(amt.Value), 64)
+				if err != nil || v <= 0 {
+					return nil, fmt.Errorf("parse camt.054: entry %s has invalid amount %q", credit.Reference, amt.Value)
+				}
+				credit.Amount = v
+				if amt.Ccy != "" {
+					credit.Currency = amt.Ccy
+				}
+
+				n.Credits = append(n.Credits, credit)
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,104 @@
+package iso20022
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCamt054Sample(t *testing.T) {
+	n, err := ParseCamt054(openSample(t, "camt.054.sample.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.MessageID != "CAMT054-20261019-0001" {
+		t.Errorf("message ID = %q", n.MessageID)
+	}
+
+	booked := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	want := []InboundCredit{
+		{
+			Reference:    "BANKREF-0001",
+			EndToEndID:   "SALARY-OCT",
+			Amount:       2500,
+			Currency:     "INR",
+			CreditorAcct: "000000000001",
+			DebtorName:   "Acme Payroll Ltd",
+			Remittance:   "October salary",
+			BookingDate:  booked,
+		},
+		{
+			// no creditor account on the transaction: the notified account
+			Reference:    "BANKREF-0002",
+			Amount:       120,
+			Currency:     "INR",
+			CreditorAcct: "NOSTRO-001",
+			DebtorName:   "Unknown Sender",
+			Remittance:   "refund",
+			BookingDate:  booked,
+		},
+	}
+	if len(n.Credits) != len(want) {
+		t.Fatalf("got %d credits, want %d: %+v", len(n.Credits), len(want), n.Credits)
+	}
+	for i := range want {
+		got := n.Credits[i]
+		if !got.BookingDate.Equal(want[i].BookingDate) {
+			t.Errorf("credit %d booked %v, want %v", i, got.BookingDate, want[i].BookingDate)
+		}
+		got.BookingDate = want[i].BookingDate
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("credit %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestParseCamt054SkipsDebitsAndPending(t *testing.T) {
+	n, err := ParseCamt054(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.08">
+  <BkToCstmrDbtCdtNtfctn>
+    <GrpHdr><MsgId>CAMT054-MIXED</MsgId></GrpHdr>
+    <Ntfctn>
+      <Id>NTF</Id>
+      <Ntry><Amt Ccy="INR">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><AcctSvcrRef>D1</AcctSvcrRef></Ntry>
+      <Ntry><Amt Ccy="INR">6.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts><AcctSvcrRef>P1</AcctSvcrRef></Ntry>
+      <Ntry>
+        <Amt Ccy="INR">30.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><AcctSvcrRef>B1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls><Amt Ccy="INR">10.00</Amt></TxDtls>
+          <TxDtls><Amt Ccy="INR">20.00</Amt></TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	var total float64
+	for _, c := range n.Credits {
+		refs = append(refs, c.Reference)
+		total += c.Amount
+	}
+	if !reflect.DeepEqual(refs, []string{"B1/1", "B1/2"}) || total != 30 {
+		t.Errorf("credits = %v totalling %v", refs, total)
+	}
+}
+
+func TestParseCreditCSVSample(t *testing.T) {
+	n, err := ParseCreditCSV(openSample(t, "credits.sample.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Credits) != 2 {
+		t.Fatalf("got %d credits, want 2", len(n.Credits))
+	}
+	first := n.Credits[0]
+	if first.Reference != "CSVREF-0001" || first.CreditorAcct != "000000000001" || first.Amount != 750 || first.DebtorName != "Jane Doe" {
+		t.Errorf("first credit = %+v", first)
+	}
+	if second := n.Credits[1]; second.CreditorAcct != "" || second.Remittance != "invoice 000000000002" {
+		t.Errorf("second credit = %+v", second)
+	}
+}
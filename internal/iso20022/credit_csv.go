@@ -0,0 +1,78 @@
+package iso20022
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var creditCSVColumns = []string{"reference", "account_number", "amount", "currency", "debtor_name", "remittance", "booking_date"}
+
+// ParseCreditCSV reads the simple CSV alternative to camt.054. The first
+// row must be the header
+//
+//	reference,account_number,amount,currency,debtor_name,remittance,booking_date
+//
+// with booking_date as YYYY-MM-DD. The message ID is derived from the file
+// content, so the same file always gets the same ID.
+func ParseCreditCSV(r io.Reader) (*CreditNotification, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse credit csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("parse credit csv: empty file")
+	}
+
+	header := rows[0]
+	if len(header) != len(creditCSVColumns) {
+		return nil, fmt.Errorf("parse credit csv: expected header %s", strings.Join(creditCSVColumns, ","))
+	}
+	for i, col := range creditCSVColumns {
+		if strings.ToLower(strings.TrimSpace(header[i])) != col {
+			return nil, fmt.Errorf("parse credit csv: expected header %s", strings.Join(creditCSVColumns, ","))
+		}
+	}
+
+	n := &CreditNotification{MessageID: "CSV-" + hex.EncodeToString(sum[:])[:24]}
+	for i, row := range rows[1:] {
+		line := i + 2
+		amount, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("parse credit csv: line %d: invalid amount %q", line, row[2])
+		}
+		ref := strings.TrimSpace(row[0])
+		if ref == "" {
+			return nil, fmt.Errorf("parse credit csv: line %d: reference is required", line)
+		}
+
+		credit := InboundCredit{
+			Reference:    ref,
+			Amount:       amount,
+			Currency:     strings.ToUpper(strings.TrimSpace(row[3])),
+			CreditorAcct: strings.TrimSpace(row[1]),
+			DebtorName:   strings.TrimSpace(row[4]),
+			Remittance:   strings.TrimSpace(row[5]),
+		}
+		if d := strings.TrimSpace(row[6]); d != "" {
+			t, err := time.Parse("2006-01-02", d)
+			if err != nil {
+				return nil, fmt.Errorf("parse credit csv: line %d: invalid booking_date %q", line, d)
+			}
+			credit.BookingDate = t
+		}
+		n.Credits = append(n.Credits, credit)
+	}
+	return n, nil
+}
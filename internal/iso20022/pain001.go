@@ -0,0 +1,222 @@
+// Package iso20022 builds and parses the ISO 20022 XML messages exchanged
+// with the clearing bank: pain.001 credit transfer initiations going out,
+// pain.002/pacs.002 payment status reports and camt.054 notifications
+// coming back.
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// CreditTransfer is one outbound payment in a pain.001 batch.
+type CreditTransfer struct {
+	EndToEndID    string
+	Amount        float64
+	Currency      string
+	CreditorName  string
+	CreditorAcct  string // IBAN or domestic account number
+	CreditorAgent string // BIC or domestic clearing code (e.g. IFSC)
+	Remittance    string
+}
+
+// DebtorBatch groups the transfers debited from one account into a
+// pain.001 PmtInf block.
+type DebtorBatch struct {
+	PaymentInfoID string
+	DebtorName    string
+	DebtorAcct    string
+	Transfers     []CreditTransfer
+}
+
+// Pain001 describes a whole credit transfer initiation file.
+type Pain001 struct {
+	MessageID      string
+	CreatedAt      time.Time
+	InitiatingName string
+	DebtorAgentBIC string
+	ExecutionDate  time.Time
+	Batches        []DebtorBatch
+}
+
+type pain001Document struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Initn   pain001Initiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Initiation struct {
+	GrpHdr groupHeader   `xml:"GrpHdr"`
+	PmtInf []paymentInfo `xml:"PmtInf"`
+}
+
+type groupHeader struct {
+	MsgID    string    `xml:"MsgId"`
+	CreDtTm  string    `xml:"CreDtTm"`
+	NbOfTxs  int       `xml:"NbOfTxs"`
+	CtrlSum  string    `xml:"CtrlSum"`
+	InitgPty partyName `xml:"InitgPty"`
+}
+
+type partyName struct {
+	Nm string `xml:"Nm"`
+}
+
+type paymentInfo struct {
+	PmtInfID    string                `xml:"PmtInfId"`
+	PmtMtd      string                `xml:"PmtMtd"`
+	NbOfTxs     int                   `xml:"NbOfTxs"`
+	CtrlSum     string                `xml:"CtrlSum"`
+	ReqdExctnDt string                `xml:"ReqdExctnDt"`
+	Dbtr        partyName             `xml:"Dbtr"`
+	DbtrAcct    cashAccount           `xml:"DbtrAcct"`
+	DbtrAgt     agent                 `xml:"DbtrAgt"`
+	CdtTrfTxInf []creditTransferTxInf `xml:"CdtTrfTxInf"`
+}
+
+type cashAccount struct {
+	ID accountID `xml:"Id"`
+}
+
+type accountID struct {
+	IBAN string         `xml:"IBAN,omitempty"`
+	Othr *genericIDElem `xml:"Othr,omitempty"`
+}
+
+type genericIDElem struct {
+	ID string `xml:"Id"`
+}
+
+type agent struct {
+	FinInstnID finInstnID `xml:"FinInstnId"`
+}
+
+type finInstnID struct {
+	BIC       string     `xml:"BIC,omitempty"`
+	ClrSysMmb *clrSysMmb `xml:"ClrSysMmbId,omitempty"`
+}
+
+type clrSysMmb struct {
+	MmbID string `xml:"MmbId"`
+}
+
+type creditTransferTxInf struct {
+	PmtID    paymentID       `xml:"PmtId"`
+	Amt      amount          `xml:"Amt"`
+	CdtrAgt  agent           `xml:"CdtrAgt"`
+	Cdtr     partyName       `xml:"Cdtr"`
+	CdtrAcct cashAccount     `xml:"CdtrAcct"`
+	RmtInf   *remittanceInfo `xml:"RmtInf,omitempty"`
+}
+
+type paymentID struct {
+	InstrID    string `xml:"InstrId"`
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+type amount struct {
+	InstdAmt currencyAmount `xml:"InstdAmt"`
+}
+
+type currencyAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type remittanceInfo struct {
+	Ustrd string `xml:"Ustrd"`
+}
+
+// Write encodes the batch as a pain.001.001.03 document.
+func (p *Pain001) Write(w io.Writer) error {
+	doc := pain001Document{
+		Xmlns: pain001Namespace,
+		Initn: pain001Initiation{
+			GrpHdr: groupHeader{
+				MsgID:    p.MessageID,
+				CreDtTm:  p.CreatedAt.UTC().Format("2006-01-02T15:04:05"),
+				InitgPty: partyName{Nm: p.InitiatingName},
+			},
+		},
+	}
+
+	var total float64
+	var count int
+	for _, b := range p.Batches {
+		info := paymentInfo{
+			PmtInfID:    b.PaymentInfoID,
+			PmtMtd:      "TRF",
+			NbOfTxs:     len(b.Transfers),
+			ReqdExctnDt: p.ExecutionDate.Format("2006-01-02"),
+			Dbtr:        partyName{Nm: b.DebtorName},
+			DbtrAcct:    newCashAccount(b.DebtorAcct),
+			DbtrAgt:     agent{FinInstnID: finInstnID{BIC: p.DebtorAgentBIC}},
+		}
+
+		var batchTotal float64
+		for _, t := range b.Transfers {
+			tx := creditTransferTxInf{
+				PmtID:    paymentID{InstrID: t.EndToEndID, EndToEndID: t.EndToEndID},
+				Amt:      amount{InstdAmt: currencyAmount{Ccy: t.Currency, Value: formatAmount(t.Amount)}},
+				CdtrAgt:  newAgent(t.CreditorAgent),
+				Cdtr:     partyName{Nm: t.CreditorName},
+				CdtrAcct: newCashAccount(t.CreditorAcct),
+			}
+			if t.Remittance != "" {
+				tx.RmtInf = &remittanceInfo{Ustrd: t.Remittance}
+			}
+			info.CdtTrfTxInf = append(info.CdtTrfTxInf, tx)
+			batchTotal += t.Amount
+		}
+		info.CtrlSum = formatAmount(batchTotal)
+
+		doc.Initn.PmtInf = append(doc.Initn.PmtInf, info)
+		total += batchTotal
+		count += len(b.Transfers)
+	}
+	doc.Initn.GrpHdr.NbOfTxs = count
+	doc.Initn.GrpHdr.CtrlSum = formatAmount(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatAmount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// newCashAccount uses the IBAN element for IBANs and Othr/Id otherwise.
+func newCashAccount(acct string) cashAccount {
+	if isIBAN(acct) {
+		return cashAccount{ID: accountID{IBAN: acct}}
+	}
+	return cashAccount{ID: accountID{Othr: &genericIDElem{ID: acct}}}
+}
+
+// newAgent uses the BIC element for BICs and a clearing system member ID
+// for domestic codes such as IFSC.
+func newAgent(code string) agent {
+	if len(code) == 8 || (len(code) == 11 && code[4] != '0') {
+		return agent{FinInstnID: finInstnID{BIC: code}}
+	}
+	return agent{FinInstnID: finInstnID{ClrSysMmb: &clrSysMmb{MmbID: code}}}
+}
+
+func isIBAN(acct string) bool {
+	if len(acct) < 15 {
+		return false
+	}
+	return acct[0] >= 'A' && acct[0] <= 'Z' && acct[1] >= 'A' && acct[1] <= 'Z'
+}
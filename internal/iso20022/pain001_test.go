@@ -0,0 +1,137 @@
+package iso20022
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sampleMessageID is the pain.001 the sample status reports answer.
+const sampleMessageID = "PAIN001-20261019090000000"
+
+func openSample(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("..", "..", "cmd", "clearing", "samples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// samplePain001 is the export the sample status reports answer: four
+// payments, OP0000000001 to OP0000000004, from two accounts.
+func samplePain001() *Pain001 {
+	created := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	return &Pain001{
+		MessageID:      sampleMessageID,
+		CreatedAt:      created,
+		InitiatingName: "Banking API",
+		DebtorAgentBIC: "BAPIINBBXXX",
+		ExecutionDate:  created,
+		Batches: []DebtorBatch{
+			{
+				PaymentInfoID: sampleMessageID + "-1",
+				DebtorName:    "Jane Doe",
+				DebtorAcct:    "000000000001",
+				Transfers: []CreditTransfer{
+					{EndToEndID: "OP0000000001", Amount: 100, Currency: "INR", CreditorName: "Acme", CreditorAcct: "GB82WEST12345698765432", CreditorAgent: "WESTGB2L", Remittance: "invoice 7"},
+					{EndToEndID: "OP0000000002", Amount: 250.5, Currency: "INR", CreditorName: "Closed Ltd", CreditorAcct: "123456789099", CreditorAgent: "HDFC0001234"},
+					{EndToEndID: "OP0000000003", Amount: 10, Currency: "INR", CreditorName: "Bob", CreditorAcct: "987654321", CreditorAgent: "SBIN0000001"},
+				},
+			},
+			{
+				PaymentInfoID: sampleMessageID + "-2",
+				DebtorName:    "John Roe",
+				DebtorAcct:    "000000000002",
+				Transfers: []CreditTransfer{
+					{EndToEndID: "OP0000000004", Amount: 39.49, Currency: "INR", CreditorName: "Carol", CreditorAcct: "55554444", CreditorAgent: "ICIC0000002"},
+				},
+			},
+		},
+	}
+}
+
+func TestPain001WriteRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := samplePain001().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc pain001Document
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("exported file does not parse: %v", err)
+	}
+	if doc.Xmlns != pain001Namespace {
+		t.Errorf("namespace = %q", doc.Xmlns)
+	}
+	hdr := doc.Initn.GrpHdr
+	if hdr.MsgID != sampleMessageID || hdr.NbOfTxs != 4 || hdr.CtrlSum != "399.99" {
+		t.Errorf("group header = %+v", hdr)
+	}
+	if hdr.CreDtTm != "2026-10-19T09:00:00" {
+		t.Errorf("CreDtTm = %q", hdr.CreDtTm)
+	}
+
+	if len(doc.Initn.PmtInf) != 2 {
+		t.Fatalf("got %d PmtInf, want 2", len(doc.Initn.PmtInf))
+	}
+	first := doc.Initn.PmtInf[0]
+	if first.NbOfTxs != 3 || first.CtrlSum != "360.50" || first.Dbtr.Nm != "Jane Doe" {
+		t.Errorf("first PmtInf = %+v", first)
+	}
+	if first.DbtrAcct.identifier() != "000000000001" || first.DbtrAgt.FinInstnID.BIC != "BAPIINBBXXX" {
+		t.Errorf("debtor account/agent = %+v / %+v", first.DbtrAcct, first.DbtrAgt)
+	}
+
+	iban := first.CdtTrfTxInf[0]
+	if iban.PmtID.EndToEndID != "OP0000000001" || iban.CdtrAcct.ID.IBAN != "GB82WEST12345698765432" {
+		t.Errorf("IBAN transfer = %+v", iban)
+	}
+	if iban.CdtrAgt.FinInstnID.BIC != "WESTGB2L" || iban.RmtInf == nil || iban.RmtInf.Ustrd != "invoice 7" {
+		t.Errorf("IBAN transfer agent/remittance = %+v / %+v", iban.CdtrAgt, iban.RmtInf)
+	}
+	if iban.Amt.InstdAmt.Ccy != "INR" || iban.Amt.InstdAmt.Value != "100.00" {
+		t.Errorf("amount = %+v", iban.Amt.InstdAmt)
+	}
+
+	domestic := first.CdtTrfTxInf[1]
+	if domestic.CdtrAcct.ID.Othr == nil || domestic.CdtrAcct.ID.Othr.ID != "123456789099" {
+		t.Errorf("domestic account = %+v", domestic.CdtrAcct)
+	}
+	if domestic.CdtrAgt.FinInstnID.ClrSysMmb == nil || domestic.CdtrAgt.FinInstnID.ClrSysMmb.MmbID != "HDFC0001234" {
+		t.Errorf("domestic agent = %+v", domestic.CdtrAgt)
+	}
+	if domestic.RmtInf != nil {
+		t.Errorf("empty remittance written: %+v", domestic.RmtInf)
+	}
+}
+
+// The sample status reports answer the sample export: every end-to-end
+// ID they report on is one it sent.
+func TestSampleReportsMatchPain001(t *testing.T) {
+	sent := map[string]bool{}
+	for _, b := range samplePain001().Batches {
+		for _, tr := range b.Transfers {
+			sent[tr.EndToEndID] = true
+		}
+	}
+
+	for _, name := range []string{"pain.002.sample.xml", "pacs.002.sample.xml"} {
+		report, err := ParseStatusReport(openSample(t, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if report.OriginalMessageID != sampleMessageID {
+			t.Errorf("%s: original message = %q", name, report.OriginalMessageID)
+		}
+		for _, tx := range report.Transactions {
+			if !sent[tx.OriginalEndToEndID] {
+				t.Errorf("%s: reports on %q, which was not exported", name, tx.OriginalEndToEndID)
+			}
+		}
+	}
+}
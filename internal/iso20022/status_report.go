@@ -0,0 +1,138 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Transaction status codes (ExternalPaymentTransactionStatus1Code) that
+// matter to us. ACCP, ACSP and ACSC all mean the clearing bank took the
+// payment; RJCT means it will not be executed.
+const (
+	StatusACCP = "ACCP" // accepted customer profile
+	StatusACSP = "ACSP" // accepted, settlement in process
+	StatusACSC = "ACSC" // accepted, settlement completed
+	StatusRJCT = "RJCT" // rejected
+	StatusPDNG = "PDNG" // pending
+)
+
+// IsAccepted reports whether a status code means the payment was accepted.
+func IsAccepted(code string) bool {
+	switch code {
+	case StatusACCP, StatusACSP, StatusACSC:
+		return true
+	}
+	return false
+}
+
+// TransactionStatus is the outcome of one original transaction.
+type TransactionStatus struct {
+	OriginalEndToEndID string
+	Status             string
+	ReasonCode         string
+	AdditionalInfo     string
+}
+
+// StatusReport is a parsed pain.002 or pacs.002 message.
+type StatusReport struct {
+	MessageType       string // "pain.002" or "pacs.002"
+	MessageID         string
+	OriginalMessageID string
+	GroupStatus       string
+	Transactions      []TransactionStatus
+}
+
+var ErrUnsupportedMessage = errors.New("unsupported ISO 20022 message")
+
+type statusReportDocument struct {
+	XMLName xml.Name
+	Pain002 *statusReportBody `xml:"CstmrPmtStsRpt"`
+	Pacs002 *statusReportBody `xml:"FIToFIPmtStsRpt"`
+}
+
+// Both messages share the same shape for the parts we read; pain.002
+// nests transaction statuses under OrgnlPmtInfAndSts, pacs.002 has them
+// directly under the report.
+type statusReportBody struct {
+	GrpHdr struct {
+		MsgID string `xml:"MsgId"`
+	} `xml:"GrpHdr"`
+	OrgnlGrpInfAndSts struct {
+		OrgnlMsgID string         `xml:"OrgnlMsgId"`
+		GrpSts     string         `xml:"GrpSts"`
+		StsRsnInf  []statusReason `xml:"StsRsnInf"`
+	} `xml:"OrgnlGrpInfAndSts"`
+	OrgnlPmtInfAndSts []struct {
+		PmtInfSts   string        `xml:"PmtInfSts"`
+		TxInfAndSts []txInfAndSts `xml:"TxInfAndSts"`
+	} `xml:"OrgnlPmtInfAndSts"`
+	TxInfAndSts []txInfAndSts `xml:"TxInfAndSts"`
+}
+
+type txInfAndSts struct {
+	OrgnlEndToEndID string         `xml:"OrgnlEndToEndId"`
+	TxSts           string         `xml:"TxSts"`
+	StsRsnInf       []statusReason `xml:"StsRsnInf"`
+}
+
+type statusReason struct {
+	Rsn struct {
+		Cd string `xml:"Cd"`
+	} `xml:"Rsn"`
+	AddtlInf []string `xml:"AddtlInf"`
+}
+
+// ParseStatusReport reads a pain.002.001.x or pacs.002.001.x document.
+// When the group status is RJCT and no per-transaction statuses are
+// present, the caller should treat every transaction of the original
+// message as rejected.
+func ParseStatusReport(r io.Reader) (*StatusReport, error) {
+	var doc statusReportDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse status report: %w", err)
+	}
+
+	report := &StatusReport{}
+	body := doc.Pain002
+	report.MessageType = "pain.002"
+	if body == nil {
+		body = doc.Pacs002
+		report.MessageType = "pacs.002"
+	}
+	if body == nil {
+		return nil, ErrUnsupportedMessage
+	}
+
+	report.MessageID = body.GrpHdr.MsgID
+	report.OriginalMessageID = body.OrgnlGrpInfAndSts.OrgnlMsgID
+	report.GroupStatus = body.OrgnlGrpInfAndSts.GrpSts
+
+	txs := body.TxInfAndSts
+	for _, p := range body.OrgnlPmtInfAndSts {
+		for _, tx := range p.TxInfAndSts {
+			if tx.TxSts == "" {
+				tx.TxSts = p.PmtInfSts
+			}
+			txs = append(txs, tx)
+		}
+	}
+	for _, tx := range txs {
+		status := TransactionStatus{
+			OriginalEndToEndID: strings.TrimSpace(tx.OrgnlEndToEndID),
+			Status:             tx.TxSts,
+		}
+		if len(tx.StsRsnInf) > 0 {
+			status.ReasonCode = tx.StsRsnInf[0].Rsn.Cd
+			status.AdditionalInfo = strings.Join(tx.StsRsnInf[0].AddtlInf, " ")
+		}
+		report.Transactions = append(report.Transactions, status)
+	}
+
+	if report.MessageID == "" {
+		return nil, errors.New("parse status report: missing GrpHdr/MsgId")
+	}
+	return report, nil
+}
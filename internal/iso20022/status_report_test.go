@@ -0,0 +1,77 @@
+package iso20022
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatusReportSamples(t *testing.T) {
+	tests := []struct {
+		file        string
+		messageType string
+		messageID   string
+		groupStatus string
+		want        []TransactionStatus
+	}{
+		{
+			file:        "pain.002.sample.xml",
+			messageType: "pain.002",
+			messageID:   "PAIN002-20261019-0001",
+			want: []TransactionStatus{
+				// no TxSts: the payment information status applies
+				{OriginalEndToEndID: "OP0000000003", Status: StatusACCP},
+				{OriginalEndToEndID: "OP0000000004", Status: StatusRJCT, ReasonCode: "AM04"},
+			},
+		},
+		{
+			file:        "pacs.002.sample.xml",
+			messageType: "pacs.002",
+			messageID:   "PACS002-20261019-0001",
+			groupStatus: "PART",
+			want: []TransactionStatus{
+				{OriginalEndToEndID: "OP0000000001", Status: StatusACSC},
+				{OriginalEndToEndID: "OP0000000002", Status: StatusRJCT, ReasonCode: "AC04", AdditionalInfo: "Closed account number"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			report, err := ParseStatusReport(openSample(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.MessageType != tt.messageType || report.MessageID != tt.messageID || report.GroupStatus != tt.groupStatus {
+				t.Errorf("report = %s %s %s", report.MessageType, report.MessageID, report.GroupStatus)
+			}
+			if !reflect.DeepEqual(report.Transactions, tt.want) {
+				t.Errorf("transactions = %+v, want %+v", report.Transactions, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatusReportGroupRejection(t *testing.T) {
+	report, err := ParseStatusReport(strings.NewReader(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pacs.002.001.10">
+  <FIToFIPmtStsRpt>
+    <GrpHdr><MsgId>PACS002-REJ</MsgId></GrpHdr>
+    <OrgnlGrpInfAndSts><OrgnlMsgId>` + sampleMessageID + `</OrgnlMsgId><GrpSts>RJCT</GrpSts></OrgnlGrpInfAndSts>
+  </FIToFIPmtStsRpt>
+</Document>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.GroupStatus != StatusRJCT || len(report.Transactions) != 0 || report.OriginalMessageID != sampleMessageID {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestParseStatusReportRejectsOtherMessages(t *testing.T) {
+	_, err := ParseStatusReport(openSample(t, "camt.054.sample.xml"))
+	if err != ErrUnsupportedMessage {
+		t.Errorf("err = %v, want ErrUnsupportedMessage", err)
+	}
+	if _, err := ParseStatusReport(strings.NewReader(`<Document><FIToFIPmtStsRpt/></Document>`)); err == nil {
+		t.Error("report without MsgId accepted")
+	}
+}
@@ -0,0 +1,81 @@
+package iso8583
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Response codes (field 39) we send.
+const (
+	RespApproved              = "00"
+	RespInvalidTransaction    = "12"
+	RespInvalidAmount         = "13"
+	RespInvalidCard           = "14"
+	RespOriginalNotFound      = "25"
+	RespFormatError           = "30"
+	RespInsufficientFunds     = "51"
+	RespExpiredCard           = "54"
+	RespNotPermitted          = "57"
+	RespSuspectedFraud        = "59"
+	RespRestrictedCard        = "62"
+	RespDuplicateTransmission = "94"
+	RespSystemError           = "96"
+)
+
+// Transaction types: the first two digits of the processing code (field 3).
+const (
+	ProcPurchase       = "00"
+	ProcCashWithdrawal = "01"
+	ProcBalanceInquiry = "31"
+)
+
+// Network management information codes (field 70).
+const (
+	NetworkSignOn  = "001"
+	NetworkSignOff = "002"
+	NetworkEcho    = "301"
+)
+
+// ParseAmount reads a field 4 amount in minor units.
+func ParseAmount(field string) (float64, error) {
+	minor, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("iso8583: invalid amount %q", field)
+	}
+	return float64(minor) / 100, nil
+}
+
+// FormatAmount renders amount in minor units for field 4.
+func FormatAmount(amount float64) string {
+	return fmt.Sprintf("%012d", int64(math.Round(math.Abs(amount)*100)))
+}
+
+// AdditionalAmount renders one field 54 entry: account type, amount type
+// ("01" ledger, "02" available), numeric currency, sign and amount.
+func AdditionalAmount(accountType, amountType, currency string, amount float64) string {
+	sign := "C"
+	if amount < 0 {
+		sign = "D"
+	}
+	return accountType + amountType + currency + sign + FormatAmount(amount)
+}
+
+var numericCurrencies = map[string]string{
+	"AED": "784",
+	"AUD": "036",
+	"CAD": "124",
+	"CHF": "756",
+	"EUR": "978",
+	"GBP": "826",
+	"INR": "356",
+	"JPY": "392",
+	"SGD": "702",
+	"USD": "840",
+}
+
+// NumericCurrency maps an ISO 4217 alphabetic code to its numeric code,
+// or "" if it is not one we know.
+func NumericCurrency(alpha string) string {
+	return numericCurrencies[alpha]
+}
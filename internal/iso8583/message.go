@@ -0,0 +1,256 @@
+// Package iso8583 encodes and decodes ISO 8583 (1987) messages in the
+// ASCII variant used by our test ATM switch: a four-digit MTI, hex-encoded
+// bitmaps and ASCII data elements. Frames on the wire carry a two-byte
+// big-endian length prefix.
+package iso8583
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Message type indicators we handle.
+const (
+	MTIAuthorizationRequest  = "0100"
+	MTIAuthorizationResponse = "0110"
+	MTIFinancialRequest      = "0200"
+	MTIFinancialResponse     = "0210"
+	MTIReversalRequest       = "0400"
+	MTIReversalAdvice        = "0420"
+	MTIReversalResponse      = "0410"
+	MTIReversalAdviceResp    = "0430"
+	MTINetworkRequest        = "0800"
+	MTINetworkResponse       = "0810"
+)
+
+var ErrFormat = errors.New("iso8583: malformed message")
+
+// Message is one ISO 8583 message. Field values are kept as their
+// unpadded string form; padding is applied by Pack.
+type Message struct {
+	MTI    string
+	fields map[int]string
+}
+
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, fields: make(map[int]string)}
+}
+
+func (m *Message) Set(field int, value string) {
+	m.fields[field] = value
+}
+
+func (m *Message) Get(field int) string {
+	return m.fields[field]
+}
+
+func (m *Message) Has(field int) bool {
+	_, ok := m.fields[field]
+	return ok
+}
+
+// Fields returns the numbers of the fields present, in ascending order.
+func (m *Message) Fields() []int {
+	nums := make([]int, 0, len(m.fields))
+	for n := range m.fields {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// echoed are copied from a request into its response.
+var echoed = []int{
+	FieldPAN, FieldProcessingCode, FieldAmount, FieldTransmissionDateTime, FieldSTAN,
+	FieldLocalTime, FieldLocalDate, FieldAcquirerID, FieldRRN, FieldTerminalID,
+	FieldMerchantID, FieldCurrency, FieldNetworkManagementCode, FieldOriginalData, FieldAccount,
+}
+
+// Response starts the response to m: the MTI's function digit is advanced
+// (0200 -> 0210) and the identifying fields are copied over.
+func (m *Message) Response() *Message {
+	mti := []byte(m.MTI)
+	if len(mti) == 4 && mti[2] >= '0' && mti[2] <= '8' {
+		mti[2]++
+	}
+	resp := NewMessage(string(mti))
+	for _, n := range echoed {
+		if v, ok := m.fields[n]; ok {
+			resp.fields[n] = v
+		}
+	}
+	return resp
+}
+
+// Pack encodes the message.
+func (m *Message) Pack() ([]byte, error) {
+	if len(m.MTI) != 4 || !isDigits(m.MTI) {
+		return nil, fmt.Errorf("iso8583: invalid MTI %q", m.MTI)
+	}
+
+	var bitmap [16]byte
+	var body strings.Builder
+	for _, n := range m.Fields() {
+		spec, ok := specs[n]
+		if !ok || n < 2 || n > 128 {
+			return nil, fmt.Errorf("iso8583: unsupported field %d", n)
+		}
+		encoded, err := encodeField(n, spec, m.fields[n])
+		if err != nil {
+			return nil, err
+		}
+		body.WriteString(encoded)
+		bitmap[(n-1)/8] |= 0x80 >> uint((n-1)%8)
+	}
+
+	secondary := false
+	for _, b := range bitmap[8:] {
+		if b != 0 {
+			secondary = true
+			break
+		}
+	}
+
+	out := []byte(m.MTI)
+	if secondary {
+		bitmap[0] |= 0x80
+		out = append(out, strings.ToUpper(hex.EncodeToString(bitmap[:]))...)
+	} else {
+		out = append(out, strings.ToUpper(hex.EncodeToString(bitmap[:8]))...)
+	}
+	return append(out, body.String()...), nil
+}
+
+func encodeField(n int, spec fieldSpec, value string) (string, error) {
+	if spec.numeric && value != "" && !isDigits(value) {
+		return "", fmt.Errorf("iso8583: field %d (%s) must be numeric", n, spec.name)
+	}
+	if len(value) > spec.length {
+		return "", fmt.Errorf("iso8583: field %d (%s) longer than %d", n, spec.name, spec.length)
+	}
+
+	switch spec.kind {
+	case llvar:
+		return fmt.Sprintf("%02d%s", len(value), value), nil
+	case lllvar:
+		return fmt.Sprintf("%03d%s", len(value), value), nil
+	}
+	if spec.numeric {
+		return strings.Repeat("0", spec.length-len(value)) + value, nil
+	}
+	return value + strings.Repeat(" ", spec.length-len(value)), nil
+}
+
+// Unpack decodes one message.
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 4+16 {
+		return nil, ErrFormat
+	}
+	m := NewMessage(string(data[:4]))
+	if !isDigits(m.MTI) {
+		return nil, fmt.Errorf("%w: invalid MTI", ErrFormat)
+	}
+
+	bitmap, err := hex.DecodeString(string(data[4:20]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid bitmap", ErrFormat)
+	}
+	pos := 20
+	if bitmap[0]&0x80 != 0 {
+		if len(data) < pos+16 {
+			return nil, fmt.Errorf("%w: truncated secondary bitmap", ErrFormat)
+		}
+		secondary, err := hex.DecodeString(string(data[pos : pos+16]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid secondary bitmap", ErrFormat)
+		}
+		bitmap = append(bitmap, secondary...)
+		pos += 16
+	}
+
+	for i := 1; i < len(bitmap)*8; i++ {
+		if bitmap[i/8]&(0x80>>uint(i%8)) == 0 {
+			continue
+		}
+		n := i + 1
+		spec, ok := specs[n]
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported field %d", ErrFormat, n)
+		}
+
+		length := spec.length
+		switch spec.kind {
+		case llvar, lllvar:
+			digits := 2
+			if spec.kind == lllvar {
+				digits = 3
+			}
+			if len(data) < pos+digits {
+				return nil, fmt.Errorf("%w: truncated field %d", ErrFormat, n)
+			}
+			length, err = strconv.Atoi(string(data[pos : pos+digits]))
+			if err != nil || length > spec.length {
+				return nil, fmt.Errorf("%w: bad length for field %d", ErrFormat, n)
+			}
+			pos += digits
+		}
+		if len(data) < pos+length {
+			return nil, fmt.Errorf("%w: truncated field %d", ErrFormat, n)
+		}
+
+		value := string(data[pos : pos+length])
+		pos += length
+		if spec.kind == fixed && !spec.numeric {
+			value = strings.TrimRight(value, " ")
+		}
+		if spec.numeric && value != "" && !isDigits(value) {
+			return nil, fmt.Errorf("%w: field %d (%s) must be numeric", ErrFormat, n, spec.name)
+		}
+		m.fields[n] = value
+	}
+
+	if pos != len(data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrFormat, len(data)-pos)
+	}
+	return m, nil
+}
+
+// ReadFrame reads one length-prefixed message from r.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var prefix [2]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteFrame writes data to w with its length prefix.
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > 0xFFFF {
+		return fmt.Errorf("iso8583: message of %d bytes too large to frame", len(data))
+	}
+	frame := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[2:], data)
+	_, err := w.Write(frame)
+	return err
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package iso8583
+
+// lengthType says how a field's length is carried on the wire.
+type lengthType int
+
+const (
+	fixed  lengthType = iota
+	llvar             // two ASCII digits of length, then the data
+	lllvar            // three ASCII digits of length, then the data
+)
+
+// fieldSpec describes one data element. Length is the fixed length, or the
+// maximum for variable fields. Numeric fixed fields are zero-padded on the
+// left; others are space-padded on the right.
+type fieldSpec struct {
+	name    string
+	length  int
+	kind    lengthType
+	numeric bool
+}
+
+// Data elements we exchange with the ATM/POS switch (ISO 8583:1987).
+// Anything else in an incoming message is rejected as a format error.
+const (
+	FieldPAN                   = 2
+	FieldProcessingCode        = 3
+	FieldAmount                = 4
+	FieldTransmissionDateTime  = 7
+	FieldSTAN                  = 11
+	FieldLocalTime             = 12
+	FieldLocalDate             = 13
+	FieldExpiry                = 14
+	FieldMerchantType          = 18
+	FieldPOSEntryMode          = 22
+	FieldAcquirerID            = 32
+	FieldRRN                   = 37
+	FieldAuthCode              = 38
+	FieldResponseCode          = 39
+	FieldTerminalID            = 41
+	FieldMerchantID            = 42
+	FieldMerchantName          = 43
+	FieldCurrency              = 49
+	FieldAdditionalAmounts     = 54
+	FieldNetworkManagementCode = 70
+	FieldOriginalData          = 90
+	FieldAccount               = 102
+)
+
+var specs = map[int]fieldSpec{
+	FieldPAN:                   {"primary account number", 19, llvar, true},
+	FieldProcessingCode:        {"processing code", 6, fixed, true},
+	FieldAmount:                {"amount, transaction", 12, fixed, true},
+	FieldTransmissionDateTime:  {"transmission date and time", 10, fixed, true},
+	FieldSTAN:                  {"systems trace audit number", 6, fixed, true},
+	FieldLocalTime:             {"time, local transaction", 6, fixed, true},
+	FieldLocalDate:             {"date, local transaction", 4, fixed, true},
+	FieldExpiry:                {"date, expiration", 4, fixed, true},
+	FieldMerchantType:          {"merchant type", 4, fixed, true},
+	FieldPOSEntryMode:          {"point of service entry mode", 3, fixed, true},
+	FieldAcquirerID:            {"acquiring institution id", 11, llvar, true},
+	FieldRRN:                   {"retrieval reference number", 12, fixed, false},
+	FieldAuthCode:              {"authorization id response", 6, fixed, false},
+	FieldResponseCode:          {"response code", 2, fixed, false},
+	FieldTerminalID:            {"card acceptor terminal id", 8, fixed, false},
+	FieldMerchantID:            {"card acceptor id", 15, fixed, false},
+	FieldMerchantName:          {"card acceptor name/location", 40, fixed, false},
+	FieldCurrency:              {"currency code, transaction", 3, fixed, true},
+	FieldAdditionalAmounts:     {"additional amounts", 120, lllvar, false},
+	FieldNetworkManagementCode: {"network management information code", 3, fixed, true},
+	FieldOriginalData:          {"original data elements", 42, fixed, true},
+	FieldAccount:               {"account identification 1", 28, llvar, false},
+}
@@ -0,0 +1,152 @@
+// Package journal makes the transaction table tamper-evident. Every posted
+// transaction carries a sequence number, the hash of the transaction before
+// it and a hash over its own content and that previous hash, so editing or
+// deleting a row breaks every link after it. Checkpoints sign the chain
+// head with an Ed25519 key, so the whole chain cannot simply be recomputed
+// after an edit without the key.
+package journal
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// Genesis is the previous hash of the first transaction in the chain.
+var Genesis = strings.Repeat("0", 64)
+
+// Hash computes a transaction's link from its content, sequence and the
+// previous hash. CreatedAt counts to the second, which is what the
+// database keeps; amounts count to the cent.
+func Hash(prevHash string, t *models.Transaction) string {
+	var seq int64
+	if t.Sequence != nil {
+		seq = *t.Sequence
+	}
+	content := strings.Join([]string{
+		prevHash,
+		strconv.FormatInt(seq, 10),
+		strconv.Itoa(t.ID),
+		optional(t.FromAccountID),
+		optional(t.ToAccountID),
+		optional(t.LoanPaymentID),
+		optional(t.BeneficiaryID),
+		strconv.FormatFloat(t.Amount, 'f', 2, 64),
+		strconv.FormatInt(t.CreatedAt.Unix(), 10),
+	}, "|")
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func optional(id *int) string {
+	if id == nil {
+		return "-"
+	}
+	return strconv.Itoa(*id)
+}
+
+// Signer signs checkpoints.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner builds a signer from a base64-encoded 32-byte Ed25519 seed.
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("journal signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, errors.New("journal signing key must be a 32-byte Ed25519 seed")
+	}
+	key := ed25519.NewKeyFromSeed(raw)
+	pub := key.Public().(ed25519.PublicKey)
+	return &Signer{key: key, keyID: KeyID(pub)}, nil
+}
+
+// PublicKey returns the base64 public key to hand to verifiers.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign fills in the checkpoint's key ID and signature.
+func (s *Signer) Sign(cp *models.JournalCheckpoint) {
+	cp.KeyID = s.keyID
+	cp.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, checkpointMessage(cp)))
+}
+
+// KeyID names a public key in checkpoints: the first 8 bytes of its
+// SHA-256, in hex.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Keys are the public keys checkpoints may have been signed with, by key
+// ID. Keep retired keys here after rotating the signing key.
+type Keys map[string]ed25519.PublicKey
+
+// ParseKeys reads comma-separated base64 public keys.
+func ParseKeys(list string) (Keys, error) {
+	keys := Keys{}
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(s)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid journal public key %q", s)
+		}
+		keys.Add(raw)
+	}
+	return keys, nil
+}
+
+func (k Keys) Add(pub ed25519.PublicKey) {
+	k[KeyID(pub)] = pub
+}
+
+// Verify checks a checkpoint's signature.
+func (k Keys) Verify(cp *models.JournalCheckpoint) error {
+	pub, ok := k[cp.KeyID]
+	if !ok {
+		return fmt.Errorf("unknown signing key %s", cp.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil || !ed25519.Verify(pub, checkpointMessage(cp), sig) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func checkpointMessage(cp *models.JournalCheckpoint) []byte {
+	return []byte(fmt.Sprintf("journal-checkpoint|%d|%s", cp.Sequence, cp.Hash))
+}
+
+// LoadKeys builds the signer from a base64 seed, if one is given, and the
+// verification keys from a comma-separated list of base64 public keys plus
+// the signer's own.
+func LoadKeys(signingSeed, verifyKeys string) (*Signer, Keys, error) {
+	keys, err := ParseKeys(verifyKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signingSeed == "" {
+		return nil, keys, nil
+	}
+	signer, err := NewSigner(signingSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys.Add(signer.key.Public().(ed25519.PublicKey))
+	return signer, keys, nil
+}
@@ -0,0 +1,87 @@
+// Package kyc stores uploaded identity documents. Files are kept outside
+// the database; the database holds their metadata and the key to fetch
+// them again.
+package kyc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stored describes a file written to a Store.
+type Stored struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// Store keeps document files.
+type Store interface {
+	// Put writes r, reading at most maxBytes. It returns ErrTooLarge for
+	// a longer file and ErrUnsupportedType for anything but JPEG, PNG or
+	// PDF, judged by content rather than file name.
+	Put(r io.Reader, maxBytes int64) (*Stored, error)
+	Open(key string) (io.ReadCloser, error)
+}
+
+var (
+	ErrTooLarge        = errors.New("document is too large")
+	ErrUnsupportedType = errors.New("unsupported document type")
+)
+
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// DirStore keeps documents as files in a local directory, named by a
+// random key so upload names never reach the file system.
+type DirStore struct {
+	Dir string
+}
+
+func (s *DirStore) Put(r io.Reader, maxBytes int64) (*Stored, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	contentType := strings.SplitN(http.DetectContentType(data), ";", 2)[0]
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	var name [16]byte
+	if _, err := rand.Read(name[:]); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(name[:]) + ext
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, key), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &Stored{Key: key, ContentType: contentType, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+func (s *DirStore) Open(key string) (io.ReadCloser, error) {
+	if key != filepath.Base(key) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(filepath.Join(s.Dir, key))
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// OAuth2 scopes an API client may hold. Each admits the client to the
+// customer routes of that name, acting for the customer who owns it.
+const (
+	ScopeAccountsRead      = "accounts:read"
+	ScopeTransfersWrite    = "transfers:write"
+	ScopePaymentsRead      = "payments:read"
+	ScopePaymentsWrite     = "payments:write"
+	ScopeBeneficiariesRead = "beneficiaries:read"
+	ScopeWebhooksManage    = "webhooks:manage"
+)
+
+// AllScopes lists every scope a client may be granted.
+var AllScopes = []string{
+	ScopeAccountsRead, ScopeTransfersWrite, ScopePaymentsRead,
+	ScopePaymentsWrite, ScopeBeneficiariesRead, ScopeWebhooksManage,
+}
+
+// APIClient is a partner system that signs in with the OAuth2 client
+// credentials grant instead of a customer's password. Its tokens act for
+// the owning customer, limited to its scopes. A client with no customer
+// is a third-party provider, which holds no scopes and reaches customers'
+// accounts only through the consents they authorize.
+type APIClient struct {
+	ID         int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	ClientID   string `gorm:"size:64;uniqueIndex" json:"client_id"`
+	Name       string `gorm:"size:100" json:"name"`
+	CustomerID int    `gorm:"index;type:int" json:"customer_id,omitempty"`
+	SecretHash string `json:"-"`
+	// PreviousSecretHash still works until PreviousSecretExpiresAt, so a
+	// rotated secret can be rolled out without downtime.
+	PreviousSecretHash      string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	// Scopes is filled in by the repository from api_client_scopes.
+	Scopes []string `gorm:"-" json:"scopes"`
+	// RateLimitPerMinute and RateLimitBurst override the default client
+	// rate limit when set.
+	RateLimitPerMinute float64 `json:"rate_limit_per_minute,omitempty"`
+	RateLimitBurst     int     `json:"rate_limit_burst,omitempty"`
+	Active             bool    `json:"active"`
+	// TokensRevokedAt invalidates tokens issued before it, e.g. after a
+	// change of scopes, since tokens carry the scopes.
+	TokensRevokedAt *time.Time `json:"-"`
+	SecretRotatedAt *time.Time `json:"secret_rotated_at,omitempty"`
+	LastTokenAt     *time.Time `json:"last_token_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type APIClientScope struct {
+	ClientID int    `gorm:"primaryKey;type:int"`
+	Scope    string `gorm:"primaryKey;size:50"`
+}
+
+// CreateAPIClientRequest registers a client for a customer, or a
+// third-party provider when CustomerID is 0.
+type CreateAPIClientRequest struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	CustomerID         int      `json:"customer_id"`
+	Scopes             []string `json:"scopes"`
+	RateLimitPerMinute float64  `json:"rate_limit_per_minute" binding:"gte=0"`
+	RateLimitBurst     int      `json:"rate_limit_burst" binding:"gte=0"`
+}
+
+type UpdateAPIClientRequest struct {
+	Name               *string   `json:"name" binding:"omitempty,max=100"`
+	Scopes             *[]string `json:"scopes" binding:"omitempty,min=1"`
+	RateLimitPerMinute *float64  `json:"rate_limit_per_minute" binding:"omitempty,gte=0"`
+	RateLimitBurst     *int      `json:"rate_limit_burst" binding:"omitempty,gte=0"`
+	Active             *bool     `json:"active"`
+}
+
+// APIClientSecret is returned when a client is created or its secret is
+// rotated. The secret is not shown again.
+type APIClientSecret struct {
+	Client       *APIClient `json:"client"`
+	ClientSecret string     `json:"client_secret"`
+}
+
+// ClientToken is the OAuth2 token response (RFC 6749 section 5.1).
+type ClientToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// Provider reports whether the client is a third-party provider.
+func (c *APIClient) Provider() bool {
+	return c.CustomerID == 0
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Audit actor types.
+const (
+	ActorCustomer  = "customer"
+	ActorStaff     = "staff"
+	ActorNetwork   = "network"
+	ActorAPIClient = "api_client"
+	ActorSystem    = "system"
+	ActorAnonymous = "anonymous"
+)
+
+// AuditLog is one append-only record of who changed what, from where.
+// Entries written by the HTTP middleware carry Method, Path and Status;
+// entries written by services carry the entity and its Before and After
+// values as JSON. Both share the RequestID of the request that caused them.
+type AuditLog struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorType  string    `gorm:"size:20;index:idx_audit_actor" json:"actor_type"`
+	ActorID    int       `gorm:"type:int;index:idx_audit_actor" json:"actor_id"`
+	Action     string    `gorm:"size:100;index" json:"action"`
+	EntityType string    `gorm:"size:50;index:idx_audit_entity" json:"entity_type,omitempty"`
+	EntityID   string    `gorm:"size:64;index:idx_audit_entity" json:"entity_id,omitempty"`
+	Before     string    `gorm:"type:text" json:"before,omitempty"`
+	After      string    `gorm:"type:text" json:"after,omitempty"`
+	Method     string    `gorm:"size:10" json:"method,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Status     int       `json:"status,omitempty"`
+	IP         string    `gorm:"size:45" json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `gorm:"size:64;index" json:"request_id,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// AuditQuery filters the audit log. Zero values match everything.
+type AuditQuery struct {
+	ActorType  string    `form:"actor_type"`
+	ActorID    int       `form:"actor_id"`
+	Action     string    `form:"action"`
+	EntityType string    `form:"entity_type"`
+	EntityID   string    `form:"entity_id"`
+	RequestID  string    `form:"request_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// BeforeID pages backwards: pass the smallest ID of the previous page.
+	BeforeID int64 `form:"before_id"`
+	Limit    int   `form:"limit" binding:"omitempty,min=1,max=500"`
+}
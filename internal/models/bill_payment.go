@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Bill payment states. A payment is debited when it leaves scheduled, then
+// waits in processing until the biller connector confirms or rejects it.
+// The money is in transit meanwhile: a confirmation credits the biller's
+// settlement account, a rejection reverses the debit.
+const (
+	BillPaymentScheduled  = "scheduled"
+	BillPaymentProcessing = "processing"
+	BillPaymentConfirmed  = "confirmed"
+	BillPaymentRejected   = "rejected"
+	BillPaymentFailed     = "failed"
+	BillPaymentCancelled  = "cancelled"
+)
+
+type Biller struct {
+	ID       int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Code     string `gorm:"size:20;uniqueIndex" json:"code"`
+	Name     string `json:"name"`
+	Category string `gorm:"size:30;index" json:"category"`
+	// ConsumerNumberPattern is a regular expression consumer numbers must match.
+	ConsumerNumberPattern string    `json:"consumer_number_pattern"`
+	SettlementAccountID   int       `json:"settlement_account_id" gorm:"type:int"`
+	Active                bool      `gorm:"default:true" json:"active"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type BillerRegistration struct {
+	ID             int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID     int       `json:"customer_id" gorm:"type:int;uniqueIndex:idx_biller_registration,priority:1"`
+	BillerID       int       `json:"biller_id" gorm:"type:int;uniqueIndex:idx_biller_registration,priority:2"`
+	Biller         *Biller   `gorm:"foreignKey:BillerID" json:"biller,omitempty"`
+	ConsumerNumber string    `gorm:"size:40;uniqueIndex:idx_biller_registration,priority:3" json:"consumer_number"`
+	Nickname       string    `gorm:"size:50" json:"nickname"`
+	CreatedAt      time.Time `json:"created_at"`
+	// soft delete keeps past bill payments linked to their registration
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type BillPayment struct {
+	ID                 int                 `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID         int                 `json:"customer_id" gorm:"type:int;index"`
+	RegistrationID     int                 `json:"registration_id" gorm:"type:int;index"`
+	Registration       *BillerRegistration `gorm:"foreignKey:RegistrationID" json:"registration,omitempty"`
+	AccountID          int                 `json:"account_id" gorm:"type:int;index"`
+	Amount             float64             `gorm:"type:decimal(15,2)" json:"amount"`
+	Status             string              `gorm:"size:20;index" json:"status"`
+	ScheduledFor       *time.Time          `json:"scheduled_for,omitempty" gorm:"index"`
+	ConnectorReference string              `gorm:"size:64;index" json:"connector_reference,omitempty"`
+	FailureReason      string              `json:"failure_reason,omitempty"`
+	// DebitTransactionID is the withdrawal a rejection reverses.
+	DebitTransactionID *int       `json:"debit_transaction_id,omitempty" gorm:"type:int"`
+	DebitedAt          *time.Time `json:"debited_at,omitempty"`
+	// ClaimedAt is when an executor last took the payment; one that is
+	// neither submitted nor finished long after is resumed.
+	ClaimedAt   *time.Time `json:"-"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CreateBillerRequest struct {
+	Code                  string `json:"code" binding:"required,max=20"`
+	Name                  string `json:"name" binding:"required"`
+	Category              string `json:"category" binding:"required,max=30"`
+	ConsumerNumberPattern string `json:"consumer_number_pattern"`
+	SettlementAccountID   int    `json:"settlement_account_id" binding:"required"`
+}
+
+type UpdateBillerRequest struct {
+	Name                  *string `json:"name"`
+	Category              *string `json:"category" binding:"omitempty,max=30"`
+	ConsumerNumberPattern *string `json:"consumer_number_pattern"`
+	SettlementAccountID   *int    `json:"settlement_account_id"`
+	Active                *bool   `json:"active"`
+}
+
+type RegisterBillerRequest struct {
+	BillerID       int    `json:"biller_id" binding:"required"`
+	ConsumerNumber string `json:"consumer_number" binding:"required,max=40"`
+	Nickname       string `json:"nickname" binding:"max=50"`
+}
+
+type CreateBillPaymentRequest struct {
+	RegistrationID int        `json:"registration_id" binding:"required"`
+	AccountID      int        `json:"account_id" binding:"required"`
+	Amount         float64    `json:"amount" binding:"required,gt=0"`
+	ScheduledFor   *time.Time `json:"scheduled_for"`
+}
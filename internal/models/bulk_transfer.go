@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Bulk transfer batch states. The total is reserved on the debit account
+// when the batch is accepted; each item then releases its share of the
+// hold as it completes or fails.
+const (
+	BulkBatchProcessing         = "processing"
+	BulkBatchCompleted          = "completed"
+	BulkBatchPartiallyCompleted = "partially_completed"
+	BulkBatchFailed             = "failed"
+
+	BulkItemPending   = "pending"
+	BulkItemCompleted = "completed"
+	BulkItemFailed    = "failed"
+)
+
+type BulkTransferBatch struct {
+	ID             int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID     int        `json:"customer_id" gorm:"type:int;index"`
+	FromAccountID  int        `json:"from_account_id" gorm:"type:int;index"`
+	FileName       string     `json:"file_name"`
+	Status         string     `gorm:"size:20;index" json:"status"`
+	ItemCount      int        `json:"item_count"`
+	TotalAmount    float64    `gorm:"type:decimal(15,2)" json:"total_amount"`
+	SucceededCount int        `json:"succeeded_count"`
+	FailedCount    int        `json:"failed_count"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	// ClaimedAt is refreshed by whoever is processing the batch after
+	// every item; a batch it stops moving on is resumed by someone else.
+	ClaimedAt *time.Time         `json:"-"`
+	CreatedAt time.Time          `json:"created_at"`
+	Items     []BulkTransferItem `gorm:"foreignKey:BatchID" json:"items,omitempty"`
+}
+
+type BulkTransferItem struct {
+	ID            int     `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	BatchID       int     `json:"batch_id" gorm:"type:int;index"`
+	LineNumber    int     `json:"line_number"`
+	ToAccountID   int     `json:"to_account_id" gorm:"type:int"`
+	Amount        float64 `gorm:"type:decimal(15,2)" json:"amount"`
+	Reference     string  `gorm:"size:140" json:"reference"`
+	Status        string  `gorm:"size:20" json:"status"`
+	Error         string  `json:"error,omitempty"`
+	TransactionID *int    `json:"transaction_id,omitempty" gorm:"type:int"`
+}
+
+type BulkTransferRow struct {
+	ToAccountID int     `json:"to_account_id"`
+	Amount      float64 `json:"amount"`
+	Reference   string  `json:"reference"`
+}
+
+type CreateBulkTransferRequest struct {
+	Items []BulkTransferRow `json:"items" binding:"required,min=1"`
+}
+
+// BulkRowError describes why one row of a bulk upload was rejected.
+type BulkRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+const (
+	CardStatusActive  = "active"
+	CardStatusBlocked = "blocked"
+	CardStatusExpired = "expired"
+)
+
+// Card authorization states. An approved authorization holds funds on the
+// linked account until it is captured (posted) or reversed (released).
+const (
+	CardAuthApproved = "approved"
+	CardAuthDeclined = "declined"
+	CardAuthCaptured = "captured"
+	CardAuthReversed = "reversed"
+)
+
+// Card is a virtual debit card. The full PAN and CVV are shown once at
+// issuance; only keyed hashes are stored.
+type Card struct {
+	ID             int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	AccountID      int    `json:"account_id" gorm:"type:int;index"`
+	CustomerID     int    `json:"customer_id" gorm:"type:int;index"`
+	PANHash        string `gorm:"size:64;uniqueIndex" json:"-"`
+	MaskedPAN      string `gorm:"size:19" json:"masked_pan"`
+	Last4          string `gorm:"size:4" json:"last4"`
+	CVVHash        string `gorm:"size:64" json:"-"`
+	ExpiryMonth    int    `json:"expiry_month"`
+	ExpiryYear     int    `json:"expiry_year"`
+	CardholderName string `json:"cardholder_name"`
+	Status         string `gorm:"size:10;index" json:"status"`
+	// limits of zero mean "no limit"
+	PerTransactionLimit float64 `gorm:"type:decimal(15,2)" json:"per_transaction_limit"`
+	DailyLimit          float64 `gorm:"type:decimal(15,2)" json:"daily_limit"`
+	// comma-separated merchant category codes, e.g. "5411,5812"
+	AllowedMCCs string    `json:"allowed_mccs"`
+	BlockedMCCs string    `json:"blocked_mccs"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CardAuthorization struct {
+	ID                   int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CardID               int       `json:"card_id" gorm:"type:int;index"`
+	AccountID            int       `json:"account_id" gorm:"type:int;index"`
+	NetworkReference     string    `gorm:"size:64;uniqueIndex" json:"network_reference"`
+	Amount               float64   `gorm:"type:decimal(15,2)" json:"amount"`
+	CapturedAmount       float64   `gorm:"type:decimal(15,2)" json:"captured_amount"`
+	Currency             string    `gorm:"size:3" json:"currency"`
+	MerchantName         string    `json:"merchant_name"`
+	MerchantCategoryCode string    `gorm:"size:4" json:"mcc"`
+	Status               string    `gorm:"size:10;index" json:"status"`
+	AuthCode             string    `gorm:"size:6" json:"auth_code,omitempty"`
+	DeclineReason        string    `json:"decline_reason,omitempty"`
+	TransactionID        *int      `json:"transaction_id,omitempty" gorm:"type:int"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type IssueCardRequest struct {
+	CardholderName      string  `json:"cardholder_name" binding:"required"`
+	PerTransactionLimit float64 `json:"per_transaction_limit" binding:"gte=0"`
+	DailyLimit          float64 `json:"daily_limit" binding:"gte=0"`
+}
+
+type UpdateCardRequest struct {
+	PerTransactionLimit *float64 `json:"per_transaction_limit" binding:"omitempty,gte=0"`
+	DailyLimit          *float64 `json:"daily_limit" binding:"omitempty,gte=0"`
+	AllowedMCCs         *string  `json:"allowed_mccs"`
+	BlockedMCCs         *string  `json:"blocked_mccs"`
+}
+
+// IssuedCard is returned once when a card is created; it is the only time
+// the full PAN and CVV leave the server.
+type IssuedCard struct {
+	*Card
+	PAN string `json:"pan"`
+	CVV string `json:"cvv"`
+}
+
+// CardAuthorizationRequest is what the card network sends for a purchase
+// or withdrawal. Expiry is "MM/YY". NetworkReference makes retries safe.
+type CardAuthorizationRequest struct {
+	PAN                  string  `json:"pan" binding:"required"`
+	Expiry               string  `json:"expiry" binding:"required"`
+	CVV                  string  `json:"cvv"`
+	Amount               float64 `json:"amount" binding:"required,gt=0"`
+	Currency             string  `json:"currency" binding:"required,len=3"`
+	MerchantName         string  `json:"merchant_name"`
+	MerchantCategoryCode string  `json:"mcc" binding:"required,len=4"`
+	NetworkReference     string  `json:"network_reference" binding:"required,max=64"`
+}
+
+type CardCaptureRequest struct {
+	// Amount defaults to the full authorized amount.
+	Amount float64 `json:"amount" binding:"gte=0"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ClearingDirectionOutbound = "outbound"
+	ClearingDirectionInbound  = "inbound"
+)
+
+// ClearingFile records every ISO 20022 file exchanged with the clearing
+// bank. MessageID is unique, which is what makes re-importing the same
+// file a no-op.
+type ClearingFile struct {
+	ID         int     `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	MessageID  string  `gorm:"size:35;uniqueIndex" json:"message_id"`
+	Type       string  `gorm:"size:10" json:"type"`
+	Direction  string  `gorm:"size:8" json:"direction"`
+	EntryCount int     `json:"entry_count"`
+	ControlSum float64 `gorm:"type:decimal(15,2)" json:"control_sum"`
+	// Content is the XML of files we generate, kept so a file whose write
+	// failed after its payments were committed can be written again.
+	Content   string    `gorm:"type:longtext" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FormatAccountNumber renders an internal account ID as the 12-digit
+// account number used in clearing files.
+func FormatAccountNumber(accountID int) string {
+	return fmt.Sprintf("%012d", accountID)
+}
+
+// ParseAccountNumber is the inverse of FormatAccountNumber. It accepts the
+// number with or without leading zeros.
+func ParseAccountNumber(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > 12 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// Open-banking consent permissions.
+const (
+	ConsentAccounts     = "accounts"
+	ConsentBalances     = "balances"
+	ConsentTransactions = "transactions"
+	ConsentPayments     = "payments"
+)
+
+// AllConsentPermissions lists every permission a consent may ask for.
+var AllConsentPermissions = []string{ConsentAccounts, ConsentBalances, ConsentTransactions, ConsentPayments}
+
+// Consent statuses.
+const (
+	ConsentAwaitingAuthorization = "awaiting_authorization"
+	ConsentAuthorized            = "authorized"
+	ConsentRejected              = "rejected"
+	ConsentRevoked               = "revoked"
+	ConsentExpired               = "expired"
+)
+
+// Consent is a customer's permission for a third-party provider (an API
+// client with no customer of its own) to read their accounts or pay from
+// them. The provider requests it; the customer authorizes it and picks
+// the accounts it covers.
+type Consent struct {
+	ID       string `gorm:"primaryKey;size:32" json:"id"`
+	ClientID int    `gorm:"index;type:int" json:"client_id"`
+	// CustomerID is set when the customer authorizes or rejects.
+	CustomerID int    `gorm:"index;type:int" json:"customer_id,omitempty"`
+	Status     string `gorm:"size:30;index" json:"status"`
+	// Permissions and AccountIDs are filled in by the repository.
+	Permissions []string `gorm:"-" json:"permissions"`
+	AccountIDs  []int    `gorm:"-" json:"account_ids"`
+	// MaxPaymentAmount caps each payment made under the consent.
+	MaxPaymentAmount float64    `gorm:"type:decimal(15,2)" json:"max_payment_amount,omitempty"`
+	ExpiresAt        time.Time  `gorm:"index" json:"expires_at"`
+	AuthorizedAt     *time.Time `json:"authorized_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Allows reports whether the consent grants permission.
+func (c *Consent) Allows(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the customer selected the account.
+func (c *Consent) Covers(accountID int) bool {
+	for _, id := range c.AccountIDs {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+type ConsentPermission struct {
+	ConsentID  string `gorm:"primaryKey;size:32"`
+	Permission string `gorm:"primaryKey;size:30"`
+}
+
+type ConsentAccount struct {
+	ConsentID string `gorm:"primaryKey;size:32"`
+	AccountID int    `gorm:"primaryKey;type:int"`
+}
+
+type CreateConsentRequest struct {
+	Permissions      []string  `json:"permissions" binding:"required,min=1"`
+	ExpiresAt        time.Time `json:"expires_at" binding:"required"`
+	MaxPaymentAmount float64   `json:"max_payment_amount" binding:"gte=0"`
+}
+
+type AuthorizeConsentRequest struct {
+	AccountIDs []int `json:"account_ids" binding:"required,min=1"`
+}
+
+// OBAccount is an account as shown to a third-party provider.
+type OBAccount struct {
+	ID       int    `json:"id"`
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+type OBBalance struct {
+	AccountID int       `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Booked    float64   `json:"booked"`
+	Available float64   `json:"available"`
+	AsOf      time.Time `json:"as_of"`
+}
+
+type OBPaymentRequest struct {
+	FromAccountID int     `json:"from_account_id" binding:"required"`
+	ToAccountID   int     `json:"to_account_id" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+}
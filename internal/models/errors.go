@@ -1,5 +1,216 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 var ErrInsufficientFunds = errors.New("insufficient funds")
+
+var ErrLoanNotFound = errors.New("loan not found")
+
+var (
+	ErrBeneficiaryNotFound   = errors.New("beneficiary not found")
+	ErrDuplicateBeneficiary  = errors.New("beneficiary already exists")
+	ErrInvalidAccountNumber  = errors.New("invalid account number")
+	ErrInvalidBankCode       = errors.New("invalid bank code")
+	ErrBeneficiaryCoolingOff = errors.New("beneficiary is in cooling-off period")
+	ErrBeneficiaryInUse      = errors.New("beneficiary has payments in progress")
+)
+
+var (
+	ErrAccountNotFound              = errors.New("account not found")
+	ErrSameAccount                  = errors.New("cannot transfer to the same account")
+	ErrPaymentNotFound              = errors.New("payment not found")
+	ErrInvalidPaymentTransition     = errors.New("invalid payment status transition")
+	ErrClearingAccountNotConfigured = errors.New("outbound clearing account is not configured")
+	ErrFileAlreadyImported          = errors.New("file has already been imported")
+	ErrInboundCreditNotFound        = errors.New("inbound credit not found")
+	ErrInboundCreditNotInSuspense   = errors.New("inbound credit is not in suspense")
+)
+
+var (
+	ErrBillerNotFound              = errors.New("biller not found")
+	ErrBillerInactive              = errors.New("biller is not accepting payments")
+	ErrDuplicateBiller             = errors.New("biller code already exists")
+	ErrInvalidConsumerNumber       = errors.New("consumer number is not valid for this biller")
+	ErrDuplicateBillerRegistration = errors.New("biller is already registered with this consumer number")
+	ErrBillerRegistrationNotFound  = errors.New("biller registration not found")
+	ErrBillPaymentNotFound         = errors.New("bill payment not found")
+	ErrBillPaymentNotCancellable   = errors.New("only scheduled bill payments can be cancelled")
+)
+
+var (
+	ErrCardNotFound              = errors.New("card not found")
+	ErrCardAuthorizationNotFound = errors.New("card authorization not found")
+	ErrCardAuthorizationState    = errors.New("card authorization is not in a state that allows this operation")
+	ErrCaptureExceedsAuthorized  = errors.New("capture amount exceeds authorized amount")
+	ErrCardNotBlockable          = errors.New("only active cards can be blocked")
+	ErrCardNotUnblockable        = errors.New("only blocked cards can be unblocked")
+	ErrNotificationNotFound      = errors.New("notification not found")
+	ErrWebhookNotFound           = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL         = errors.New("webhook url must be an absolute https url")
+	ErrWebhookURLNotPublic       = errors.New("webhook url must resolve to public addresses only")
+	ErrUnknownEventType          = errors.New("unknown event type")
+	ErrCardsNotConfigured        = errors.New("card issuing is not configured")
+	ErrInvalidMCCList            = errors.New("merchant category codes must be four digits")
+	ErrJournalSigningKeyMissing  = errors.New("journal signing key is not configured")
+)
+
+var (
+	ErrBulkBatchNotFound = errors.New("bulk transfer batch not found")
+	ErrBulkBatchTooLarge = errors.New("bulk transfer batch has too many rows")
+)
+
+// BulkValidationError is returned when one or more rows of a bulk upload
+// are invalid. No part of the batch is executed in that case.
+type BulkValidationError struct {
+	Rows []BulkRowError
+}
+
+func (e *BulkValidationError) Error() string {
+	return "bulk transfer validation failed"
+}
+
+var (
+	ErrStepUpRequired         = errors.New("additional verification required")
+	ErrSuspectedFraud         = errors.New("declined as suspected fraud")
+	ErrHeldTransferNotFound   = errors.New("held transfer not found")
+	ErrHeldTransferNotPending = errors.New("held transfer has already been reviewed")
+	ErrFraudRuleNotFound      = errors.New("fraud rule not found")
+	ErrDuplicateFraudRule     = errors.New("fraud rule name already exists")
+)
+
+// StepUpRequiredError is returned when a challenge rule fired and the
+// caller has not verified again. It matches ErrStepUpRequired.
+type StepUpRequiredError struct {
+	Rules []string
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return ErrStepUpRequired.Error() + ": " + strings.Join(e.Rules, ", ")
+}
+
+func (e *StepUpRequiredError) Unwrap() error {
+	return ErrStepUpRequired
+}
+
+var (
+	ErrSanctionsBlocked       = errors.New("blocked by sanctions screening")
+	ErrSanctionsUnderReview   = errors.New("pending sanctions review")
+	ErrSanctionsHitNotFound   = errors.New("sanctions hit not found")
+	ErrSanctionsHitNotPending = errors.New("sanctions hit has already been reviewed")
+	ErrSanctionsListNotLoaded = errors.New("no sanctions list is loaded")
+)
+
+var (
+	ErrCustomerNotFound    = errors.New("customer not found")
+	ErrKYCDocumentNotFound = errors.New("kyc document not found")
+	ErrKYCNotPending       = errors.New("customer has no verification pending")
+	ErrKYCRequired         = errors.New("identity verification required")
+	ErrKYCRejected         = errors.New("identity verification was rejected")
+	ErrKYCLimitExceeded    = errors.New("amount exceeds the limit for unverified customers")
+	ErrInvalidKYCDocument  = errors.New("document must be a JPEG, PNG or PDF")
+	ErrKYCDocumentTooLarge = errors.New("document is too large")
+	ErrKYCAlreadyVerified  = errors.New("customer is already verified")
+	ErrKYCDocumentExpired  = errors.New("identity document has expired")
+)
+
+var (
+	ErrEmailInUse              = errors.New("email address is already in use")
+	ErrBlankName               = errors.New("first and last name cannot be blank")
+	ErrContactChangeNotFound   = errors.New("contact change not found")
+	ErrContactChangeNotPending = errors.New("contact change is no longer pending")
+	ErrContactChangeExpired    = errors.New("verification code has expired")
+	ErrInvalidVerificationCode = errors.New("invalid verification code")
+	ErrIncorrectPassword       = errors.New("current password is incorrect")
+	ErrSamePassword            = errors.New("new password must differ from the current one")
+)
+
+var (
+	ErrInvalidResetToken = errors.New("reset token is invalid or has expired")
+	ErrSessionRevoked    = errors.New("session has been revoked")
+)
+
+// PasswordPolicyError explains why a new password was refused.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password " + e.Reason
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+var (
+	ErrStaffNotFound      = errors.New("staff user not found")
+	ErrStaffUsernameTaken = errors.New("staff username already exists")
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrDuplicateRole      = errors.New("role name already exists")
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrCannotModifySelf   = errors.New("staff cannot change their own roles or deactivate themselves")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrReservedRoleName   = errors.New("role name is reserved")
+)
+
+var (
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotPending     = errors.New("no authenticator enrolment in progress")
+	ErrInvalidMFACode    = errors.New("invalid verification code")
+	ErrInvalidMFAToken   = errors.New("sign-in has expired; start again")
+)
+
+var (
+	ErrLoginThrottled  = errors.New("too many failed sign-in attempts")
+	ErrLockoutNotFound = errors.New("no failed sign-ins recorded for that key")
+)
+
+// LoginThrottledError says when the caller may try again. Locked is set
+// for a lockout rather than a short delay. It matches ErrLoginThrottled.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	wait := e.RetryAfter.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if e.Locked {
+		return ErrLoginThrottled.Error() + "; locked for " + wait.String()
+	}
+	return ErrLoginThrottled.Error() + "; try again in " + wait.String()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+var (
+	ErrAPIClientNotFound = errors.New("API client not found")
+	ErrInvalidClient     = errors.New("client authentication failed")
+	ErrInvalidScope      = errors.New("requested scope is invalid or not granted")
+	ErrUnknownScope      = errors.New("unknown scope")
+)
+
+var (
+	ErrConsentNotFound       = errors.New("consent not found")
+	ErrConsentNotAuthorized  = errors.New("consent is not authorized")
+	ErrConsentNotPending     = errors.New("consent is no longer awaiting authorization")
+	ErrInvalidConsent        = errors.New("invalid consent request")
+	ErrConsentPermission     = errors.New("consent does not grant this permission")
+	ErrConsentAccount        = errors.New("account is not covered by the consent")
+	ErrConsentPaymentLimit   = errors.New("payment exceeds the consent's limit")
+	ErrProviderScopes        = errors.New("third-party providers act through consents and cannot hold scopes")
+	ErrNoScopes              = errors.New("an API client acting for a customer needs at least one scope")
+	ErrNotThirdPartyProvider = errors.New("only third-party providers can request consents")
+)
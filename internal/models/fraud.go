@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+// Fraud rule types.
+const (
+	// FraudVelocityCount fires when the operation would be more than
+	// Threshold debits from the account within the window.
+	FraudVelocityCount = "velocity_count"
+	// FraudVelocityAmount fires when debits within the window, including
+	// this one, would exceed Threshold.
+	FraudVelocityAmount = "velocity_amount"
+	// FraudNewPayee fires on a transfer to an account this account has
+	// never paid before.
+	FraudNewPayee = "new_payee"
+	// FraudUnusualHour fires between StartHour and EndHour (local time,
+	// wrapping past midnight when StartHour > EndHour).
+	FraudUnusualHour = "unusual_hour"
+	// FraudRapidInOut fires when the amount is at least Threshold (a
+	// fraction, e.g. 0.8) of what the account received within the window:
+	// money passing straight through.
+	FraudRapidInOut = "rapid_in_out"
+)
+
+// Fraud rule outcomes, in increasing severity. Allow only flags the
+// operation; challenge asks the customer to verify themselves again; hold
+// parks the operation for staff review.
+const (
+	FraudAllow     = "allow"
+	FraudChallenge = "challenge"
+	FraudHold      = "hold"
+)
+
+// Operations fraud rules apply to.
+const (
+	FraudOpTransfer   = "transfer"
+	FraudOpWithdrawal = "withdrawal"
+	FraudOpAny        = "any"
+)
+
+// FraudRule is one configurable check. Rules only consider operations of
+// at least MinAmount.
+type FraudRule struct {
+	ID            int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Name          string    `gorm:"size:100;uniqueIndex" json:"name"`
+	Type          string    `gorm:"size:30" json:"type"`
+	Operation     string    `gorm:"size:20" json:"operation"`
+	MinAmount     float64   `gorm:"type:decimal(15,2)" json:"min_amount"`
+	Threshold     float64   `gorm:"type:decimal(15,2)" json:"threshold"`
+	WindowSeconds int       `json:"window_seconds"`
+	StartHour     int       `json:"start_hour"`
+	EndHour       int       `json:"end_hour"`
+	Action        string    `gorm:"size:10" json:"action"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (r *FraudRule) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+type CreateFraudRuleRequest struct {
+	Name          string  `json:"name" binding:"required,max=100"`
+	Type          string  `json:"type" binding:"required,oneof=velocity_count velocity_amount new_payee unusual_hour rapid_in_out"`
+	Operation     string  `json:"operation" binding:"required,oneof=transfer withdrawal any"`
+	MinAmount     float64 `json:"min_amount" binding:"gte=0"`
+	Threshold     float64 `json:"threshold" binding:"gte=0"`
+	WindowSeconds int     `json:"window_seconds" binding:"gte=0"`
+	StartHour     int     `json:"start_hour" binding:"gte=0,lte=23"`
+	EndHour       int     `json:"end_hour" binding:"gte=0,lte=23"`
+	Action        string  `json:"action" binding:"required,oneof=allow challenge hold"`
+	Active        *bool   `json:"active"`
+}
+
+type UpdateFraudRuleRequest struct {
+	MinAmount     *float64 `json:"min_amount" binding:"omitempty,gte=0"`
+	Threshold     *float64 `json:"threshold" binding:"omitempty,gte=0"`
+	WindowSeconds *int     `json:"window_seconds" binding:"omitempty,gte=0"`
+	StartHour     *int     `json:"start_hour" binding:"omitempty,gte=0,lte=23"`
+	EndHour       *int     `json:"end_hour" binding:"omitempty,gte=0,lte=23"`
+	Action        *string  `json:"action" binding:"omitempty,oneof=allow challenge hold"`
+	Active        *bool    `json:"active"`
+}
+
+// FraudDecision is the combined outcome of all rules for one operation.
+type FraudDecision struct {
+	Action string   `json:"action"`
+	Rules  []string `json:"rules,omitempty"`
+}
+
+// Held transfer review states.
+const (
+	HeldTransferPending  = "pending"
+	HeldTransferApproved = "approved"
+	HeldTransferRejected = "rejected"
+)
+
+// HeldTransfer is a transfer parked by a fraud rule. Its amount is held on
+// the source account until staff approve (the transfer is posted) or
+// reject it (the hold is released).
+type HeldTransfer struct {
+	ID            int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID    int        `json:"customer_id" gorm:"type:int;index"`
+	FromAccountID int        `json:"from_account_id" gorm:"type:int;index"`
+	ToAccountID   int        `json:"to_account_id" gorm:"type:int"`
+	Amount        float64    `gorm:"type:decimal(15,2)" json:"amount"`
+	Status        string     `gorm:"size:10;index" json:"status"`
+	Rules         string     `json:"rules"`
+	ReviewNote    string     `json:"review_note,omitempty"`
+	ReviewedBy    int        `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	TransactionID *int       `json:"transaction_id,omitempty" gorm:"type:int"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type ReviewHeldTransferRequest struct {
+	Note string `json:"note"`
+}
+
+// TransferResult says whether a transfer was posted or held for review.
+type TransferResult struct {
+	Status         string `json:"status"`
+	TransactionID  int    `json:"transaction_id,omitempty"`
+	HeldTransferID int    `json:"held_transfer_id,omitempty"`
+}
+
+const (
+	TransferCompleted = "completed"
+	TransferHeld      = "held"
+)
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Inbound credit states. Credits that match an internal account are posted
+// straight away; the rest wait in suspense until staff assign them.
+const (
+	InboundCreditPosted   = "posted"
+	InboundCreditSuspense = "suspense"
+	InboundCreditAssigned = "assigned"
+)
+
+type InboundCredit struct {
+	ID             int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	ClearingFileID int        `json:"clearing_file_id" gorm:"type:int;index"`
+	Reference      string     `gorm:"size:64;uniqueIndex" json:"reference"`
+	EndToEndID     string     `gorm:"size:35" json:"end_to_end_id"`
+	AccountNumber  string     `gorm:"size:34" json:"account_number"`
+	Amount         float64    `gorm:"type:decimal(15,2)" json:"amount"`
+	Currency       string     `gorm:"size:3" json:"currency"`
+	DebtorName     string     `json:"debtor_name"`
+	Remittance     string     `json:"remittance"`
+	BookingDate    time.Time  `json:"booking_date"`
+	Status         string     `gorm:"size:10;index" json:"status"`
+	SuspenseReason string     `json:"suspense_reason,omitempty"`
+	AccountID      *int       `json:"account_id" gorm:"type:int;index"`
+	TransactionID  *int       `json:"transaction_id" gorm:"type:int"`
+	PostedAt       *time.Time `json:"posted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type AssignInboundCreditRequest struct {
+	AccountID int `json:"account_id" binding:"required"`
+}
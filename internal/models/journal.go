@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// JournalHead is the single row holding the end of the transaction hash
+// chain. Posting a transaction locks it, so sequences have no gaps.
+type JournalHead struct {
+	ID       int    `gorm:"primaryKey;type:int;autoIncrement:false" json:"-"`
+	Sequence int64  `json:"sequence"`
+	Hash     string `gorm:"size:64" json:"hash"`
+}
+
+// JournalCheckpoint is a signed statement of the chain head at a point in
+// time. Rewriting history before a checkpoint would need the signing key.
+type JournalCheckpoint struct {
+	ID        int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Sequence  int64     `gorm:"index" json:"sequence"`
+	Hash      string    `gorm:"size:64" json:"hash"`
+	KeyID     string    `gorm:"size:16" json:"key_id"`
+	Signature string    `gorm:"size:100" json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalBreak is the first place the chain fails verification.
+type JournalBreak struct {
+	Sequence      int64  `json:"sequence"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// JournalReport is the outcome of walking the chain.
+type JournalReport struct {
+	OK                   bool          `json:"ok"`
+	TransactionsChecked  int64         `json:"transactions_checked"`
+	LastSequence         int64         `json:"last_sequence"`
+	CheckpointsVerified  int           `json:"checkpoints_verified"`
+	UnsealedTransactions int64         `json:"unsealed_transactions"`
+	FirstBreak           *JournalBreak `json:"first_break,omitempty"`
+}
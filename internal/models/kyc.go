@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Customer verification states. Customers start unverified, are pending
+// once they upload a document, and staff move them to verified or
+// rejected. Verification lapses to expired after its validity period.
+const (
+	KYCUnverified = "unverified"
+	KYCPending    = "pending"
+	KYCVerified   = "verified"
+	KYCRejected   = "rejected"
+	KYCExpired    = "expired"
+)
+
+// Identity document types.
+const (
+	KYCPassport       = "passport"
+	KYCNationalID     = "national_id"
+	KYCDrivingLicence = "driving_licence"
+	KYCProofOfAddress = "proof_of_address"
+)
+
+// Document review states.
+const (
+	KYCDocumentPending  = "pending"
+	KYCDocumentAccepted = "accepted"
+	KYCDocumentRejected = "rejected"
+)
+
+// KYCDocument is an uploaded identity document. The file itself lives in
+// the document store under StorageKey.
+type KYCDocument struct {
+	ID             int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID     int        `json:"customer_id" gorm:"type:int;index"`
+	Type           string     `gorm:"size:20" json:"type"`
+	DocumentNumber string     `gorm:"size:50" json:"document_number"`
+	IssuingCountry string     `gorm:"size:2" json:"issuing_country"`
+	ExpiresOn      *time.Time `json:"expires_on,omitempty"`
+	FileName       string     `json:"file_name"`
+	ContentType    string     `gorm:"size:50" json:"content_type"`
+	Size           int64      `json:"size"`
+	SHA256         string     `gorm:"size:64" json:"sha256"`
+	StorageKey     string     `json:"-"`
+	Status         string     `gorm:"size:10;index" json:"status"`
+	ReviewNote     string     `json:"review_note,omitempty"`
+	ReviewedBy     int        `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// UploadKYCDocumentRequest is the form sent alongside the file.
+type UploadKYCDocumentRequest struct {
+	Type           string `form:"type" binding:"required,oneof=passport national_id driving_licence proof_of_address"`
+	DocumentNumber string `form:"document_number" binding:"max=50"`
+	IssuingCountry string `form:"issuing_country" binding:"omitempty,len=2"`
+	ExpiresOn      string `form:"expires_on" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type ReviewKYCRequest struct {
+	Note string `json:"note"`
+}
+
+// KYCProfile is a customer's verification status with their documents.
+type KYCProfile struct {
+	CustomerID int           `json:"customer_id"`
+	Status     string        `json:"status"`
+	VerifiedAt *time.Time    `json:"verified_at,omitempty"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	Documents  []KYCDocument `json:"documents"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// TOTPFactor is a customer's authenticator app. It counts only once
+// ConfirmedAt is set; until then it is an enrolment in progress.
+// LastUsedStep stops a code being used twice.
+type TOTPFactor struct {
+	CustomerID   int        `gorm:"primaryKey;type:int" json:"customer_id"`
+	SealedSecret string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only
+// its hash is stored.
+type RecoveryCode struct {
+	ID         int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID int        `json:"customer_id" gorm:"type:int;index"`
+	CodeHash   string     `gorm:"size:64;index" json:"-"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type MFAStatus struct {
+	TOTPEnabled            bool       `json:"totp_enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnrolment is what the customer scans into their app.
+type TOTPEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAChallenge is the first half of a two-step sign-in.
+type MFAChallenge struct {
+	MFARequired bool     `json:"mfa_required"`
+	MFAToken    string   `json:"mfa_token"`
+	ExpiresIn   int      `json:"expires_in"`
+	Methods     []string `json:"methods"`
+}
+
+type BeginTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// StepUpRequest re-verifies a signed-in customer: with a TOTP code when
+// they have enrolled, otherwise with their password.
+type StepUpRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
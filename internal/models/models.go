@@ -5,15 +5,21 @@ import (
 )
 
 type Customer struct {
-	ID           int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
-	Username     string    `gorm:"unique;size:50" json:"username"`
-	PasswordHash string    `json:"-" gorm:"size:255"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	Email        string    `gorm:"unique;size:100" json:"email"`
-	Phone        string    `gorm:"size:20" json:"phone"`
-	Address      string    `json:"address"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID                int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Username          string     `gorm:"unique;size:50" json:"username"`
+	PasswordHash      string     `json:"-" gorm:"size:255"`
+	FirstName         string     `json:"first_name"`
+	LastName          string     `json:"last_name"`
+	Email             string     `gorm:"unique;size:100" json:"email"`
+	Phone             string     `gorm:"size:20" json:"phone"`
+	Address           string     `json:"address"`
+	ScreeningStatus   string     `gorm:"size:10;default:clear" json:"screening_status"`
+	KYCStatus         string     `gorm:"size:12;default:unverified;index" json:"kyc_status"`
+	KYCVerifiedAt     *time.Time `json:"kyc_verified_at,omitempty"`
+	KYCExpiresAt      *time.Time `json:"kyc_expires_at,omitempty"`
+	KYCRemindedAt     *time.Time `json:"-"`
+	SessionsRevokedAt *time.Time `json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
 
 	Accounts      []Account     `gorm:"foreignKey:CustomerID" json:"-"`
 	Loans         []Loan        `gorm:"foreignKey:CustomerID" json:"-"`
@@ -40,6 +46,7 @@ type Account struct {
 	Branch       *Branch       `gorm:"foreignKey:BranchID" json:"branch"`
 	Owner        string        `json:"owner"`
 	Balance      float64       `gorm:"type:decimal(15,2)" json:"balance"`
+	HeldAmount   float64       `gorm:"type:decimal(15,2);default:0" json:"held_amount"`
 	Currency     string        `json:"currency"`
 	CreatedAt    time.Time     `json:"created_at"`
 	Transactions []Transaction `gorm:"foreignKey:FromAccountID;references:ID" json:"-"`
@@ -53,6 +60,12 @@ type Transaction struct {
 	BeneficiaryID *int      `json:"beneficiary_id" gorm:"type:int;index"`
 	Amount        float64   `gorm:"type:decimal(15,2)" json:"amount"`
 	CreatedAt     time.Time `json:"created_at"`
+
+	// journal hash chain; rows posted before the chain existed have no
+	// sequence until they are sealed
+	Sequence *int64 `gorm:"uniqueIndex" json:"sequence,omitempty"`
+	PrevHash string `gorm:"size:64" json:"prev_hash,omitempty"`
+	Hash     string `gorm:"size:64" json:"hash,omitempty"`
 }
 
 type Loan struct {
@@ -81,6 +94,9 @@ type LoanPayment struct {
 	PaidDate  time.Time `json:"paid_date"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
+	// when the due and overdue reminders went out
+	ReminderSentAt  *time.Time `json:"-"`
+	OverdueNoticeAt *time.Time `json:"-"`
 }
 
 type CreateLoanRequest struct {
@@ -90,11 +106,16 @@ type CreateLoanRequest struct {
 }
 
 type Beneficiary struct {
-	ID            int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
-	CustomerID    int    `json:"customer_id" gorm:"type:int;index"`
-	Name          string `json:"name"`
-	AccountNumber string `json:"account_number"`
-	BankName      string `json:"bank_name"`
+	ID              int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID      int       `json:"customer_id" gorm:"type:int;index;uniqueIndex:idx_beneficiary_customer_account,priority:1"`
+	Name            string    `json:"name"`
+	Nickname        string    `gorm:"size:50" json:"nickname"`
+	AccountNumber   string    `gorm:"size:34;uniqueIndex:idx_beneficiary_customer_account,priority:2" json:"account_number"`
+	BankName        string    `json:"bank_name"`
+	BankCode        string    `gorm:"size:11;uniqueIndex:idx_beneficiary_customer_account,priority:3" json:"bank_code"`
+	CoolingOffUntil time.Time `json:"cooling_off_until"`
+	ScreeningStatus string    `gorm:"size:10;default:clear" json:"screening_status"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type CreateAccountRequest struct {
@@ -119,8 +140,14 @@ type RegisterCustomerRequest struct {
 
 type AddBeneficiaryRequest struct {
 	Name          string `json:"name" binding:"required"`
+	Nickname      string `json:"nickname" binding:"max=50"`
 	AccountNumber string `json:"account_number" binding:"required"`
 	BankName      string `json:"bank_name"`
+	BankCode      string `json:"bank_code" binding:"required"`
+}
+
+type UpdateBeneficiaryRequest struct {
+	Nickname string `json:"nickname" binding:"max=50"`
 }
 
 type DepositRequest struct {
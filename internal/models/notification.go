@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Notification channels.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelInApp = "in_app"
+)
+
+// Notification is an entry in a customer's in-app inbox.
+type Notification struct {
+	ID         int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID int        `json:"customer_id" gorm:"type:int;index"`
+	Event      string     `gorm:"size:50" json:"event"`
+	Subject    string     `json:"subject"`
+	Body       string     `gorm:"type:text" json:"body"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NotificationPreference holds a customer's channel choices. Customers
+// without a row get DefaultNotificationPreference.
+type NotificationPreference struct {
+	CustomerID int  `gorm:"primaryKey;autoIncrement:false;type:int" json:"customer_id"`
+	Email      bool `gorm:"not null" json:"email"`
+	SMS        bool `gorm:"not null" json:"sms"`
+	InApp      bool `gorm:"not null" json:"in_app"`
+	// LowBalanceThreshold triggers a low-balance alert when a debit takes
+	// an account below it; 0 turns the alert off.
+	LowBalanceThreshold float64 `gorm:"type:decimal(15,2)" json:"low_balance_threshold"`
+	// MutedEvents is a comma-separated list of event types not to notify.
+	MutedEvents string    `json:"muted_events"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func DefaultNotificationPreference(customerID int) *NotificationPreference {
+	return &NotificationPreference{CustomerID: customerID, Email: true, InApp: true, LowBalanceThreshold: 1000}
+}
+
+type UpdateNotificationPreferenceRequest struct {
+	Email               *bool    `json:"email"`
+	SMS                 *bool    `json:"sms"`
+	InApp               *bool    `json:"in_app"`
+	LowBalanceThreshold *float64 `json:"low_balance_threshold" binding:"omitempty,gte=0"`
+	MutedEvents         *string  `json:"muted_events"`
+}
+
+// CustomerDevice is a device a customer has signed in from, used to spot
+// logins from somewhere new.
+type CustomerDevice struct {
+	ID          int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID  int       `json:"customer_id" gorm:"type:int;uniqueIndex:idx_device_customer_fingerprint,priority:1"`
+	Fingerprint string    `gorm:"size:64;uniqueIndex:idx_device_customer_fingerprint,priority:2" json:"-"`
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `gorm:"size:45" json:"last_ip"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
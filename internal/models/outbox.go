@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes. Data is the event's JSON payload.
+type OutboxEvent struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type       string    `gorm:"size:50;index" json:"type"`
+	CustomerID int       `json:"customer_id" gorm:"type:int;index"`
+	Data       string    `gorm:"type:text" json:"data"`
+	OccurredAt time.Time `json:"occurred_at"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// OutboxConsumer is one sink's position in the outbox. Only the instance
+// holding the lease delivers to the sink, so events stay in order across
+// several API servers.
+type OutboxConsumer struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	LastEventID int64     `json:"last_event_id"`
+	LeaseOwner  string    `gorm:"size:100" json:"lease_owner"`
+	LeaseUntil  time.Time `json:"lease_until"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
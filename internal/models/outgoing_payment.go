@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Outgoing payment lifecycle. A payment starts as initiated once the
+// customer's account has been debited into the outbound clearing account,
+// is submitting while claimed for hand-over to a payment rail, becomes
+// submitted once the rail has it, and ends as settled or returned. A
+// return can also arrive after settlement.
+const (
+	PaymentStatusInitiated  = "initiated"
+	PaymentStatusSubmitting = "submitting"
+	PaymentStatusSubmitted  = "submitted"
+	PaymentStatusSettled    = "settled"
+	PaymentStatusReturned   = "returned"
+)
+
+// OpenPaymentStatuses are the statuses of payments still on their way to
+// the rail, which need their beneficiary's details.
+var OpenPaymentStatuses = []string{PaymentStatusInitiated, PaymentStatusSubmitting, PaymentStatusSubmitted}
+
+type OutgoingPayment struct {
+	ID                  int          `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	AccountID           int          `json:"account_id" gorm:"type:int;index"`
+	Account             *Account     `gorm:"foreignKey:AccountID" json:"-"`
+	BeneficiaryID       int          `json:"beneficiary_id" gorm:"type:int;index"`
+	Beneficiary         *Beneficiary `gorm:"foreignKey:BeneficiaryID" json:"beneficiary,omitempty"`
+	Amount              float64      `gorm:"type:decimal(15,2)" json:"amount"`
+	Currency            string       `gorm:"size:3" json:"currency"`
+	Reference           string       `gorm:"size:140" json:"reference"`
+	Status              string       `gorm:"size:20;index" json:"status"`
+	Rail                string       `gorm:"size:20" json:"rail"`
+	RailReference       string       `gorm:"size:64;index" json:"rail_reference"`
+	ClearingFileID      *int         `json:"clearing_file_id,omitempty" gorm:"type:int;index"`
+	ReturnReason        string       `json:"return_reason,omitempty"`
+	DebitTransactionID  *int         `json:"debit_transaction_id" gorm:"type:int"`
+	ReturnTransactionID *int         `json:"return_transaction_id,omitempty" gorm:"type:int"`
+	SubmittedAt         *time.Time   `json:"submitted_at,omitempty"`
+	SettledAt           *time.Time   `json:"settled_at,omitempty"`
+	ReturnedAt          *time.Time   `json:"returned_at,omitempty"`
+	// PendingStatus and PendingReason keep a rail update that could not be
+	// applied, until RetryStatusUpdates gets it through.
+	PendingStatus string    `gorm:"size:20;index" json:"-"`
+	PendingReason string    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateOutgoingPaymentRequest struct {
+	BeneficiaryID int     `json:"beneficiary_id" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Reference     string  `json:"reference" binding:"max=140"`
+}
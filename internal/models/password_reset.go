@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use token sent to a customer who forgot
+// their password. Only its hash is stored.
+type PasswordResetToken struct {
+	ID         int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID int        `json:"customer_id" gorm:"type:int;index"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	// Login is the username or email address.
+	Login string `json:"login" binding:"required,max=100"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Contact change states. A change waits for the code sent to the new
+// address; asking for another change of the same kind cancels it.
+const (
+	ContactChangePending   = "pending"
+	ContactChangeConfirmed = "confirmed"
+	ContactChangeCancelled = "cancelled"
+)
+
+// ContactChange is a requested new email address or phone number that
+// takes effect once the customer enters the code sent to it.
+type ContactChange struct {
+	ID          int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID  int        `json:"customer_id" gorm:"type:int;index"`
+	Channel     string     `gorm:"size:10" json:"channel"`
+	NewValue    string     `gorm:"size:100" json:"new_value"`
+	CodeHash    string     `gorm:"size:64" json:"-"`
+	Attempts    int        `json:"-"`
+	Status      string     `gorm:"size:10" json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ProfileChange is one entry in a customer's profile history. Password
+// changes are recorded without values.
+type ProfileChange struct {
+	ID         int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID int       `json:"customer_id" gorm:"type:int;index"`
+	Field      string    `gorm:"size:20" json:"field"`
+	OldValue   string    `json:"old_value,omitempty"`
+	NewValue   string    `json:"new_value,omitempty"`
+	RequestID  string    `gorm:"size:64" json:"request_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"last_name" binding:"omitempty,min=1,max=100"`
+	Address   *string `json:"address" binding:"omitempty,max=255"`
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" binding:"required,e164"`
+}
+
+type ConfirmContactChangeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
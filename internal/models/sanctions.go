@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Screening states of a customer or beneficiary.
+const (
+	ScreeningClear   = "clear"
+	ScreeningReview  = "review"
+	ScreeningBlocked = "blocked"
+)
+
+// Sanctions hit subjects and states. A blocked hit was refused outright at
+// onboarding; pending hits wait for staff, who confirm or dismiss them.
+const (
+	SanctionsSubjectCustomer    = "customer"
+	SanctionsSubjectBeneficiary = "beneficiary"
+
+	SanctionsHitPending   = "pending"
+	SanctionsHitConfirmed = "confirmed"
+	SanctionsHitDismissed = "dismissed"
+	SanctionsHitBlocked   = "blocked"
+)
+
+// SanctionsHit is a screened name that resembled a listed one. A subject
+// is raised once per list entry, so a dismissed hit stays dismissed when
+// the list is re-screened. SubjectID is zero when onboarding was refused.
+type SanctionsHit struct {
+	ID           int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	SubjectType  string     `gorm:"size:20;uniqueIndex:idx_sanctions_hit_subject,priority:1" json:"subject_type"`
+	SubjectID    int        `gorm:"type:int;uniqueIndex:idx_sanctions_hit_subject,priority:2" json:"subject_id"`
+	CustomerID   int        `json:"customer_id" gorm:"type:int;index"`
+	ScreenedName string     `gorm:"size:200;uniqueIndex:idx_sanctions_hit_subject,priority:4" json:"screened_name"`
+	EntryID      string     `gorm:"size:64;uniqueIndex:idx_sanctions_hit_subject,priority:3" json:"entry_id"`
+	EntryName    string     `json:"entry_name"`
+	MatchedName  string     `json:"matched_name"`
+	Score        float64    `gorm:"type:decimal(5,4)" json:"score"`
+	ListVersion  string     `gorm:"size:64" json:"list_version"`
+	Status       string     `gorm:"size:10;index" json:"status"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	ReviewedBy   int        `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// SanctionsList records each version of the list that was loaded and when
+// everyone was last screened against it.
+type SanctionsList struct {
+	Version    string     `gorm:"primaryKey;size:64" json:"version"`
+	Source     string     `json:"source"`
+	Entries    int        `json:"entries"`
+	LoadedAt   time.Time  `json:"loaded_at"`
+	ScreenedAt *time.Time `json:"screened_at,omitempty"`
+}
+
+// ScreeningResult is the outcome of screening one subject's names.
+type ScreeningResult struct {
+	Status      string           `json:"status"`
+	ListVersion string           `json:"list_version"`
+	Matches     []SanctionsMatch `json:"matches,omitempty"`
+}
+
+// SanctionsMatch is a listed name that resembles a screened one.
+type SanctionsMatch struct {
+	ScreenedName string  `json:"screened_name"`
+	EntryID      string  `json:"entry_id"`
+	EntryName    string  `json:"entry_name"`
+	MatchedName  string  `json:"matched_name"`
+	Score        float64 `json:"score"`
+}
+
+type ReviewSanctionsHitRequest struct {
+	Note string `json:"note"`
+}
+
+// RescreenResult summarises a re-screening run.
+type RescreenResult struct {
+	ListVersion   string `json:"list_version"`
+	Customers     int    `json:"customers"`
+	Beneficiaries int    `json:"beneficiaries"`
+	NewHits       int    `json:"new_hits"`
+}
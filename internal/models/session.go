@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Session is one sign-in: a family of refresh tokens, each replaced by
+// the next when used. Revoking the session ends every access token issued
+// in it. ExpiresAt moves forward with each refresh. AMR (comma-separated)
+// and AuthTime record how and when the customer last authenticated, and
+// are carried into every access token.
+type Session struct {
+	ID           string     `gorm:"primaryKey;size:32" json:"id"`
+	CustomerID   int        `json:"customer_id" gorm:"type:int;index"`
+	IP           string     `gorm:"size:45" json:"ip"`
+	UserAgent    string     `json:"user_agent"`
+	AMR          string     `gorm:"size:50" json:"amr"`
+	AuthTime     time.Time  `json:"auth_time"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `gorm:"size:30" json:"revoke_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Session revoke reasons.
+const (
+	SessionLogout        = "logout"
+	SessionRevoked       = "revoked"
+	SessionPasswordReset = "password_reset"
+	SessionTokenReuse    = "refresh_token_reuse"
+)
+
+// RefreshToken is one link in a session's chain. Only its hash is stored;
+// UsedAt is set when it is exchanged, and presenting it again revokes the
+// session.
+type RefreshToken struct {
+	ID        int        `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	SessionID string     `gorm:"size:32;index" json:"session_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TokenPair is what sign-in and refresh return. Token repeats
+// AccessToken for clients written before refresh tokens existed. Step-up
+// returns only a new access token.
+type TokenPair struct {
+	Token        string `json:"token"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	SessionID    string `json:"session_id"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,114 @@
+package models
+
+import "time"
+
+// Permissions granted to staff through roles.
+const (
+	PermStaffManage     = "staff.manage"
+	PermAuditRead       = "audit.read"
+	PermPaymentsOperate = "payments.operate"
+	PermBillersManage   = "billers.manage"
+	PermFraudRules      = "fraud.rules"
+	PermFraudReview     = "fraud.review"
+	PermKYCReview       = "kyc.review"
+	PermSanctionsReview = "sanctions.review"
+	PermJournalRead     = "journal.read"
+	PermJournalWrite    = "journal.write"
+	PermSecurityManage  = "security.manage"
+	PermClientsManage   = "clients.manage"
+)
+
+// AllPermissions lists every permission a role may grant.
+var AllPermissions = []string{
+	PermStaffManage, PermAuditRead, PermPaymentsOperate, PermBillersManage,
+	PermFraudRules, PermFraudReview, PermKYCReview, PermSanctionsReview,
+	PermJournalRead, PermJournalWrite, PermSecurityManage, PermClientsManage,
+}
+
+// RoleCustomer is the role in every customer token (auth.KindCustomer).
+// It is not stored and cannot be used for a staff role.
+const RoleCustomer = "customer"
+
+// Role is a named set of permissions. Built-in roles are seeded at start
+// and can be edited but not renamed.
+type Role struct {
+	ID          int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Name        string    `gorm:"size:50;uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+	BuiltIn     bool      `json:"built_in"`
+	Permissions []string  `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type RolePermission struct {
+	RoleID     int    `gorm:"primaryKey;type:int"`
+	Permission string `gorm:"primaryKey;size:50"`
+}
+
+// StaffUser is a bank employee. Staff sign in separately from customers
+// and only reach the /admin routes their roles allow.
+type StaffUser struct {
+	ID           int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	Username     string `gorm:"size:50;uniqueIndex" json:"username"`
+	PasswordHash string `json:"-"`
+	FirstName    string `gorm:"size:100" json:"first_name"`
+	LastName     string `gorm:"size:100" json:"last_name"`
+	Email        string `gorm:"size:100" json:"email"`
+	Active       bool   `json:"active"`
+	// Roles is filled in by the repository from staff_roles.
+	Roles []string `gorm:"-" json:"roles"`
+	// TokensRevokedAt invalidates tokens issued before it, e.g. after a
+	// change of roles, since tokens carry the permissions.
+	TokensRevokedAt *time.Time `json:"-"`
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type StaffRole struct {
+	StaffID int `gorm:"primaryKey;type:int"`
+	RoleID  int `gorm:"primaryKey;type:int"`
+}
+
+type StaffLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type CreateStaffRequest struct {
+	Username  string   `json:"username" binding:"required,max=50"`
+	Password  string   `json:"password" binding:"required"`
+	FirstName string   `json:"first_name" binding:"required,max=100"`
+	LastName  string   `json:"last_name" binding:"required,max=100"`
+	Email     string   `json:"email" binding:"omitempty,email,max=100"`
+	Roles     []string `json:"roles" binding:"required,min=1"`
+}
+
+type UpdateStaffRequest struct {
+	FirstName *string   `json:"first_name" binding:"omitempty,max=100"`
+	LastName  *string   `json:"last_name" binding:"omitempty,max=100"`
+	Email     *string   `json:"email" binding:"omitempty,email,max=100"`
+	Roles     *[]string `json:"roles" binding:"omitempty,min=1"`
+	Active    *bool     `json:"active"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions" binding:"omitempty,min=1"`
+}
+
+// StaffToken is what a staff sign-in returns.
+type StaffToken struct {
+	Token       string   `json:"token"`
+	TokenType   string   `json:"token_type"`
+	ExpiresIn   int      `json:"expires_in"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
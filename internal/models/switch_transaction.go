@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SwitchTransaction records a financial message received from the ATM/POS
+// switch, so retransmissions get the original answer and reversals can
+// find what they undo. Terminal and RRN identify the message.
+type SwitchTransaction struct {
+	ID             int     `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	MTI            string  `gorm:"size:4" json:"mti"`
+	ProcessingCode string  `gorm:"size:6" json:"processing_code"`
+	TerminalID     string  `gorm:"size:8;uniqueIndex:idx_switch_terminal_rrn" json:"terminal_id"`
+	RRN            string  `gorm:"size:12;uniqueIndex:idx_switch_terminal_rrn" json:"rrn"`
+	STAN           string  `gorm:"size:6" json:"stan"`
+	CardID         *int    `json:"card_id,omitempty" gorm:"type:int;index"`
+	AccountID      *int    `json:"account_id,omitempty" gorm:"type:int;index"`
+	Amount         float64 `gorm:"type:decimal(15,2)" json:"amount"`
+	ResponseCode   string  `gorm:"size:2" json:"response_code"`
+	AuthCode       string  `gorm:"size:6" json:"auth_code,omitempty"`
+	// TransactionID is the posting made for an approved withdrawal.
+	TransactionID *int `json:"transaction_id,omitempty" gorm:"type:int"`
+	// ReversalTransactionID is the posting that undid it, if reversed.
+	ReversalTransactionID *int       `json:"reversal_transaction_id,omitempty" gorm:"type:int"`
+	ReversedAt            *time.Time `json:"reversed_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
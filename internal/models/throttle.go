@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// LoginThrottle counts recent failed sign-ins for one key: a username
+// ("user:alice", "staff:bob") or a client address ("ip:203.0.113.7").
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;size:191" json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `gorm:"index" json:"locked_until,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RateLimitBucket is a token bucket shared by every instance of the
+// server. Tokens is the level as of RefilledAt.
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;size:191"`
+	Tokens     float64   `gorm:"type:double"`
+	RefilledAt time.Time `gorm:"index"`
+}
+
+type UnlockRequest struct {
+	Key string `json:"key" binding:"required"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Webhook delivery states. A delivery that exhausts its retries is dead
+// (the dead-letter list) until someone redelivers it.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription sends the owner's events of the listed types to URL.
+// Secret keys the HMAC signature on every delivery; it is returned only
+// when the subscription is created.
+type WebhookSubscription struct {
+	ID         int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	CustomerID int    `json:"customer_id" gorm:"type:int;index"`
+	URL        string `gorm:"size:500" json:"url"`
+	Secret     string `gorm:"size:64" json:"-"`
+	// comma-separated event types, e.g. "transfer.completed,deposit.received"
+	EventTypes string    `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int    `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	SubscriptionID int    `json:"subscription_id" gorm:"type:int;uniqueIndex:idx_webhook_delivery_event,priority:1"`
+	EventID        string `gorm:"size:36;uniqueIndex:idx_webhook_delivery_event,priority:2" json:"event_id"`
+	EventType      string `gorm:"size:50" json:"event_type"`
+	Payload        string `gorm:"type:text" json:"payload"`
+	Status         string `gorm:"size:10;index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts       int    `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is next due; workers push it
+	// forward while they are sending so nobody else picks it up.
+	NextAttemptAt  time.Time            `gorm:"index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int                  `json:"last_status_code"`
+	LastError      string               `gorm:"size:500" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	AttemptLog     []WebhookAttempt     `gorm:"foreignKey:DeliveryID" json:"attempt_log,omitempty"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+}
+
+// WebhookAttempt is one HTTP call made for a delivery.
+type WebhookAttempt struct {
+	ID          int       `gorm:"primaryKey;autoIncrement;type:int" json:"id"`
+	DeliveryID  int       `json:"delivery_id" gorm:"type:int;index"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `gorm:"size:500" json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+}
+
+// CreatedWebhook is returned once, with the signing secret.
+type CreatedWebhook struct {
+	*WebhookSubscription
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,41 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+)
+
+// verificationCode is the template for one-time codes sent to an address
+// the customer is adding. It is never raised as an event.
+const verificationCode = "verification.code"
+
+// ErrChannelUnavailable is returned when no notifier is configured for
+// the channel, e.g. email without SMTP_ADDR.
+var ErrChannelUnavailable = errors.New("notification channel is not configured")
+
+// SendCode queues a verification code for delivery to an address that is
+// not on the customer's profile yet. It skips the customer's preferences,
+// since they asked for it, but is retried like any other delivery.
+func (d *Dispatcher) SendCode(customerID int, channel, to, code string) error {
+	if _, ok := d.notifiers[channel]; !ok {
+		return ErrChannelUnavailable
+	}
+	subject, body, err := d.templates.Render(verificationCode, channel, TemplateData{
+		Data: map[string]any{"code": code},
+	})
+	if err != nil {
+		return err
+	}
+
+	job := delivery{
+		channel: channel,
+		msg:     Message{CustomerID: customerID, Event: verificationCode, To: to, Subject: subject, Body: body},
+		attempt: 1,
+	}
+	select {
+	case d.deliveries <- job:
+		return nil
+	default:
+		return fmt.Errorf("notifications: queue full, cannot send %s", verificationCode)
+	}
+}
@@ -0,0 +1,216 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+// lowBalance is a notification derived from transfer.completed when the
+// debit takes the account below the customer's alert level.
+const lowBalance = "balance.low"
+
+type DispatcherConfig struct {
+	Workers   int
+	QueueSize int
+	// MaxAttempts bounds deliveries per channel; retries back off
+	// exponentially from RetryBackoff.
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	SendTimeout  time.Duration
+}
+
+// Dispatcher turns events into notifications. HandleEvent only enqueues;
+// a pool of workers renders and sends.
+type Dispatcher struct {
+	customers repositories.CustomerRepository
+	prefs     repositories.NotificationPreferenceRepository
+	templates *Templates
+	notifiers map[string]Notifier
+	config    DispatcherConfig
+
+	events     chan events.Event
+	deliveries chan delivery
+}
+
+type delivery struct {
+	channel string
+	msg     Message
+	attempt int
+}
+
+func NewDispatcher(
+	customers repositories.CustomerRepository,
+	prefs repositories.NotificationPreferenceRepository,
+	templates *Templates,
+	config DispatcherConfig,
+	notifiers ...Notifier,
+) *Dispatcher {
+	d := &Dispatcher{
+		customers:  customers,
+		prefs:      prefs,
+		templates:  templates,
+		notifiers:  make(map[string]Notifier),
+		config:     config,
+		events:     make(chan events.Event, config.QueueSize),
+		deliveries: make(chan delivery, config.QueueSize),
+	}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+	return d
+}
+
+// Start launches the workers.
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.config.Workers; i++ {
+		go d.work()
+	}
+}
+
+// HandleEvent queues e for notification. It never blocks: if the queue is
+// full the event is dropped and logged.
+func (d *Dispatcher) HandleEvent(e events.Event) {
+	if !d.templates.Has(e.Type) && e.Type != events.TransferCompleted {
+		return
+	}
+	select {
+	case d.events <- e:
+	default:
+		log.Printf("notifications: queue full, dropping %s for customer %d", e.Type, e.CustomerID)
+	}
+}
+
+func (d *Dispatcher) work() {
+	for {
+		select {
+		case e := <-d.events:
+			d.expand(e)
+		case job := <-d.deliveries:
+			d.deliver(job)
+		}
+	}
+}
+
+// expand renders e for each channel the customer wants and delivers it.
+func (d *Dispatcher) expand(e events.Event) {
+	customer, err := d.customers.GetByID(e.CustomerID)
+	if err != nil || customer == nil {
+		log.Printf("notifications: %s: customer %d: %v", e.Type, e.CustomerID, err)
+		return
+	}
+	pref, err := d.prefs.Get(e.CustomerID)
+	if err != nil {
+		log.Printf("notifications: %s: preferences for %d: %v", e.Type, e.CustomerID, err)
+		return
+	}
+	if pref == nil {
+		pref = models.DefaultNotificationPreference(e.CustomerID)
+	}
+
+	data := TemplateData{Customer: customer, OccurredAt: e.OccurredAt, Data: e.Data}
+	notify := []string{e.Type}
+	if e.Type == events.TransferCompleted && crossedBelow(pref.LowBalanceThreshold, e.Data) {
+		data.Data = withThreshold(e.Data, pref.LowBalanceThreshold)
+		notify = append(notify, lowBalance)
+	}
+
+	for _, event := range notify {
+		if muted(pref, event) || !d.templates.Has(event) {
+			continue
+		}
+		for _, channel := range enabledChannels(pref) {
+			if _, ok := d.notifiers[channel]; !ok {
+				continue
+			}
+			subject, body, err := d.templates.Render(event, channel, data)
+			if err != nil {
+				log.Printf("notifications: render %s for %s: %v", event, channel, err)
+				continue
+			}
+			msg := Message{CustomerID: customer.ID, Event: event, Subject: subject, Body: body}
+			switch channel {
+			case models.ChannelEmail:
+				msg.To = customer.Email
+			case models.ChannelSMS:
+				msg.To = customer.Phone
+			}
+			d.deliver(delivery{channel: channel, msg: msg, attempt: 1})
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(job delivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.SendTimeout)
+	err := d.notifiers[job.channel].Send(ctx, job.msg)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	if job.attempt >= d.config.MaxAttempts {
+		log.Printf("notifications: giving up on %s %s for customer %d after %d attempts: %v",
+			job.channel, job.msg.Event, job.msg.CustomerID, job.attempt, err)
+		return
+	}
+	wait := d.config.RetryBackoff << uint(job.attempt-1)
+	log.Printf("notifications: %s %s for customer %d failed (attempt %d), retrying in %s: %v",
+		job.channel, job.msg.Event, job.msg.CustomerID, job.attempt, wait, err)
+
+	job.attempt++
+	time.AfterFunc(wait, func() {
+		select {
+		case d.deliveries <- job:
+		default:
+			log.Printf("notifications: queue full, dropping retry of %s %s", job.channel, job.msg.Event)
+		}
+	})
+}
+
+func enabledChannels(pref *models.NotificationPreference) []string {
+	var channels []string
+	if pref.Email {
+		channels = append(channels, models.ChannelEmail)
+	}
+	if pref.SMS {
+		channels = append(channels, models.ChannelSMS)
+	}
+	if pref.InApp {
+		channels = append(channels, models.ChannelInApp)
+	}
+	return channels
+}
+
+func muted(pref *models.NotificationPreference, event string) bool {
+	for _, m := range strings.Split(pref.MutedEvents, ",") {
+		if strings.TrimSpace(m) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// crossedBelow reports whether a debit of data["amount"] that left
+// data["balance"] took the account from at or above threshold to below it.
+func crossedBelow(threshold float64, data map[string]any) bool {
+	if threshold <= 0 {
+		return false
+	}
+	after := toFloat(data["balance"])
+	before := after + toFloat(data["amount"])
+	return before >= threshold && after < threshold
+}
+
+func withThreshold(data map[string]any, threshold float64) map[string]any {
+	out := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		out[k] = v
+	}
+	out["threshold"] = threshold
+	return out
+}
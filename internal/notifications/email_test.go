@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/notifications/smtpfake"
+)
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := &smtpfake.Server{}
+	addr, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	notifier := &SMTPNotifier{Addr: addr, From: "alerts@bank.example"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = notifier.Send(ctx, Message{
+		CustomerID: 1,
+		Event:      "deposit.received",
+		To:         "jane@example.com",
+		Subject:    "Deposit received",
+		// the second line starts with a dot, which SMTP has to escape
+		Body: "You received 750.00 INR.\n.Balance: 1250.00 INR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.From != "alerts@bank.example" {
+		t.Errorf("envelope sender = %q", got.From)
+	}
+	if len(got.To) != 1 || got.To[0] != "jane@example.com" {
+		t.Errorf("envelope recipients = %q", got.To)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to := parsed.Header.Get("To"); to != "jane@example.com" {
+		t.Errorf("To header = %q", to)
+	}
+	if subject := parsed.Header.Get("Subject"); subject != "Deposit received" {
+		t.Errorf("Subject header = %q", subject)
+	}
+	body, err := io.ReadAll(parsed.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "You received 750.00 INR.\r\n.Balance: 1250.00 INR"
+	if strings.TrimRight(string(body), "\r\n") != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestSMTPNotifierSkipsEmptyRecipient(t *testing.T) {
+	server := &smtpfake.Server{}
+	addr, err := server.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	notifier := &SMTPNotifier{Addr: addr, From: "alerts@bank.example"}
+	if err := notifier.Send(context.Background(), Message{Subject: "no address"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Messages()); n != 0 {
+		t.Errorf("got %d messages, want none", n)
+	}
+}
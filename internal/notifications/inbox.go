@@ -0,0 +1,24 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+// InboxNotifier stores notifications in the customer's in-app inbox.
+type InboxNotifier struct {
+	Repo repositories.NotificationRepository
+}
+
+func (n *InboxNotifier) Channel() string { return models.ChannelInApp }
+
+func (n *InboxNotifier) Send(_ context.Context, msg Message) error {
+	return n.Repo.Create(&models.Notification{
+		CustomerID: msg.CustomerID,
+		Event:      msg.Event,
+		Subject:    msg.Subject,
+		Body:       msg.Body,
+	})
+}
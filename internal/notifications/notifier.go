@@ -0,0 +1,25 @@
+// Package notifications tells customers about events on their accounts
+// and loans. A Dispatcher receives domain events from the outbox relay,
+// renders the template for each event and hands the result to one
+// Notifier per channel the customer has enabled, retrying failed
+// deliveries in the background so that the relay never waits on email
+// or SMS.
+package notifications
+
+import "context"
+
+// Message is one rendered notification for one channel. To is the email
+// address or phone number; it is empty for the in-app inbox.
+type Message struct {
+	CustomerID int
+	Event      string
+	To         string
+	Subject    string
+	Body       string
+}
+
+type Notifier interface {
+	// Channel is one of models.ChannelEmail, ChannelSMS or ChannelInApp.
+	Channel() string
+	Send(ctx context.Context, msg Message) error
+}
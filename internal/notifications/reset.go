@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// passwordReset is the template for reset links. It is never raised as an
+// event: the token must not pass through the outbox.
+const passwordReset = "password.reset"
+
+// resetLink appends the token to base as the "token" query parameter.
+func resetLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// LogResetSender writes reset links to the log instead of sending them,
+// for development.
+type LogResetSender struct {
+	URL string
+}
+
+func (s LogResetSender) SendReset(customer *models.Customer, token string, expiresAt time.Time) error {
+	log.Printf("password reset for customer %d (%s), valid until %s: %s",
+		customer.ID, customer.Username, expiresAt.Format(time.RFC3339), resetLink(s.URL, token))
+	return nil
+}
+
+// NotifierResetSender sends reset links on one channel, email or SMS, to
+// the address on the customer's profile.
+type NotifierResetSender struct {
+	Notifier  Notifier
+	Templates *Templates
+	URL       string
+	Timeout   time.Duration
+}
+
+func (s *NotifierResetSender) SendReset(customer *models.Customer, token string, expiresAt time.Time) error {
+	channel := s.Notifier.Channel()
+	subject, body, err := s.Templates.Render(passwordReset, channel, TemplateData{
+		Customer:   customer,
+		OccurredAt: time.Now(),
+		Data:       map[string]any{"link": resetLink(s.URL, token), "expires_at": expiresAt},
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := Message{CustomerID: customer.ID, Event: passwordReset, Subject: subject, Body: body, To: customer.Email}
+	if channel == models.ChannelSMS {
+		msg.To = customer.Phone
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+	return s.Notifier.Send(ctx, msg)
+}
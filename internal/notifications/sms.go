@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// HTTPSMSNotifier posts {"to", "message"} as JSON to an SMS gateway.
+type HTTPSMSNotifier struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (n *HTTPSMSNotifier) Channel() string { return models.ChannelSMS }
+
+func (n *HTTPSMSNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]string{"to": msg.To, "message": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway returned %s", resp.Status)
+	}
+	return nil
+}
+
+// LogSMSNotifier writes SMS messages to the log instead of sending them,
+// for development without a gateway.
+type LogSMSNotifier struct{}
+
+func (LogSMSNotifier) Channel() string { return models.ChannelSMS }
+
+func (LogSMSNotifier) Send(_ context.Context, msg Message) error {
+	if msg.To != "" {
+		log.Printf("sms to %s: %s", msg.To, msg.Body)
+	}
+	return nil
+}
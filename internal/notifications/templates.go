@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Each templates/<event>.tmpl defines "subject", "body" (email and inbox)
+// and "sms". Events without a file are not notified.
+type Templates struct {
+	byEvent map[string]*template.Template
+}
+
+// TemplateData is what templates render.
+type TemplateData struct {
+	Customer   *models.Customer
+	OccurredAt time.Time
+	Data       map[string]any
+}
+
+func LoadTemplates() (*Templates, error) {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{byEvent: make(map[string]*template.Template)}
+	for _, f := range files {
+		event := strings.TrimSuffix(f.Name(), ".tmpl")
+		tmpl, err := template.New(event).Funcs(templateFuncs).ParseFS(templateFS, path.Join("templates", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		t.byEvent[event] = tmpl
+	}
+	return t, nil
+}
+
+func (t *Templates) Has(event string) bool {
+	_, ok := t.byEvent[event]
+	return ok
+}
+
+// Render produces the message for one channel; SMS gets the short form.
+func (t *Templates) Render(event, channel string, data TemplateData) (subject, body string, err error) {
+	tmpl, ok := t.byEvent[event]
+	if !ok {
+		return "", "", fmt.Errorf("no template for %s", event)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	name := "body"
+	if channel == models.ChannelSMS {
+		name = "sms"
+	}
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", "", err
+	}
+	return subject, strings.TrimSpace(buf.String()), nil
+}
+
+// Event data may come straight from a service or back from JSON, so the
+// helpers accept either form.
+var templateFuncs = template.FuncMap{
+	"money": func(v any) string {
+		return fmt.Sprintf("%.2f", toFloat(v))
+	},
+	"account": func(v any) string {
+		number := models.FormatAccountNumber(int(toFloat(v)))
+		return "XXXX" + number[len(number)-4:]
+	},
+	"date": func(v any) string {
+		switch t := v.(type) {
+		case time.Time:
+			return t.Format("02 Jan 2006")
+		case string:
+			if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+				return parsed.Format("02 Jan 2006")
+			}
+			return t
+		}
+		return fmt.Sprint(v)
+	},
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case interface{ Float64() (float64, error) }:
+		f, _ := n.Float64()
+		return f
+	}
+	return 0
+}
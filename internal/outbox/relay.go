@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// GapTimeout is how long the relay waits on a missing ID, which is
+	// usually a transaction that has not committed yet, before assuming it
+	// rolled back and moving past it.
+	GapTimeout  time.Duration
+	SendTimeout time.Duration
+}
+
+// Relay delivers outbox events to sinks. Each sink is a named consumer
+// with its own offset and lease, so a slow or failing sink holds back only
+// itself.
+type Relay struct {
+	repo      repositories.OutboxRepository
+	config    RelayConfig
+	owner     string
+	consumers []consumer
+}
+
+type consumer struct {
+	name string
+	sink Sink
+}
+
+func NewRelay(repo repositories.OutboxRepository, config RelayConfig) *Relay {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return &Relay{
+		repo:   repo,
+		config: config,
+		owner:  fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(b)),
+	}
+}
+
+// Add registers a sink under a consumer name. The name keys the stored
+// offset, so renaming a consumer replays the outbox to it from the start.
+func (r *Relay) Add(name string, sink Sink) {
+	r.consumers = append(r.consumers, consumer{name: name, sink: sink})
+}
+
+// Start runs one delivery loop per consumer.
+func (r *Relay) Start() {
+	for _, c := range r.consumers {
+		go r.run(c)
+	}
+}
+
+func (r *Relay) run(c consumer) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := r.pass(c); err != nil {
+			log.Printf("outbox %s: %v", c.name, err)
+		}
+	}
+}
+
+// pass delivers what is available to one consumer, stopping at the first
+// sink error or unexplained gap.
+func (r *Relay) pass(c consumer) error {
+	now := time.Now()
+	// the lease outlives a full batch of slow deliveries
+	leaseFor := r.config.PollInterval + time.Duration(r.config.BatchSize)*r.config.SendTimeout
+	state, ok, err := r.repo.AcquireLease(c.name, r.owner, now, now.Add(leaseFor))
+	if err != nil || !ok {
+		return err
+	}
+
+	rows, err := r.repo.ListAfter(state.LastEventID, r.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	last := state.LastEventID
+	for i := range rows {
+		row := &rows[i]
+		if row.ID != last+1 && time.Since(row.CreatedAt) < r.config.GapTimeout {
+			// an earlier ID may still be committing; wait for it
+			break
+		}
+
+		if err := r.deliver(c, row); err != nil {
+			return fmt.Errorf("event %d: %w", row.ID, err)
+		}
+		last = row.ID
+		if ok, err := r.repo.CommitOffset(c.name, r.owner, last); err != nil || !ok {
+			if err == nil {
+				err = fmt.Errorf("lost lease")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Relay) deliver(c consumer, row *models.OutboxEvent) error {
+	e, err := toEvent(row)
+	if err != nil {
+		// a payload we cannot decode will never decode; skip it loudly
+		log.Printf("outbox %s: event %d has bad data, skipping: %v", c.name, row.ID, err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.SendTimeout)
+	defer cancel()
+	return c.sink.Deliver(ctx, e)
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/webhooks"
+)
+
+// Sink receives events in order. Returning an error stops delivery to this
+// sink; the relay retries the same event on its next pass.
+type Sink interface {
+	Deliver(ctx context.Context, e events.Event) error
+}
+
+// SinkFunc adapts an in-process subscriber to a Sink.
+type SinkFunc func(ctx context.Context, e events.Event) error
+
+func (f SinkFunc) Deliver(ctx context.Context, e events.Event) error {
+	return f(ctx, e)
+}
+
+// LogSink writes each event to the standard logger.
+type LogSink struct{}
+
+func (LogSink) Deliver(_ context.Context, e events.Event) error {
+	data, _ := json.Marshal(e.Data)
+	log.Printf("event %d %s customer=%d %s", e.ID, e.Type, e.CustomerID, data)
+	return nil
+}
+
+// FileSink appends each event as a JSON line to Path and syncs it to disk
+// before the offset moves on.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSink) Deliver(_ context.Context, e events.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTTPSink posts each event as JSON to URL. With a Secret the request is
+// signed like a webhook delivery.
+type HTTPSink struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (s *HTTPSink) Deliver(ctx context.Context, e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderID, strconv.FormatInt(e.ID, 10))
+	req.Header.Set(webhooks.HeaderEvent, e.Type)
+	if s.Secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(s.Secret, ts, body))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", s.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package outbox implements the transactional outbox. Services record
+// domain events through a Writer inside the same database transaction as
+// the change; a Relay later reads them in ID order and delivers them to
+// each configured Sink, remembering per sink how far it has got. Delivery
+// is at least once: a sink may see an event again after a crash or a
+// failed offset commit, and should use Event.ID to de-duplicate.
+package outbox
+
+import (
+	"encoding/json"
+
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Writer is the events.Recorder backed by the outbox table.
+type Writer struct {
+	repo repositories.OutboxRepository
+}
+
+func NewWriter(repo repositories.OutboxRepository) *Writer {
+	return &Writer{repo: repo}
+}
+
+func (w *Writer) Record(tx *gorm.DB, e events.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return w.repo.WithTx(tx).Append(&models.OutboxEvent{
+		Type:       e.Type,
+		CustomerID: e.CustomerID,
+		Data:       string(data),
+		OccurredAt: e.OccurredAt,
+	})
+}
+
+func toEvent(row *models.OutboxEvent) (events.Event, error) {
+	e := events.Event{
+		ID:         row.ID,
+		Type:       row.Type,
+		CustomerID: row.CustomerID,
+		OccurredAt: row.OccurredAt,
+	}
+	if err := json.Unmarshal([]byte(row.Data), &e.Data); err != nil {
+		return e, err
+	}
+	return e, nil
+}
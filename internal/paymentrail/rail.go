@@ -0,0 +1,28 @@
+// Package paymentrail connects outbound payments to an external clearing
+// network. A Rail accepts payments and later reports their outcome through
+// an UpdateHandler, so the payment service never blocks on the network.
+package paymentrail
+
+import "github.com/Mahesh252k/banking-api/internal/models"
+
+// StatusUpdate reports a change to a payment previously accepted by a rail.
+// Status is one of models.PaymentStatusSettled or models.PaymentStatusReturned.
+type StatusUpdate struct {
+	RailReference string
+	Status        string
+	Reason        string
+}
+
+// UpdateHandler is called by a rail whenever a submitted payment changes status.
+type UpdateHandler func(update StatusUpdate) error
+
+type Rail interface {
+	// Name identifies the rail on stored payments.
+	Name() string
+	// Submit hands the payment to the rail under payment.RailReference,
+	// which updates for it carry. The reference is saved before Submit is
+	// called, and the same one is used if a submission is retried.
+	Submit(payment *models.OutgoingPayment, beneficiary *models.Beneficiary) error
+	// OnUpdate registers the handler that receives status changes.
+	OnUpdate(handler UpdateHandler)
+}
@@ -0,0 +1,59 @@
+package paymentrail
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// Simulator is a local Rail for development. Every payment settles after
+// SettleAfter, except payments to account numbers ending in "99", which are
+// returned with reason AC04 (closed account) so the return path can be
+// exercised without a real clearing bank.
+type Simulator struct {
+	SettleAfter time.Duration
+
+	mu      sync.RWMutex
+	handler UpdateHandler
+}
+
+func NewSimulator(settleAfter time.Duration) *Simulator {
+	return &Simulator{SettleAfter: settleAfter}
+}
+
+func (s *Simulator) Name() string {
+	return "simulator"
+}
+
+func (s *Simulator) OnUpdate(handler UpdateHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+func (s *Simulator) Submit(payment *models.OutgoingPayment, beneficiary *models.Beneficiary) error {
+	ref := payment.RailReference
+
+	update := StatusUpdate{RailReference: ref, Status: models.PaymentStatusSettled}
+	if strings.HasSuffix(beneficiary.AccountNumber, "99") {
+		update.Status = models.PaymentStatusReturned
+		update.Reason = "AC04 closed account number"
+	}
+
+	time.AfterFunc(s.SettleAfter, func() {
+		s.mu.RLock()
+		handler := s.handler
+		s.mu.RUnlock()
+
+		if handler == nil {
+			return
+		}
+		if err := handler(update); err != nil {
+			log.Printf("payment rail simulator: failed to apply %s for %s: %v", update.Status, ref, err)
+		}
+	})
+	return nil
+}
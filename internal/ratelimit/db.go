@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// DBStore keeps buckets in the database so every instance of the server
+// sees the same counts. Each request costs a short locking transaction.
+type DBStore struct {
+	db   *gorm.DB
+	repo repositories.ThrottleRepository
+}
+
+func NewDBStore(db *gorm.DB, repo repositories.ThrottleRepository) *DBStore {
+	return &DBStore{db: db, repo: repo}
+}
+
+func (s *DBStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		bucket, err := repo.GetBucketForUpdate(key, float64(limit.Burst), now)
+		if err != nil {
+			return err
+		}
+		bucket.Tokens, allowed, wait = take(bucket.Tokens, bucket.RefilledAt, limit, now)
+		// another instance's clock may be a little ahead
+		if now.After(bucket.RefilledAt) {
+			bucket.RefilledAt = now
+		}
+		return repo.SaveBucket(bucket)
+	})
+	return allowed, wait, err
+}
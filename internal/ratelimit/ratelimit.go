@@ -0,0 +1,100 @@
+// Package ratelimit throttles requests with token buckets. Buckets live in
+// a Store: in memory for a single instance, or in the database when
+// several instances share the load.
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limit allows Burst requests at once, refilled at Rate per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute is a limit of n requests a minute with bursts of burst.
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Store keeps buckets by key.
+type Store interface {
+	// Take removes a token from the key's bucket. When the bucket is empty
+	// it returns false and how long until a token is available.
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// take applies the token bucket to a level last set at refilledAt and
+// returns the new level and the outcome.
+func take(tokens float64, refilledAt time.Time, limit Limit, now time.Time) (float64, bool, time.Duration) {
+	elapsed := now.Sub(refilledAt).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, false, wait
+}
+
+// KeyFunc picks the bucket for a request, e.g. by client address.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP keys requests by client address.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// LimitFunc picks the bucket and limit for a request. An empty key, or a
+// limit that is not Enabled, lets the request through.
+type LimitFunc func(c *gin.Context) (string, Limit)
+
+// Middleware limits requests in a route group. name separates the
+// group's buckets from other groups'. Requests with an empty key are not
+// limited.
+func Middleware(store Store, name string, limit Limit, key KeyFunc) gin.HandlerFunc {
+	if !limit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return Dynamic(store, name, func(c *gin.Context) (string, Limit) {
+		return key(c), limit
+	})
+}
+
+// Dynamic is Middleware for limits that vary by caller. Requests over the
+// limit get 429 with Retry-After. If the store fails the request goes
+// through; an outage of the limiter should not take the API down with it.
+func Dynamic(store Store, name string, limitFor LimitFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, limit := limitFor(c)
+		if key == "" || !limit.Enabled() {
+			c.Next()
+			return
+		}
+		ok, wait, err := store.Take(name+":"+key, limit, time.Now())
+		if err != nil {
+			log.Printf("rate limit %s: %v", name, err)
+			c.Next()
+			return
+		}
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -3,13 +3,19 @@ package repositories
 import (
 	"github.com/Mahesh252k/banking-api/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AccountRepository interface {
 	Create(account *models.Account) error
 	GetByID(id int) (*models.Account, error)
 	UpdateBalance(account *models.Account) error
+	UpdateHeldAmount(account *models.Account) error
 	ListByCustomerID(customerID int) ([]models.Account, error)
+	// GetForUpdate loads the account with a row lock; use it inside WithTx.
+	GetForUpdate(id int) (*models.Account, error)
+	// WithTx returns a repository bound to the given database transaction.
+	WithTx(tx *gorm.DB) AccountRepository
 }
 
 type accountRepo struct {
@@ -36,6 +42,10 @@ func (r *accountRepo) UpdateBalance(account *models.Account) error {
 	return r.db.Model(account).Update("balance", account.Balance).Error
 }
 
+func (r *accountRepo) UpdateHeldAmount(account *models.Account) error {
+	return r.db.Model(account).Update("held_amount", account.HeldAmount).Error
+}
+
 func (r *accountRepo) ListByCustomerID(customerID int) ([]models.Account, error) {
 	var accounts []models.Account
 	if err := r.db.Preload("Customer").Preload("Branch").
@@ -45,3 +55,15 @@ func (r *accountRepo) ListByCustomerID(customerID int) ([]models.Account, error)
 	}
 	return accounts, nil
 }
+
+func (r *accountRepo) GetForUpdate(id int) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepo) WithTx(tx *gorm.DB) AccountRepository {
+	return &accountRepo{db: tx}
+}
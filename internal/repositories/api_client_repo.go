@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type APIClientRepository interface {
+	Create(client *models.APIClient) error
+	// Get, GetForUpdate and GetByClientID return nil if there is no such
+	// client. Scopes are filled in.
+	Get(id int) (*models.APIClient, error)
+	GetForUpdate(id int) (*models.APIClient, error)
+	GetByClientID(clientID string) (*models.APIClient, error)
+	List() ([]models.APIClient, error)
+	Update(client *models.APIClient) error
+	// MarkTokenIssued records when the client last got a token without
+	// touching the rest of the row.
+	MarkTokenIssued(id int, at time.Time) error
+	// SetScopes replaces the client's scopes.
+	SetScopes(id int, scopes []string) error
+
+	WithTx(tx *gorm.DB) APIClientRepository
+}
+
+type apiClientRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIClientRepo(db *gorm.DB) APIClientRepository {
+	return &apiClientRepo{db: db}
+}
+
+func (r *apiClientRepo) Create(client *models.APIClient) error {
+	return r.db.Create(client).Error
+}
+
+func (r *apiClientRepo) first(q *gorm.DB) (*models.APIClient, error) {
+	var client models.APIClient
+	if err := q.First(&client).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.fillScopes([]*models.APIClient{&client}); err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *apiClientRepo) Get(id int) (*models.APIClient, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *apiClientRepo) GetForUpdate(id int) (*models.APIClient, error) {
+	return r.first(r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id))
+}
+
+func (r *apiClientRepo) GetByClientID(clientID string) (*models.APIClient, error) {
+	return r.first(r.db.Where("client_id = ?", clientID))
+}
+
+func (r *apiClientRepo) List() ([]models.APIClient, error) {
+	var clients []models.APIClient
+	if err := r.db.Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	ptrs := make([]*models.APIClient, len(clients))
+	for i := range clients {
+		ptrs[i] = &clients[i]
+	}
+	return clients, r.fillScopes(ptrs)
+}
+
+func (r *apiClientRepo) fillScopes(clients []*models.APIClient) error {
+	if len(clients) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.APIClient, len(clients))
+	ids := make([]int, len(clients))
+	for i, client := range clients {
+		client.Scopes = []string{}
+		byID[client.ID] = client
+		ids[i] = client.ID
+	}
+	var rows []models.APIClientScope
+	if err := r.db.Where("client_id IN ?", ids).Order("scope").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		byID[row.ClientID].Scopes = append(byID[row.ClientID].Scopes, row.Scope)
+	}
+	return nil
+}
+
+func (r *apiClientRepo) Update(client *models.APIClient) error {
+	return r.db.Save(client).Error
+}
+
+func (r *apiClientRepo) MarkTokenIssued(id int, at time.Time) error {
+	return r.db.Model(&models.APIClient{}).Where("id = ?", id).Update("last_token_at", at).Error
+}
+
+func (r *apiClientRepo) SetScopes(id int, scopes []string) error {
+	if err := r.db.Where("client_id = ?", id).Delete(&models.APIClientScope{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.APIClientScope, len(scopes))
+	for i, scope := range scopes {
+		rows[i] = models.APIClientScope{ClientID: id, Scope: scope}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *apiClientRepo) WithTx(tx *gorm.DB) APIClientRepository {
+	return &apiClientRepo{db: tx}
+}
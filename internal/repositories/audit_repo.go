@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditRepository only appends and reads; the audit log is never updated
+// or deleted through the application.
+type AuditRepository interface {
+	Append(entry *models.AuditLog) error
+	// List returns matching entries, newest first.
+	List(q *models.AuditQuery) ([]models.AuditLog, error)
+
+	WithTx(tx *gorm.DB) AuditRepository
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Append(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *auditRepo) List(q *models.AuditQuery) ([]models.AuditLog, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if q.ActorType != "" {
+		query = query.Where("actor_type = ?", q.ActorType)
+	}
+	if q.ActorID != 0 {
+		query = query.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+	if q.EntityType != "" {
+		query = query.Where("entity_type = ?", q.EntityType)
+	}
+	if q.EntityID != "" {
+		query = query.Where("entity_id = ?", q.EntityID)
+	}
+	if q.RequestID != "" {
+		query = query.Where("request_id = ?", q.RequestID)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created_at < ?", q.To)
+	}
+	if q.BeforeID != 0 {
+		query = query.Where("id < ?", q.BeforeID)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *auditRepo) WithTx(tx *gorm.DB) AuditRepository {
+	return &auditRepo{db: tx}
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BeneficiaryRepository interface {
+	Create(beneficiary *models.Beneficiary) error
+	GetByID(id int) (*models.Beneficiary, error)
+	// GetForUpdate is GetByID with a row lock, for use inside WithTx.
+	GetForUpdate(id int) (*models.Beneficiary, error)
+	ListByCustomerID(customerID int) ([]models.Beneficiary, error)
+	FindByAccount(customerID int, accountNumber, bankCode string) (*models.Beneficiary, error)
+	UpdateNickname(id int, nickname string) error
+	Delete(id int) error
+	// ListAfter pages through every customer's beneficiaries in id order.
+	ListAfter(afterID, limit int) ([]models.Beneficiary, error)
+	UpdateScreeningStatus(id int, status string) error
+
+	WithTx(tx *gorm.DB) BeneficiaryRepository
+}
+
+type beneficiaryRepo struct {
+	db *gorm.DB
+}
+
+func NewBeneficiaryRepo(db *gorm.DB) BeneficiaryRepository {
+	return &beneficiaryRepo{db: db}
+}
+
+func (r *beneficiaryRepo) Create(beneficiary *models.Beneficiary) error {
+	return r.db.Create(beneficiary).Error
+}
+
+func (r *beneficiaryRepo) GetByID(id int) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	if err := r.db.First(&beneficiary, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+func (r *beneficiaryRepo) ListByCustomerID(customerID int) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	if err := r.db.Where("customer_id = ?", customerID).Order("name").Find(&beneficiaries).Error; err != nil {
+		return nil, err
+	}
+	return beneficiaries, nil
+}
+
+func (r *beneficiaryRepo) FindByAccount(customerID int, accountNumber, bankCode string) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	err := r.db.Where("customer_id = ? AND account_number = ? AND bank_code = ?", customerID, accountNumber, bankCode).
+		First(&beneficiary).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+func (r *beneficiaryRepo) GetForUpdate(id int) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&beneficiary, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+func (r *beneficiaryRepo) UpdateNickname(id int, nickname string) error {
+	return r.db.Model(&models.Beneficiary{}).Where("id = ?", id).Update("nickname", nickname).Error
+}
+
+func (r *beneficiaryRepo) Delete(id int) error {
+	return r.db.Delete(&models.Beneficiary{}, id).Error
+}
+
+func (r *beneficiaryRepo) ListAfter(afterID, limit int) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	err := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&beneficiaries).Error
+	return beneficiaries, err
+}
+
+func (r *beneficiaryRepo) UpdateScreeningStatus(id int, status string) error {
+	return r.db.Model(&models.Beneficiary{}).Where("id = ?", id).Update("screening_status", status).Error
+}
+
+func (r *beneficiaryRepo) WithTx(tx *gorm.DB) BeneficiaryRepository {
+	return &beneficiaryRepo{db: tx}
+}
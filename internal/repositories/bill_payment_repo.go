@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BillPaymentRepository interface {
+	Create(payment *models.BillPayment) error
+	GetByID(id int) (*models.BillPayment, error)
+	GetForUpdate(id int) (*models.BillPayment, error)
+	GetByConnectorReference(ref string) (*models.BillPayment, error)
+	ListByCustomerID(customerID int) ([]models.BillPayment, error)
+	ListDueScheduled(now time.Time, limit int) ([]models.BillPayment, error)
+	// ListStuck lists payments in processing that were never handed to the
+	// biller and have not been claimed since staleBefore.
+	ListStuck(staleBefore time.Time, limit int) ([]models.BillPayment, error)
+	// TransitionStatus moves a payment from one status to another and
+	// reports whether this caller won the change.
+	TransitionStatus(id int, from, to string) (bool, error)
+	// RecordSubmission stores when the connector accepted the payment
+	// without touching the status, which its answer may already have
+	// changed.
+	RecordSubmission(id int, submittedAt time.Time) error
+	Update(payment *models.BillPayment) error
+	WithTx(tx *gorm.DB) BillPaymentRepository
+}
+
+type billPaymentRepo struct {
+	db *gorm.DB
+}
+
+func NewBillPaymentRepo(db *gorm.DB) BillPaymentRepository {
+	return &billPaymentRepo{db: db}
+}
+
+func (r *billPaymentRepo) Create(payment *models.BillPayment) error {
+	return r.db.Omit(clause.Associations).Create(payment).Error
+}
+
+func (r *billPaymentRepo) GetByID(id int) (*models.BillPayment, error) {
+	var payment models.BillPayment
+	if err := withRegistration(r.db).First(&payment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *billPaymentRepo) GetForUpdate(id int) (*models.BillPayment, error) {
+	var payment models.BillPayment
+	err := withRegistration(r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *billPaymentRepo) GetByConnectorReference(ref string) (*models.BillPayment, error) {
+	var payment models.BillPayment
+	if err := withRegistration(r.db).Where("connector_reference = ?", ref).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *billPaymentRepo) ListByCustomerID(customerID int) ([]models.BillPayment, error) {
+	var payments []models.BillPayment
+	if err := withRegistration(r.db).Where("customer_id = ?", customerID).
+		Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *billPaymentRepo) ListDueScheduled(now time.Time, limit int) ([]models.BillPayment, error) {
+	var payments []models.BillPayment
+	if err := withRegistration(r.db).
+		Where("status = ? AND scheduled_for <= ?", models.BillPaymentScheduled, now).
+		Order("scheduled_for").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *billPaymentRepo) ListStuck(staleBefore time.Time, limit int) ([]models.BillPayment, error) {
+	var payments []models.BillPayment
+	if err := r.db.
+		Where("status = ? AND submitted_at IS NULL AND COALESCE(claimed_at, created_at) < ?", models.BillPaymentProcessing, staleBefore).
+		Order("id").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *billPaymentRepo) TransitionStatus(id int, from, to string) (bool, error) {
+	res := r.db.Model(&models.BillPayment{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *billPaymentRepo) RecordSubmission(id int, submittedAt time.Time) error {
+	return r.db.Model(&models.BillPayment{}).Where("id = ?", id).Update("submitted_at", submittedAt).Error
+}
+
+func (r *billPaymentRepo) Update(payment *models.BillPayment) error {
+	return r.db.Omit(clause.Associations).Save(payment).Error
+}
+
+func (r *billPaymentRepo) WithTx(tx *gorm.DB) BillPaymentRepository {
+	return &billPaymentRepo{db: tx}
+}
+
+// withRegistration preloads the registration and biller of bill payments,
+// including registrations the customer has since deleted.
+func withRegistration(db *gorm.DB) *gorm.DB {
+	return db.Preload("Registration", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Registration.Biller")
+}
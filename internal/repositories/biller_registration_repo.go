@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type BillerRegistrationRepository interface {
+	Create(registration *models.BillerRegistration) error
+	GetByID(id int) (*models.BillerRegistration, error)
+	// Find also returns deleted registrations, so they can be restored.
+	Find(customerID, billerID int, consumerNumber string) (*models.BillerRegistration, error)
+	Restore(registration *models.BillerRegistration) error
+	ListByCustomerID(customerID int) ([]models.BillerRegistration, error)
+	Delete(id int) error
+}
+
+type billerRegistrationRepo struct {
+	db *gorm.DB
+}
+
+func NewBillerRegistrationRepo(db *gorm.DB) BillerRegistrationRepository {
+	return &billerRegistrationRepo{db: db}
+}
+
+func (r *billerRegistrationRepo) Create(registration *models.BillerRegistration) error {
+	return r.db.Create(registration).Error
+}
+
+func (r *billerRegistrationRepo) GetByID(id int) (*models.BillerRegistration, error) {
+	var registration models.BillerRegistration
+	if err := r.db.Preload("Biller").First(&registration, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &registration, nil
+}
+
+func (r *billerRegistrationRepo) Find(customerID, billerID int, consumerNumber string) (*models.BillerRegistration, error) {
+	var registration models.BillerRegistration
+	err := r.db.Unscoped().Where("customer_id = ? AND biller_id = ? AND consumer_number = ?", customerID, billerID, consumerNumber).
+		First(&registration).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &registration, nil
+}
+
+func (r *billerRegistrationRepo) Restore(registration *models.BillerRegistration) error {
+	return r.db.Unscoped().Model(registration).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"nickname":   registration.Nickname,
+	}).Error
+}
+
+func (r *billerRegistrationRepo) ListByCustomerID(customerID int) ([]models.BillerRegistration, error) {
+	var registrations []models.BillerRegistration
+	if err := r.db.Preload("Biller").Where("customer_id = ?", customerID).Find(&registrations).Error; err != nil {
+		return nil, err
+	}
+	return registrations, nil
+}
+
+func (r *billerRegistrationRepo) Delete(id int) error {
+	return r.db.Delete(&models.BillerRegistration{}, id).Error
+}
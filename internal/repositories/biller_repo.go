@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type BillerRepository interface {
+	Create(biller *models.Biller) error
+	GetByID(id int) (*models.Biller, error)
+	GetByCode(code string) (*models.Biller, error)
+	List(activeOnly bool) ([]models.Biller, error)
+	Update(biller *models.Biller) error
+}
+
+type billerRepo struct {
+	db *gorm.DB
+}
+
+func NewBillerRepo(db *gorm.DB) BillerRepository {
+	return &billerRepo{db: db}
+}
+
+func (r *billerRepo) Create(biller *models.Biller) error {
+	return r.db.Create(biller).Error
+}
+
+func (r *billerRepo) GetByID(id int) (*models.Biller, error) {
+	var biller models.Biller
+	if err := r.db.First(&biller, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &biller, nil
+}
+
+func (r *billerRepo) GetByCode(code string) (*models.Biller, error) {
+	var biller models.Biller
+	if err := r.db.Where("code = ?", code).First(&biller).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &biller, nil
+}
+
+func (r *billerRepo) List(activeOnly bool) ([]models.Biller, error) {
+	var billers []models.Biller
+	q := r.db.Order("category, name")
+	if activeOnly {
+		q = q.Where("active = ?", true)
+	}
+	if err := q.Find(&billers).Error; err != nil {
+		return nil, err
+	}
+	return billers, nil
+}
+
+func (r *billerRepo) Update(biller *models.Biller) error {
+	return r.db.Save(biller).Error
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BulkTransferRepository interface {
+	CreateBatch(batch *models.BulkTransferBatch) error
+	GetBatch(id int) (*models.BulkTransferBatch, error)
+	// ListStaleBatchIDs lists processing batches not claimed since
+	// staleBefore.
+	ListStaleBatchIDs(staleBefore time.Time) ([]int, error)
+	// ClaimBatch claims a processing batch not claimed since staleBefore,
+	// reporting whether it did.
+	ClaimBatch(id int, staleBefore, now time.Time) (bool, error)
+	// TouchBatch renews the claim on a batch.
+	TouchBatch(id int, now time.Time) error
+	ListPendingItems(batchID int) ([]models.BulkTransferItem, error)
+	GetItemForUpdate(id int) (*models.BulkTransferItem, error)
+	UpdateItem(item *models.BulkTransferItem) error
+	UpdateBatch(batch *models.BulkTransferBatch) error
+	WithTx(tx *gorm.DB) BulkTransferRepository
+}
+
+type bulkTransferRepo struct {
+	db *gorm.DB
+}
+
+func NewBulkTransferRepo(db *gorm.DB) BulkTransferRepository {
+	return &bulkTransferRepo{db: db}
+}
+
+// CreateBatch inserts the batch together with its items.
+func (r *bulkTransferRepo) CreateBatch(batch *models.BulkTransferBatch) error {
+	return r.db.Create(batch).Error
+}
+
+func (r *bulkTransferRepo) GetBatch(id int) (*models.BulkTransferBatch, error) {
+	var batch models.BulkTransferBatch
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line_number")
+	}).First(&batch, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *bulkTransferRepo) ListStaleBatchIDs(staleBefore time.Time) ([]int, error) {
+	var ids []int
+	if err := r.db.Model(&models.BulkTransferBatch{}).
+		Where("status = ? AND COALESCE(claimed_at, created_at) < ?", models.BulkBatchProcessing, staleBefore).
+		Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *bulkTransferRepo) ClaimBatch(id int, staleBefore, now time.Time) (bool, error) {
+	res := r.db.Model(&models.BulkTransferBatch{}).
+		Where("id = ? AND status = ? AND COALESCE(claimed_at, created_at) < ?", id, models.BulkBatchProcessing, staleBefore).
+		Update("claimed_at", now)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *bulkTransferRepo) TouchBatch(id int, now time.Time) error {
+	return r.db.Model(&models.BulkTransferBatch{}).Where("id = ?", id).Update("claimed_at", now).Error
+}
+
+func (r *bulkTransferRepo) ListPendingItems(batchID int) ([]models.BulkTransferItem, error) {
+	var items []models.BulkTransferItem
+	if err := r.db.Where("batch_id = ? AND status = ?", batchID, models.BulkItemPending).
+		Order("line_number").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *bulkTransferRepo) GetItemForUpdate(id int) (*models.BulkTransferItem, error) {
+	var item models.BulkTransferItem
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *bulkTransferRepo) UpdateItem(item *models.BulkTransferItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *bulkTransferRepo) UpdateBatch(batch *models.BulkTransferBatch) error {
+	return r.db.Omit(clause.Associations).Save(batch).Error
+}
+
+func (r *bulkTransferRepo) WithTx(tx *gorm.DB) BulkTransferRepository {
+	return &bulkTransferRepo{db: tx}
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CardAuthorizationRepository interface {
+	Create(auth *models.CardAuthorization) error
+	GetByID(id int) (*models.CardAuthorization, error)
+	GetForUpdate(id int) (*models.CardAuthorization, error)
+	GetByNetworkReference(ref string) (*models.CardAuthorization, error)
+	ListByCardID(cardID int) ([]models.CardAuthorization, error)
+	// SpentSince sums what the card has authorized or captured since the given time.
+	SpentSince(cardID int, since time.Time) (float64, error)
+	Update(auth *models.CardAuthorization) error
+	WithTx(tx *gorm.DB) CardAuthorizationRepository
+}
+
+type cardAuthorizationRepo struct {
+	db *gorm.DB
+}
+
+func NewCardAuthorizationRepo(db *gorm.DB) CardAuthorizationRepository {
+	return &cardAuthorizationRepo{db: db}
+}
+
+func (r *cardAuthorizationRepo) Create(auth *models.CardAuthorization) error {
+	return r.db.Create(auth).Error
+}
+
+func (r *cardAuthorizationRepo) GetByID(id int) (*models.CardAuthorization, error) {
+	var auth models.CardAuthorization
+	if err := r.db.First(&auth, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &auth, nil
+}
+
+func (r *cardAuthorizationRepo) GetForUpdate(id int) (*models.CardAuthorization, error) {
+	var auth models.CardAuthorization
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&auth, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &auth, nil
+}
+
+func (r *cardAuthorizationRepo) GetByNetworkReference(ref string) (*models.CardAuthorization, error) {
+	var auth models.CardAuthorization
+	if err := r.db.Where("network_reference = ?", ref).First(&auth).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &auth, nil
+}
+
+func (r *cardAuthorizationRepo) ListByCardID(cardID int) ([]models.CardAuthorization, error) {
+	var auths []models.CardAuthorization
+	if err := r.db.Where("card_id = ?", cardID).Order("created_at DESC").Limit(100).Find(&auths).Error; err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+func (r *cardAuthorizationRepo) SpentSince(cardID int, since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.CardAuthorization{}).
+		Select("COALESCE(SUM(CASE WHEN status = ? THEN captured_amount ELSE amount END), 0)", models.CardAuthCaptured).
+		Where("card_id = ? AND created_at >= ? AND status IN ?", cardID, since,
+			[]string{models.CardAuthApproved, models.CardAuthCaptured}).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *cardAuthorizationRepo) Update(auth *models.CardAuthorization) error {
+	return r.db.Save(auth).Error
+}
+
+func (r *cardAuthorizationRepo) WithTx(tx *gorm.DB) CardAuthorizationRepository {
+	return &cardAuthorizationRepo{db: tx}
+}
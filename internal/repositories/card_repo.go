@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type CardRepository interface {
+	Create(card *models.Card) error
+	GetByID(id int) (*models.Card, error)
+	GetByPANHash(panHash string) (*models.Card, error)
+	ListByCustomerID(customerID int) ([]models.Card, error)
+	Update(card *models.Card) error
+	// ExpireDue marks cards whose expiry month has ended as expired.
+	ExpireDue(now time.Time) (int64, error)
+}
+
+type cardRepo struct {
+	db *gorm.DB
+}
+
+func NewCardRepo(db *gorm.DB) CardRepository {
+	return &cardRepo{db: db}
+}
+
+func (r *cardRepo) Create(card *models.Card) error {
+	return r.db.Create(card).Error
+}
+
+func (r *cardRepo) GetByID(id int) (*models.Card, error) {
+	var card models.Card
+	if err := r.db.First(&card, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *cardRepo) GetByPANHash(panHash string) (*models.Card, error) {
+	var card models.Card
+	if err := r.db.Where("pan_hash = ?", panHash).First(&card).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *cardRepo) ListByCustomerID(customerID int) ([]models.Card, error) {
+	var cards []models.Card
+	if err := r.db.Where("customer_id = ?", customerID).Order("id").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *cardRepo) Update(card *models.Card) error {
+	return r.db.Save(card).Error
+}
+
+func (r *cardRepo) ExpireDue(now time.Time) (int64, error) {
+	year, month := now.Year(), int(now.Month())
+	res := r.db.Model(&models.Card{}).
+		Where("status <> ?", models.CardStatusExpired).
+		Where("expiry_year < ? OR (expiry_year = ? AND expiry_month < ?)", year, year, month).
+		Update("status", models.CardStatusExpired)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type ClearingFileRepository interface {
+	Create(file *models.ClearingFile) error
+	GetByMessageID(messageID string) (*models.ClearingFile, error)
+	WithTx(tx *gorm.DB) ClearingFileRepository
+}
+
+type clearingFileRepo struct {
+	db *gorm.DB
+}
+
+func NewClearingFileRepo(db *gorm.DB) ClearingFileRepository {
+	return &clearingFileRepo{db: db}
+}
+
+func (r *clearingFileRepo) Create(file *models.ClearingFile) error {
+	return r.db.Create(file).Error
+}
+
+func (r *clearingFileRepo) GetByMessageID(messageID string) (*models.ClearingFile, error) {
+	var file models.ClearingFile
+	if err := r.db.Where("message_id = ?", messageID).First(&file).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *clearingFileRepo) WithTx(tx *gorm.DB) ClearingFileRepository {
+	return &clearingFileRepo{db: tx}
+}
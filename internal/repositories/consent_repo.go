@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConsentRepository interface {
+	Create(consent *models.Consent) error
+	// Get and GetForUpdate return nil if there is no such consent.
+	// Permissions and accounts are filled in.
+	Get(id string) (*models.Consent, error)
+	GetForUpdate(id string) (*models.Consent, error)
+	ListByCustomer(customerID int) ([]models.Consent, error)
+	Update(consent *models.Consent) error
+	SetPermissions(id string, permissions []string) error
+	// SetAccounts replaces the accounts the consent covers.
+	SetAccounts(id string, accountIDs []int) error
+	// ExpireDue marks consents past their expiry that are still pending or
+	// authorized as expired.
+	ExpireDue(now time.Time) (int64, error)
+
+	WithTx(tx *gorm.DB) ConsentRepository
+}
+
+type consentRepo struct {
+	db *gorm.DB
+}
+
+func NewConsentRepo(db *gorm.DB) ConsentRepository {
+	return &consentRepo{db: db}
+}
+
+func (r *consentRepo) Create(consent *models.Consent) error {
+	return r.db.Create(consent).Error
+}
+
+func (r *consentRepo) first(q *gorm.DB) (*models.Consent, error) {
+	var consent models.Consent
+	if err := q.First(&consent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.fill([]*models.Consent{&consent}); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *consentRepo) Get(id string) (*models.Consent, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *consentRepo) GetForUpdate(id string) (*models.Consent, error) {
+	return r.first(r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id))
+}
+
+func (r *consentRepo) ListByCustomer(customerID int) ([]models.Consent, error) {
+	var consents []models.Consent
+	if err := r.db.Where("customer_id = ?", customerID).Order("created_at DESC").Find(&consents).Error; err != nil {
+		return nil, err
+	}
+	ptrs := make([]*models.Consent, len(consents))
+	for i := range consents {
+		ptrs[i] = &consents[i]
+	}
+	return consents, r.fill(ptrs)
+}
+
+// fill loads the permissions and accounts of the given consents.
+func (r *consentRepo) fill(consents []*models.Consent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Consent, len(consents))
+	ids := make([]string, len(consents))
+	for i, consent := range consents {
+		consent.Permissions = []string{}
+		consent.AccountIDs = []int{}
+		byID[consent.ID] = consent
+		ids[i] = consent.ID
+	}
+
+	var permissions []models.ConsentPermission
+	if err := r.db.Where("consent_id IN ?", ids).Order("permission").Find(&permissions).Error; err != nil {
+		return err
+	}
+	for _, row := range permissions {
+		byID[row.ConsentID].Permissions = append(byID[row.ConsentID].Permissions, row.Permission)
+	}
+
+	var accounts []models.ConsentAccount
+	if err := r.db.Where("consent_id IN ?", ids).Order("account_id").Find(&accounts).Error; err != nil {
+		return err
+	}
+	for _, row := range accounts {
+		byID[row.ConsentID].AccountIDs = append(byID[row.ConsentID].AccountIDs, row.AccountID)
+	}
+	return nil
+}
+
+func (r *consentRepo) Update(consent *models.Consent) error {
+	return r.db.Save(consent).Error
+}
+
+func (r *consentRepo) SetPermissions(id string, permissions []string) error {
+	if err := r.db.Where("consent_id = ?", id).Delete(&models.ConsentPermission{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.ConsentPermission, len(permissions))
+	for i, p := range permissions {
+		rows[i] = models.ConsentPermission{ConsentID: id, Permission: p}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *consentRepo) SetAccounts(id string, accountIDs []int) error {
+	if err := r.db.Where("consent_id = ?", id).Delete(&models.ConsentAccount{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.ConsentAccount, len(accountIDs))
+	for i, accountID := range accountIDs {
+		rows[i] = models.ConsentAccount{ConsentID: id, AccountID: accountID}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *consentRepo) ExpireDue(now time.Time) (int64, error) {
+	res := r.db.Model(&models.Consent{}).
+		Where("status IN ? AND expires_at <= ?", []string{models.ConsentAwaitingAuthorization, models.ConsentAuthorized}, now).
+		Update("status", models.ConsentExpired)
+	return res.RowsAffected, res.Error
+}
+
+func (r *consentRepo) WithTx(tx *gorm.DB) ConsentRepository {
+	return &consentRepo{db: tx}
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomerRepository interface {
+	// GetByID returns nil if there is no such customer.
+	GetByID(id int) (*models.Customer, error)
+	// GetForUpdate loads the customer with a row lock; use it inside WithTx.
+	GetForUpdate(id int) (*models.Customer, error)
+	// GetByEmail returns nil if no customer has the address.
+	GetByEmail(email string) (*models.Customer, error)
+	// GetByLogin finds a customer by username or email address, or
+	// returns nil.
+	GetByLogin(login string) (*models.Customer, error)
+	// Update saves the named columns of the customer.
+	Update(customer *models.Customer, fields ...string) error
+	// ListAfter pages through all customers in id order.
+	ListAfter(afterID, limit int) ([]models.Customer, error)
+	UpdateScreeningStatus(id int, status string) error
+
+	WithTx(tx *gorm.DB) CustomerRepository
+}
+
+type customerRepo struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepo(db *gorm.DB) CustomerRepository {
+	return &customerRepo{db: db}
+}
+
+func (r *customerRepo) GetByID(id int) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.First(&customer, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepo) GetForUpdate(id int) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepo) GetByEmail(email string) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Where("email = ?", email).First(&customer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepo) GetByLogin(login string) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Where("username = ? OR email = ?", login, login).First(&customer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepo) Update(customer *models.Customer, fields ...string) error {
+	return r.db.Model(customer).Select(fields).Updates(customer).Error
+}
+
+func (r *customerRepo) ListAfter(afterID, limit int) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&customers).Error
+	return customers, err
+}
+
+func (r *customerRepo) UpdateScreeningStatus(id int, status string) error {
+	return r.db.Model(&models.Customer{}).Where("id = ?", id).Update("screening_status", status).Error
+}
+
+func (r *customerRepo) WithTx(tx *gorm.DB) CustomerRepository {
+	return &customerRepo{db: tx}
+}
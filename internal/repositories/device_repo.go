@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type DeviceRepository interface {
+	Create(device *models.CustomerDevice) error
+	Find(customerID int, fingerprint string) (*models.CustomerDevice, error)
+	CountByCustomerID(customerID int) (int64, error)
+	Update(device *models.CustomerDevice) error
+	WithTx(tx *gorm.DB) DeviceRepository
+}
+
+type deviceRepo struct {
+	db *gorm.DB
+}
+
+func NewDeviceRepo(db *gorm.DB) DeviceRepository {
+	return &deviceRepo{db: db}
+}
+
+func (r *deviceRepo) Create(device *models.CustomerDevice) error {
+	return r.db.Create(device).Error
+}
+
+func (r *deviceRepo) Find(customerID int, fingerprint string) (*models.CustomerDevice, error) {
+	var device models.CustomerDevice
+	if err := r.db.Where("customer_id = ? AND fingerprint = ?", customerID, fingerprint).First(&device).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *deviceRepo) CountByCustomerID(customerID int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CustomerDevice{}).Where("customer_id = ?", customerID).Count(&count).Error
+	return count, err
+}
+
+func (r *deviceRepo) Update(device *models.CustomerDevice) error {
+	return r.db.Save(device).Error
+}
+
+func (r *deviceRepo) WithTx(tx *gorm.DB) DeviceRepository {
+	return &deviceRepo{db: tx}
+}
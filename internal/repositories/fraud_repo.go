@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FraudRepository interface {
+	CreateRule(rule *models.FraudRule) error
+	GetRule(id int) (*models.FraudRule, error)
+	GetRuleByName(name string) (*models.FraudRule, error)
+	ListRules() ([]models.FraudRule, error)
+	ListActiveRules() ([]models.FraudRule, error)
+	UpdateRule(rule *models.FraudRule) error
+	CountRules() (int64, error)
+
+	CreateHeldTransfer(held *models.HeldTransfer) error
+	GetHeldTransfer(id int) (*models.HeldTransfer, error)
+	// GetHeldTransferForUpdate loads the held transfer with a row lock; use
+	// it inside WithTx.
+	GetHeldTransferForUpdate(id int) (*models.HeldTransfer, error)
+	// ListHeldTransfers returns held transfers oldest first, all of them
+	// when status is empty.
+	ListHeldTransfers(status string, limit int) ([]models.HeldTransfer, error)
+	UpdateHeldTransfer(held *models.HeldTransfer) error
+
+	WithTx(tx *gorm.DB) FraudRepository
+}
+
+type fraudRepo struct {
+	db *gorm.DB
+}
+
+func NewFraudRepo(db *gorm.DB) FraudRepository {
+	return &fraudRepo{db: db}
+}
+
+func (r *fraudRepo) CreateRule(rule *models.FraudRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *fraudRepo) GetRule(id int) (*models.FraudRule, error) {
+	var rule models.FraudRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *fraudRepo) GetRuleByName(name string) (*models.FraudRule, error) {
+	var rule models.FraudRule
+	if err := r.db.Where("name = ?", name).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *fraudRepo) ListRules() ([]models.FraudRule, error) {
+	var rules []models.FraudRule
+	err := r.db.Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *fraudRepo) ListActiveRules() ([]models.FraudRule, error) {
+	var rules []models.FraudRule
+	err := r.db.Where("active = ?", true).Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *fraudRepo) UpdateRule(rule *models.FraudRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *fraudRepo) CountRules() (int64, error) {
+	var n int64
+	err := r.db.Model(&models.FraudRule{}).Count(&n).Error
+	return n, err
+}
+
+func (r *fraudRepo) CreateHeldTransfer(held *models.HeldTransfer) error {
+	return r.db.Create(held).Error
+}
+
+func (r *fraudRepo) GetHeldTransfer(id int) (*models.HeldTransfer, error) {
+	var held models.HeldTransfer
+	if err := r.db.First(&held, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &held, nil
+}
+
+func (r *fraudRepo) GetHeldTransferForUpdate(id int) (*models.HeldTransfer, error) {
+	var held models.HeldTransfer
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&held, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &held, nil
+}
+
+func (r *fraudRepo) ListHeldTransfers(status string, limit int) ([]models.HeldTransfer, error) {
+	query := r.db.Order("id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var held []models.HeldTransfer
+	err := query.Limit(limit).Find(&held).Error
+	return held, err
+}
+
+func (r *fraudRepo) UpdateHeldTransfer(held *models.HeldTransfer) error {
+	return r.db.Save(held).Error
+}
+
+func (r *fraudRepo) WithTx(tx *gorm.DB) FraudRepository {
+	return &fraudRepo{db: tx}
+}
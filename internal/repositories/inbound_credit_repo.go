@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InboundCreditRepository interface {
+	Create(credit *models.InboundCredit) error
+	ExistsByReference(reference string) (bool, error)
+	GetForUpdate(id int) (*models.InboundCredit, error)
+	ListByStatus(status string) ([]models.InboundCredit, error)
+	Update(credit *models.InboundCredit) error
+	WithTx(tx *gorm.DB) InboundCreditRepository
+}
+
+type inboundCreditRepo struct {
+	db *gorm.DB
+}
+
+func NewInboundCreditRepo(db *gorm.DB) InboundCreditRepository {
+	return &inboundCreditRepo{db: db}
+}
+
+func (r *inboundCreditRepo) Create(credit *models.InboundCredit) error {
+	return r.db.Create(credit).Error
+}
+
+func (r *inboundCreditRepo) ExistsByReference(reference string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.InboundCredit{}).Where("reference = ?", reference).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *inboundCreditRepo) GetForUpdate(id int) (*models.InboundCredit, error) {
+	var credit models.InboundCredit
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&credit, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credit, nil
+}
+
+func (r *inboundCreditRepo) ListByStatus(status string) ([]models.InboundCredit, error) {
+	var credits []models.InboundCredit
+	if err := r.db.Where("status = ?", status).Order("id").Find(&credits).Error; err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+func (r *inboundCreditRepo) Update(credit *models.InboundCredit) error {
+	return r.db.Save(credit).Error
+}
+
+func (r *inboundCreditRepo) WithTx(tx *gorm.DB) InboundCreditRepository {
+	return &inboundCreditRepo{db: tx}
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type JournalCheckpointRepository interface {
+	Create(cp *models.JournalCheckpoint) error
+	// Latest returns the newest checkpoint, or nil if there is none.
+	Latest() (*models.JournalCheckpoint, error)
+	// List returns checkpoints newest first; a limit of 0 returns all.
+	List(limit int) ([]models.JournalCheckpoint, error)
+}
+
+type journalCheckpointRepo struct {
+	db *gorm.DB
+}
+
+func NewJournalCheckpointRepo(db *gorm.DB) JournalCheckpointRepository {
+	return &journalCheckpointRepo{db: db}
+}
+
+func (r *journalCheckpointRepo) Create(cp *models.JournalCheckpoint) error {
+	return r.db.Create(cp).Error
+}
+
+func (r *journalCheckpointRepo) Latest() (*models.JournalCheckpoint, error) {
+	var cp models.JournalCheckpoint
+	if err := r.db.Order("sequence DESC, id DESC").First(&cp).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (r *journalCheckpointRepo) List(limit int) ([]models.JournalCheckpoint, error) {
+	query := r.db.Order("sequence DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var cps []models.JournalCheckpoint
+	err := query.Find(&cps).Error
+	return cps, err
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KYCRepository interface {
+	CreateDocument(doc *models.KYCDocument) error
+	GetDocument(id int) (*models.KYCDocument, error)
+	ListDocuments(customerID int) ([]models.KYCDocument, error)
+	// ReviewPendingDocuments sets the outcome on every pending document of
+	// the customer.
+	ReviewPendingDocuments(customerID int, status, note string, reviewedBy int, at time.Time) error
+
+	// GetCustomerForUpdate loads the customer with a row lock; use it
+	// inside WithTx.
+	GetCustomerForUpdate(id int) (*models.Customer, error)
+	// ListAwaitingReview returns customers with documents pending review,
+	// longest waiting first.
+	ListAwaitingReview(limit int) ([]models.Customer, error)
+	// ListCustomers returns customers in the given verification state,
+	// oldest first.
+	ListCustomers(status string, limit int) ([]models.Customer, error)
+	// UpdateCustomer saves the customer's KYC columns.
+	UpdateCustomer(customer *models.Customer) error
+	// ListExpiring returns verified customers whose verification lapses
+	// before the given time and who have not been reminded.
+	ListExpiring(before time.Time) ([]models.Customer, error)
+	// ListLapsed returns verified customers whose verification lapsed
+	// before now.
+	ListLapsed(now time.Time) ([]models.Customer, error)
+	// MarkReminded reports false if another worker already did.
+	MarkReminded(id int, at time.Time) (bool, error)
+
+	WithTx(tx *gorm.DB) KYCRepository
+}
+
+type kycRepo struct {
+	db *gorm.DB
+}
+
+func NewKYCRepo(db *gorm.DB) KYCRepository {
+	return &kycRepo{db: db}
+}
+
+func (r *kycRepo) CreateDocument(doc *models.KYCDocument) error {
+	return r.db.Create(doc).Error
+}
+
+func (r *kycRepo) GetDocument(id int) (*models.KYCDocument, error) {
+	var doc models.KYCDocument
+	if err := r.db.First(&doc, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *kycRepo) ListDocuments(customerID int) ([]models.KYCDocument, error) {
+	var docs []models.KYCDocument
+	err := r.db.Where("customer_id = ?", customerID).Order("id").Find(&docs).Error
+	return docs, err
+}
+
+func (r *kycRepo) ReviewPendingDocuments(customerID int, status, note string, reviewedBy int, at time.Time) error {
+	return r.db.Model(&models.KYCDocument{}).
+		Where("customer_id = ? AND status = ?", customerID, models.KYCDocumentPending).
+		Updates(map[string]any{"status": status, "review_note": note, "reviewed_by": reviewedBy, "reviewed_at": at}).Error
+}
+
+func (r *kycRepo) GetCustomerForUpdate(id int) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *kycRepo) ListAwaitingReview(limit int) ([]models.Customer, error) {
+	waiting := r.db.Model(&models.KYCDocument{}).
+		Select("customer_id, MIN(id) AS first_id").
+		Where("status = ?", models.KYCDocumentPending).
+		Group("customer_id")
+	var customers []models.Customer
+	err := r.db.Joins("JOIN (?) AS waiting ON waiting.customer_id = customers.id", waiting).
+		Order("waiting.first_id").
+		Limit(limit).
+		Find(&customers).Error
+	return customers, err
+}
+
+func (r *kycRepo) ListCustomers(status string, limit int) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.db.Where("kyc_status = ?", status).Order("id").Limit(limit).Find(&customers).Error
+	return customers, err
+}
+
+func (r *kycRepo) UpdateCustomer(customer *models.Customer) error {
+	return r.db.Model(customer).Select("KYCStatus", "KYCVerifiedAt", "KYCExpiresAt", "KYCRemindedAt").Updates(customer).Error
+}
+
+func (r *kycRepo) ListExpiring(before time.Time) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.db.
+		Where("kyc_status = ? AND kyc_expires_at < ? AND kyc_reminded_at IS NULL", models.KYCVerified, before).
+		Order("kyc_expires_at").
+		Limit(500).
+		Find(&customers).Error
+	return customers, err
+}
+
+func (r *kycRepo) ListLapsed(now time.Time) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.db.
+		Where("kyc_status = ? AND kyc_expires_at < ?", models.KYCVerified, now).
+		Order("kyc_expires_at").
+		Limit(500).
+		Find(&customers).Error
+	return customers, err
+}
+
+func (r *kycRepo) MarkReminded(id int, at time.Time) (bool, error) {
+	res := r.db.Model(&models.Customer{}).
+		Where("id = ? AND kyc_reminded_at IS NULL", id).
+		Update("kyc_reminded_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *kycRepo) WithTx(tx *gorm.DB) KYCRepository {
+	return &kycRepo{db: tx}
+}
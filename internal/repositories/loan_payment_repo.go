@@ -12,6 +12,17 @@ type LoanPaymentRepository interface {
 	GetByID(id int) (*models.LoanPayment, error)
 	ListByLoanID(loanID int) ([]models.LoanPayment, error)
 	UpdateStatus(id int, status string, paidDate time.Time) error
+	// ListDueForReminder returns unpaid EMIs due before the given time
+	// that have not been reminded about, with their loans.
+	ListDueForReminder(before time.Time) ([]models.LoanPayment, error)
+	// ListOverdueUnnotified returns unpaid EMIs past due at now that have
+	// had no overdue notice, with their loans.
+	ListOverdueUnnotified(now time.Time) ([]models.LoanPayment, error)
+	// MarkReminderSent and MarkOverdueNotified report false if another
+	// worker already did.
+	MarkReminderSent(id int, at time.Time) (bool, error)
+	MarkOverdueNotified(id int, at time.Time) (bool, error)
+	WithTx(tx *gorm.DB) LoanPaymentRepository
 }
 
 func (r *loanPaymentRepo) ListByLoan(loanID int) ([]models.LoanPayment, error) {
@@ -59,3 +70,41 @@ func (r *loanPaymentRepo) UpdateStatus(id int, status string, paidDate time.Time
 		"paid_date": paidDate,
 	}).Error
 }
+
+func (r *loanPaymentRepo) ListDueForReminder(before time.Time) ([]models.LoanPayment, error) {
+	var payments []models.LoanPayment
+	err := r.db.Preload("Loan").
+		Where("status <> ? AND due_date < ? AND reminder_sent_at IS NULL", "paid", before).
+		Order("due_date").
+		Limit(500).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *loanPaymentRepo) ListOverdueUnnotified(now time.Time) ([]models.LoanPayment, error) {
+	var payments []models.LoanPayment
+	err := r.db.Preload("Loan").
+		Where("status <> ? AND due_date < ? AND overdue_notice_at IS NULL", "paid", now).
+		Order("due_date").
+		Limit(500).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *loanPaymentRepo) MarkReminderSent(id int, at time.Time) (bool, error) {
+	res := r.db.Model(&models.LoanPayment{}).
+		Where("id = ? AND reminder_sent_at IS NULL", id).
+		Update("reminder_sent_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *loanPaymentRepo) MarkOverdueNotified(id int, at time.Time) (bool, error) {
+	res := r.db.Model(&models.LoanPayment{}).
+		Where("id = ? AND overdue_notice_at IS NULL", id).
+		Update("overdue_notice_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *loanPaymentRepo) WithTx(tx *gorm.DB) LoanPaymentRepository {
+	return &loanPaymentRepo{db: tx}
+}
@@ -10,6 +10,7 @@ type LoanRepository interface {
 	GetByID(id int) (*models.Loan, error)
 	ListByCustomerID(customerID int) ([]models.Loan, error)
 	UpdateStatus(id int, status string) error
+	WithTx(tx *gorm.DB) LoanRepository
 }
 
 type loanRepo struct {
@@ -46,3 +47,7 @@ func (r *loanRepo) ListByCustomerID(customerID int) ([]models.Loan, error) {
 func (r *loanRepo) UpdateStatus(id int, status string) error {
 	return r.db.Model(&models.Loan{}).Where("id = ?", id).Update("status", status).Error
 }
+
+func (r *loanRepo) WithTx(tx *gorm.DB) LoanRepository {
+	return &loanRepo{db: tx}
+}
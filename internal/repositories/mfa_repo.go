@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository interface {
+	// GetFactor returns nil if the customer has no authenticator, confirmed
+	// or not.
+	GetFactor(customerID int) (*models.TOTPFactor, error)
+	// GetFactorForUpdate loads the factor with a row lock; use it inside
+	// WithTx.
+	GetFactorForUpdate(customerID int) (*models.TOTPFactor, error)
+	SaveFactor(factor *models.TOTPFactor) error
+	DeleteFactor(customerID int) error
+
+	// ReplaceRecoveryCodes discards the customer's codes and stores new
+	// ones.
+	ReplaceRecoveryCodes(customerID int, hashes []string) error
+	// UseRecoveryCode marks an unused code as used and reports whether
+	// there was one.
+	UseRecoveryCode(customerID int, hash string, at time.Time) (bool, error)
+	CountUnusedRecoveryCodes(customerID int) (int64, error)
+
+	WithTx(tx *gorm.DB) MFARepository
+}
+
+type mfaRepo struct {
+	db *gorm.DB
+}
+
+func NewMFARepo(db *gorm.DB) MFARepository {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) GetFactor(customerID int) (*models.TOTPFactor, error) {
+	var factor models.TOTPFactor
+	if err := r.db.Where("customer_id = ?", customerID).First(&factor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &factor, nil
+}
+
+func (r *mfaRepo) GetFactorForUpdate(customerID int) (*models.TOTPFactor, error) {
+	var factor models.TOTPFactor
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("customer_id = ?", customerID).First(&factor).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &factor, nil
+}
+
+func (r *mfaRepo) SaveFactor(factor *models.TOTPFactor) error {
+	return r.db.Save(factor).Error
+}
+
+func (r *mfaRepo) DeleteFactor(customerID int) error {
+	return r.db.Where("customer_id = ?", customerID).Delete(&models.TOTPFactor{}).Error
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(customerID int, hashes []string) error {
+	if err := r.db.Where("customer_id = ?", customerID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	codes := make([]models.RecoveryCode, len(hashes))
+	for i, h := range hashes {
+		codes[i] = models.RecoveryCode{CustomerID: customerID, CodeHash: h}
+	}
+	return r.db.Create(&codes).Error
+}
+
+func (r *mfaRepo) UseRecoveryCode(customerID int, hash string, at time.Time) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("customer_id = ? AND code_hash = ? AND used_at IS NULL", customerID, hash).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *mfaRepo) CountUnusedRecoveryCodes(customerID int) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("customer_id = ? AND used_at IS NULL", customerID).Count(&n).Error
+	return n, err
+}
+
+func (r *mfaRepo) WithTx(tx *gorm.DB) MFARepository {
+	return &mfaRepo{db: tx}
+}
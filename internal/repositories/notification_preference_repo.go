@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type NotificationPreferenceRepository interface {
+	// Get returns nil if the customer has not saved preferences.
+	Get(customerID int) (*models.NotificationPreference, error)
+	Save(pref *models.NotificationPreference) error
+}
+
+type notificationPreferenceRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepo(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepo{db: db}
+}
+
+func (r *notificationPreferenceRepo) Get(customerID int) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	if err := r.db.First(&pref, "customer_id = ?", customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pref, nil
+}
+
+func (r *notificationPreferenceRepo) Save(pref *models.NotificationPreference) error {
+	return r.db.Save(pref).Error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(n *models.Notification) error
+	ListByCustomerID(customerID int, unreadOnly bool) ([]models.Notification, error)
+	// MarkRead reports false if the notification is not the customer's.
+	MarkRead(id, customerID int, at time.Time) (bool, error)
+}
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepo(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) Create(n *models.Notification) error {
+	return r.db.Create(n).Error
+}
+
+func (r *notificationRepo) ListByCustomerID(customerID int, unreadOnly bool) ([]models.Notification, error) {
+	var notifications []models.Notification
+	q := r.db.Where("customer_id = ?", customerID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	err := q.Order("id DESC").Limit(100).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepo) MarkRead(id, customerID int, at time.Time) (bool, error) {
+	var n models.Notification
+	if err := r.db.Where("id = ? AND customer_id = ?", id, customerID).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if n.ReadAt != nil {
+		return true, nil
+	}
+	return true, r.db.Model(&n).Update("read_at", at).Error
+}
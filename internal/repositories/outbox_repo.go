@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Append(event *models.OutboxEvent) error
+	// ListAfter returns events with IDs above afterID, oldest first.
+	ListAfter(afterID int64, limit int) ([]models.OutboxEvent, error)
+
+	// AcquireLease creates the consumer if needed and gives owner its lease
+	// until the given time, unless another owner holds an unexpired one.
+	AcquireLease(consumer, owner string, now, until time.Time) (*models.OutboxConsumer, bool, error)
+	// CommitOffset records that consumer has handled everything up to
+	// eventID, provided owner still holds the lease.
+	CommitOffset(consumer, owner string, eventID int64) (bool, error)
+	ListConsumers() ([]models.OutboxConsumer, error)
+	// Prune deletes events older than before that every consumer active
+	// since then has handled. Consumers idle since before are ignored so a
+	// retired sink does not pin the table forever.
+	Prune(before time.Time) (int64, error)
+
+	WithTx(tx *gorm.DB) OutboxRepository
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) OutboxRepository {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) Append(event *models.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *outboxRepo) ListAfter(afterID int64, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepo) AcquireLease(consumer, owner string, now, until time.Time) (*models.OutboxConsumer, bool, error) {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxConsumer{Name: consumer}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	res := r.db.Model(&models.OutboxConsumer{}).
+		Where("name = ? AND (lease_owner = ? OR lease_owner = '' OR lease_until < ?)", consumer, owner, now).
+		Updates(map[string]any{"lease_owner": owner, "lease_until": until})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, false, res.Error
+	}
+
+	var c models.OutboxConsumer
+	if err := r.db.First(&c, "name = ?", consumer).Error; err != nil {
+		return nil, false, err
+	}
+	return &c, true, nil
+}
+
+func (r *outboxRepo) CommitOffset(consumer, owner string, eventID int64) (bool, error) {
+	res := r.db.Model(&models.OutboxConsumer{}).
+		Where("name = ? AND lease_owner = ?", consumer, owner).
+		Update("last_event_id", eventID)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *outboxRepo) ListConsumers() ([]models.OutboxConsumer, error) {
+	var consumers []models.OutboxConsumer
+	err := r.db.Order("name").Find(&consumers).Error
+	return consumers, err
+}
+
+func (r *outboxRepo) Prune(before time.Time) (int64, error) {
+	var minOffset *int64
+	if err := r.db.Model(&models.OutboxConsumer{}).Where("updated_at >= ?", before).
+		Select("MIN(last_event_id)").Scan(&minOffset).Error; err != nil {
+		return 0, err
+	}
+	if minOffset == nil {
+		return 0, nil
+	}
+	res := r.db.Where("id <= ? AND created_at < ?", *minOffset, before).Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *outboxRepo) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepo{db: tx}
+}
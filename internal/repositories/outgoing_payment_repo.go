@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutgoingPaymentRepository interface {
+	Create(payment *models.OutgoingPayment) error
+	GetByID(id int) (*models.OutgoingPayment, error)
+	GetByRailReference(railReference string) (*models.OutgoingPayment, error)
+	GetForUpdate(id int) (*models.OutgoingPayment, error)
+	ListByAccountID(accountID int) ([]models.OutgoingPayment, error)
+	ListByStatus(status string, limit int) ([]models.OutgoingPayment, error)
+	// ListSubmittable lists initiated payments and those left submitting
+	// since before staleBefore, oldest first.
+	ListSubmittable(staleBefore time.Time, limit int) ([]models.OutgoingPayment, error)
+	// LockByStatus is ListByStatus with row locks, for use inside WithTx.
+	LockByStatus(status string, limit int) ([]models.OutgoingPayment, error)
+	ListByClearingFileID(fileID int) ([]models.OutgoingPayment, error)
+	// CountOpenByBeneficiary counts the beneficiary's payments in one of
+	// models.OpenPaymentStatuses.
+	CountOpenByBeneficiary(beneficiaryID int) (int64, error)
+	// KeepStatusUpdate stores a rail update to apply later, leaving
+	// updated_at alone.
+	KeepStatusUpdate(id int, status, reason string) error
+	// ListPendingStatus lists payments with a kept rail update, oldest
+	// first.
+	ListPendingStatus(limit int) ([]models.OutgoingPayment, error)
+	Update(payment *models.OutgoingPayment) error
+	WithTx(tx *gorm.DB) OutgoingPaymentRepository
+}
+
+type outgoingPaymentRepo struct {
+	db *gorm.DB
+}
+
+func NewOutgoingPaymentRepo(db *gorm.DB) OutgoingPaymentRepository {
+	return &outgoingPaymentRepo{db: db}
+}
+
+func (r *outgoingPaymentRepo) Create(payment *models.OutgoingPayment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *outgoingPaymentRepo) GetByID(id int) (*models.OutgoingPayment, error) {
+	var payment models.OutgoingPayment
+	if err := r.db.Preload("Beneficiary").First(&payment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *outgoingPaymentRepo) GetByRailReference(railReference string) (*models.OutgoingPayment, error) {
+	var payment models.OutgoingPayment
+	if err := r.db.Where("rail_reference = ?", railReference).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *outgoingPaymentRepo) GetForUpdate(id int) (*models.OutgoingPayment, error) {
+	var payment models.OutgoingPayment
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *outgoingPaymentRepo) ListByAccountID(accountID int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Preload("Beneficiary").Where("account_id = ?", accountID).
+		Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) ListByStatus(status string, limit int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Preload("Beneficiary").Preload("Account").Where("status = ?", status).
+		Order("id").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) ListSubmittable(staleBefore time.Time, limit int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Where("status = ? OR (status = ? AND updated_at < ?)",
+		models.PaymentStatusInitiated, models.PaymentStatusSubmitting, staleBefore).
+		Order("id").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) LockByStatus(status string, limit int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Beneficiary").Preload("Account").Where("status = ?", status).
+		Order("id").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) CountOpenByBeneficiary(beneficiaryID int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OutgoingPayment{}).
+		Where("beneficiary_id = ? AND status IN ?", beneficiaryID, models.OpenPaymentStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *outgoingPaymentRepo) ListByClearingFileID(fileID int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Where("clearing_file_id = ?", fileID).Order("id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) KeepStatusUpdate(id int, status, reason string) error {
+	return r.db.Model(&models.OutgoingPayment{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"pending_status": status, "pending_reason": reason}).Error
+}
+
+func (r *outgoingPaymentRepo) ListPendingStatus(limit int) ([]models.OutgoingPayment, error) {
+	var payments []models.OutgoingPayment
+	if err := r.db.Where("pending_status <> ''").Order("id").Limit(limit).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *outgoingPaymentRepo) Update(payment *models.OutgoingPayment) error {
+	return r.db.Omit(clause.Associations).Save(payment).Error
+}
+
+func (r *outgoingPaymentRepo) WithTx(tx *gorm.DB) OutgoingPaymentRepository {
+	return &outgoingPaymentRepo{db: tx}
+}
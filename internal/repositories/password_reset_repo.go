@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	// GetByHashForUpdate loads the token with a row lock; use it inside
+	// WithTx. It returns nil if there is no such token.
+	GetByHashForUpdate(hash string) (*models.PasswordResetToken, error)
+	// UseAll marks every unused token of the customer used, so that only
+	// the newest one sent, or none after a reset, works.
+	UseAll(customerID int, at time.Time) error
+
+	WithTx(tx *gorm.DB) PasswordResetRepository
+}
+
+type passwordResetRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepo(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepo{db: db}
+}
+
+func (r *passwordResetRepo) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepo) GetByHashForUpdate(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepo) UseAll(customerID int, at time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("customer_id = ? AND used_at IS NULL", customerID).
+		Update("used_at", at).Error
+}
+
+func (r *passwordResetRepo) WithTx(tx *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepo{db: tx}
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProfileRepository interface {
+	CreateContactChange(change *models.ContactChange) error
+	// GetContactChangeForUpdate loads the change with a row lock; use it
+	// inside WithTx.
+	GetContactChangeForUpdate(id int) (*models.ContactChange, error)
+	UpdateContactChange(change *models.ContactChange) error
+	// CancelPendingContactChanges cancels the customer's pending changes on
+	// one channel.
+	CancelPendingContactChanges(customerID int, channel string) error
+
+	AddChanges(changes []models.ProfileChange) error
+	// ListChanges returns the customer's profile history, newest first.
+	ListChanges(customerID, limit int) ([]models.ProfileChange, error)
+
+	WithTx(tx *gorm.DB) ProfileRepository
+}
+
+type profileRepo struct {
+	db *gorm.DB
+}
+
+func NewProfileRepo(db *gorm.DB) ProfileRepository {
+	return &profileRepo{db: db}
+}
+
+func (r *profileRepo) CreateContactChange(change *models.ContactChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *profileRepo) GetContactChangeForUpdate(id int) (*models.ContactChange, error) {
+	var change models.ContactChange
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&change, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *profileRepo) UpdateContactChange(change *models.ContactChange) error {
+	return r.db.Save(change).Error
+}
+
+func (r *profileRepo) CancelPendingContactChanges(customerID int, channel string) error {
+	return r.db.Model(&models.ContactChange{}).
+		Where("customer_id = ? AND channel = ? AND status = ?", customerID, channel, models.ContactChangePending).
+		Update("status", models.ContactChangeCancelled).Error
+}
+
+func (r *profileRepo) AddChanges(changes []models.ProfileChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return r.db.Create(&changes).Error
+}
+
+func (r *profileRepo) ListChanges(customerID, limit int) ([]models.ProfileChange, error) {
+	var changes []models.ProfileChange
+	err := r.db.Where("customer_id = ?", customerID).Order("id DESC").Limit(limit).Find(&changes).Error
+	return changes, err
+}
+
+func (r *profileRepo) WithTx(tx *gorm.DB) ProfileRepository {
+	return &profileRepo{db: tx}
+}
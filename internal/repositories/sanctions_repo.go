@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SanctionsRepository interface {
+	// CreateHit inserts the hit unless the subject was already raised for
+	// the same entry and name, and reports whether it was inserted.
+	CreateHit(hit *models.SanctionsHit) (bool, error)
+	GetHit(id int) (*models.SanctionsHit, error)
+	// GetHitForUpdate loads the hit with a row lock; use it inside WithTx.
+	GetHitForUpdate(id int) (*models.SanctionsHit, error)
+	// ListHits returns hits oldest first, all of them when status is empty.
+	ListHits(status string, limit int) ([]models.SanctionsHit, error)
+	UpdateHit(hit *models.SanctionsHit) error
+	CountPendingHits(subjectType string, subjectID int) (int64, error)
+
+	GetList(version string) (*models.SanctionsList, error)
+	SaveList(list *models.SanctionsList) error
+	MarkListScreened(version string, at time.Time) error
+
+	WithTx(tx *gorm.DB) SanctionsRepository
+}
+
+type sanctionsRepo struct {
+	db *gorm.DB
+}
+
+func NewSanctionsRepo(db *gorm.DB) SanctionsRepository {
+	return &sanctionsRepo{db: db}
+}
+
+func (r *sanctionsRepo) CreateHit(hit *models.SanctionsHit) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(hit)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sanctionsRepo) GetHit(id int) (*models.SanctionsHit, error) {
+	var hit models.SanctionsHit
+	if err := r.db.First(&hit, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hit, nil
+}
+
+func (r *sanctionsRepo) GetHitForUpdate(id int) (*models.SanctionsHit, error) {
+	var hit models.SanctionsHit
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hit, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hit, nil
+}
+
+func (r *sanctionsRepo) ListHits(status string, limit int) ([]models.SanctionsHit, error) {
+	query := r.db.Order("id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var hits []models.SanctionsHit
+	err := query.Limit(limit).Find(&hits).Error
+	return hits, err
+}
+
+func (r *sanctionsRepo) UpdateHit(hit *models.SanctionsHit) error {
+	return r.db.Save(hit).Error
+}
+
+func (r *sanctionsRepo) CountPendingHits(subjectType string, subjectID int) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.SanctionsHit{}).
+		Where("subject_type = ? AND subject_id = ? AND status = ?", subjectType, subjectID, models.SanctionsHitPending).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *sanctionsRepo) GetList(version string) (*models.SanctionsList, error) {
+	var list models.SanctionsList
+	if err := r.db.Where("version = ?", version).First(&list).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *sanctionsRepo) SaveList(list *models.SanctionsList) error {
+	return r.db.Save(list).Error
+}
+
+func (r *sanctionsRepo) MarkListScreened(version string, at time.Time) error {
+	return r.db.Model(&models.SanctionsList{}).Where("version = ?", version).Update("screened_at", at).Error
+}
+
+func (r *sanctionsRepo) WithTx(tx *gorm.DB) SanctionsRepository {
+	return &sanctionsRepo{db: tx}
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	// Get returns nil if there is no such session.
+	Get(id string) (*models.Session, error)
+	// GetForUpdate loads the session with a row lock; use it inside WithTx.
+	GetForUpdate(id string) (*models.Session, error)
+	Update(session *models.Session) error
+	// ListActive returns the customer's live sessions, most recent first.
+	ListActive(customerID int, now time.Time) ([]models.Session, error)
+	// RevokeAll revokes every live session of the customer.
+	RevokeAll(customerID int, reason string, at time.Time) error
+
+	CreateRefreshToken(token *models.RefreshToken) error
+	// GetRefreshTokenForUpdate loads the token by hash with a row lock, or
+	// returns nil.
+	GetRefreshTokenForUpdate(hash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(id int, at time.Time) error
+	// Prune deletes sessions, and their refresh tokens, that expired or
+	// were revoked before the cutoff.
+	Prune(before time.Time) (int64, error)
+
+	WithTx(tx *gorm.DB) SessionRepository
+}
+
+type sessionRepo struct {
+	db *gorm.DB
+}
+
+func NewSessionRepo(db *gorm.DB) SessionRepository {
+	return &sessionRepo{db: db}
+}
+
+func (r *sessionRepo) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepo) Get(id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepo) GetForUpdate(id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepo) Update(session *models.Session) error {
+	return r.db.Save(session).Error
+}
+
+func (r *sessionRepo) ListActive(customerID int, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("customer_id = ? AND revoked_at IS NULL AND expires_at > ?", customerID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepo) RevokeAll(customerID int, reason string, at time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("customer_id = ? AND revoked_at IS NULL", customerID).
+		Updates(map[string]any{"revoked_at": at, "revoke_reason": reason}).Error
+}
+
+func (r *sessionRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *sessionRepo) GetRefreshTokenForUpdate(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *sessionRepo) MarkRefreshTokenUsed(id int, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).Where("id = ?", id).Update("used_at", at).Error
+}
+
+func (r *sessionRepo) Prune(before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&models.Session{}).Select("id").
+			Where("expires_at < ? OR revoked_at < ?", before, before)
+		if err := tx.Where("session_id IN (?)", stale).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&models.Session{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
+
+func (r *sessionRepo) WithTx(tx *gorm.DB) SessionRepository {
+	return &sessionRepo{db: tx}
+}
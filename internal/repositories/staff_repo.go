@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StaffRepository interface {
+	Create(staff *models.StaffUser) error
+	// Get, GetForUpdate and GetByUsername return nil if there is no such
+	// staff user. Roles are filled in.
+	Get(id int) (*models.StaffUser, error)
+	GetForUpdate(id int) (*models.StaffUser, error)
+	GetByUsername(username string) (*models.StaffUser, error)
+	List() ([]models.StaffUser, error)
+	Update(staff *models.StaffUser) error
+	Count() (int64, error)
+	// SetRoles replaces the staff user's roles.
+	SetRoles(staffID int, roleIDs []int) error
+	// RevokeTokensForRole invalidates tokens of every staff user holding
+	// the role.
+	RevokeTokensForRole(roleID int, at time.Time) error
+
+	CreateRole(role *models.Role) error
+	// GetRole and GetRoleByName return nil if there is no such role.
+	// Permissions are filled in.
+	GetRole(id int) (*models.Role, error)
+	GetRoleByName(name string) (*models.Role, error)
+	ListRoles() ([]models.Role, error)
+	UpdateRole(role *models.Role) error
+	// SetPermissions replaces the role's permissions.
+	SetPermissions(roleID int, permissions []string) error
+	// Permissions returns the union of the permissions of the named roles.
+	Permissions(roles []string) ([]string, error)
+
+	WithTx(tx *gorm.DB) StaffRepository
+}
+
+type staffRepo struct {
+	db *gorm.DB
+}
+
+func NewStaffRepo(db *gorm.DB) StaffRepository {
+	return &staffRepo{db: db}
+}
+
+func (r *staffRepo) Create(staff *models.StaffUser) error {
+	return r.db.Create(staff).Error
+}
+
+func (r *staffRepo) first(q *gorm.DB) (*models.StaffUser, error) {
+	var staff models.StaffUser
+	if err := q.First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.fillRoles([]*models.StaffUser{&staff}); err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *staffRepo) Get(id int) (*models.StaffUser, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *staffRepo) GetForUpdate(id int) (*models.StaffUser, error) {
+	return r.first(r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id))
+}
+
+func (r *staffRepo) GetByUsername(username string) (*models.StaffUser, error) {
+	return r.first(r.db.Where("username = ?", username))
+}
+
+func (r *staffRepo) List() ([]models.StaffUser, error) {
+	var staff []models.StaffUser
+	if err := r.db.Order("username").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+	ptrs := make([]*models.StaffUser, len(staff))
+	for i := range staff {
+		ptrs[i] = &staff[i]
+	}
+	return staff, r.fillRoles(ptrs)
+}
+
+// fillRoles loads the role names of the given staff users.
+func (r *staffRepo) fillRoles(staff []*models.StaffUser) error {
+	if len(staff) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.StaffUser, len(staff))
+	ids := make([]int, len(staff))
+	for i, s := range staff {
+		s.Roles = []string{}
+		byID[s.ID] = s
+		ids[i] = s.ID
+	}
+	var rows []struct {
+		StaffID int
+		Name    string
+	}
+	err := r.db.Table("staff_roles").
+		Select("staff_roles.staff_id, roles.name").
+		Joins("JOIN roles ON roles.id = staff_roles.role_id").
+		Where("staff_roles.staff_id IN ?", ids).
+		Order("roles.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		byID[row.StaffID].Roles = append(byID[row.StaffID].Roles, row.Name)
+	}
+	return nil
+}
+
+func (r *staffRepo) Update(staff *models.StaffUser) error {
+	return r.db.Save(staff).Error
+}
+
+func (r *staffRepo) Count() (int64, error) {
+	var n int64
+	err := r.db.Model(&models.StaffUser{}).Count(&n).Error
+	return n, err
+}
+
+func (r *staffRepo) SetRoles(staffID int, roleIDs []int) error {
+	if err := r.db.Where("staff_id = ?", staffID).Delete(&models.StaffRole{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.StaffRole, len(roleIDs))
+	for i, id := range roleIDs {
+		rows[i] = models.StaffRole{StaffID: staffID, RoleID: id}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *staffRepo) RevokeTokensForRole(roleID int, at time.Time) error {
+	holders := r.db.Model(&models.StaffRole{}).Select("staff_id").Where("role_id = ?", roleID)
+	return r.db.Model(&models.StaffUser{}).Where("id IN (?)", holders).Update("tokens_revoked_at", at).Error
+}
+
+func (r *staffRepo) CreateRole(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *staffRepo) firstRole(q *gorm.DB) (*models.Role, error) {
+	var role models.Role
+	if err := q.First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.fillPermissions([]*models.Role{&role}); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *staffRepo) GetRole(id int) (*models.Role, error) {
+	return r.firstRole(r.db.Where("id = ?", id))
+}
+
+func (r *staffRepo) GetRoleByName(name string) (*models.Role, error) {
+	return r.firstRole(r.db.Where("name = ?", name))
+}
+
+func (r *staffRepo) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	ptrs := make([]*models.Role, len(roles))
+	for i := range roles {
+		ptrs[i] = &roles[i]
+	}
+	return roles, r.fillPermissions(ptrs)
+}
+
+func (r *staffRepo) fillPermissions(roles []*models.Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Role, len(roles))
+	ids := make([]int, len(roles))
+	for i, role := range roles {
+		role.Permissions = []string{}
+		byID[role.ID] = role
+		ids[i] = role.ID
+	}
+	var rows []models.RolePermission
+	if err := r.db.Where("role_id IN ?", ids).Order("permission").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		byID[row.RoleID].Permissions = append(byID[row.RoleID].Permissions, row.Permission)
+	}
+	return nil
+}
+
+func (r *staffRepo) UpdateRole(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+func (r *staffRepo) SetPermissions(roleID int, permissions []string) error {
+	if err := r.db.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.RolePermission, len(permissions))
+	for i, p := range permissions {
+		rows[i] = models.RolePermission{RoleID: roleID, Permission: p}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *staffRepo) Permissions(roles []string) ([]string, error) {
+	perms := []string{}
+	if len(roles) == 0 {
+		return perms, nil
+	}
+	err := r.db.Table("role_permissions").
+		Distinct("role_permissions.permission").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name IN ?", roles).
+		Pluck("role_permissions.permission", &perms).Error
+	sort.Strings(perms)
+	return perms, err
+}
+
+func (r *staffRepo) WithTx(tx *gorm.DB) StaffRepository {
+	return &staffRepo{db: tx}
+}
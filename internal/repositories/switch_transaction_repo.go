@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SwitchTransactionRepository interface {
+	Create(txn *models.SwitchTransaction) error
+	GetByTerminalRRN(terminalID, rrn string) (*models.SwitchTransaction, error)
+	// LockByTerminalRRN loads the record with a row lock; use it inside WithTx.
+	LockByTerminalRRN(terminalID, rrn string) (*models.SwitchTransaction, error)
+	Update(txn *models.SwitchTransaction) error
+	// SpentSince sums the card's approved withdrawals since the given
+	// time, leaving out reversed ones.
+	SpentSince(cardID int, since time.Time) (float64, error)
+	WithTx(tx *gorm.DB) SwitchTransactionRepository
+}
+
+type switchTransactionRepo struct {
+	db *gorm.DB
+}
+
+func NewSwitchTransactionRepo(db *gorm.DB) SwitchTransactionRepository {
+	return &switchTransactionRepo{db: db}
+}
+
+func (r *switchTransactionRepo) Create(txn *models.SwitchTransaction) error {
+	return r.db.Create(txn).Error
+}
+
+func (r *switchTransactionRepo) GetByTerminalRRN(terminalID, rrn string) (*models.SwitchTransaction, error) {
+	return r.find(r.db, terminalID, rrn)
+}
+
+func (r *switchTransactionRepo) LockByTerminalRRN(terminalID, rrn string) (*models.SwitchTransaction, error) {
+	return r.find(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), terminalID, rrn)
+}
+
+func (r *switchTransactionRepo) find(db *gorm.DB, terminalID, rrn string) (*models.SwitchTransaction, error) {
+	var txn models.SwitchTransaction
+	if err := db.Where("terminal_id = ? AND rrn = ?", terminalID, rrn).First(&txn).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *switchTransactionRepo) Update(txn *models.SwitchTransaction) error {
+	return r.db.Save(txn).Error
+}
+
+func (r *switchTransactionRepo) SpentSince(cardID int, since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.SwitchTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("card_id = ? AND created_at >= ? AND transaction_id IS NOT NULL AND reversed_at IS NULL", cardID, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *switchTransactionRepo) WithTx(tx *gorm.DB) SwitchTransactionRepository {
+	return &switchTransactionRepo{db: tx}
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ThrottleRepository interface {
+	// GetLogin returns nil if the key has no recorded failures.
+	GetLogin(key string) (*models.LoginThrottle, error)
+	// GetLoginForUpdate creates the row if needed and loads it with a row
+	// lock; use it inside WithTx.
+	GetLoginForUpdate(key string) (*models.LoginThrottle, error)
+	SaveLogin(throttle *models.LoginThrottle) error
+	DeleteLogin(key string) (bool, error)
+	// ListLocked returns keys locked at now, soonest unlock first.
+	ListLocked(now time.Time) ([]models.LoginThrottle, error)
+	// PruneLogins deletes rows with no failure since before and no lock
+	// still running.
+	PruneLogins(before, now time.Time) (int64, error)
+
+	// GetBucketForUpdate creates the bucket, full, if needed and loads it
+	// with a row lock; use it inside WithTx.
+	GetBucketForUpdate(key string, full float64, now time.Time) (*models.RateLimitBucket, error)
+	SaveBucket(bucket *models.RateLimitBucket) error
+	// PruneBuckets deletes buckets untouched since before.
+	PruneBuckets(before time.Time) (int64, error)
+
+	WithTx(tx *gorm.DB) ThrottleRepository
+}
+
+type throttleRepo struct {
+	db *gorm.DB
+}
+
+func NewThrottleRepo(db *gorm.DB) ThrottleRepository {
+	return &throttleRepo{db: db}
+}
+
+func (r *throttleRepo) GetLogin(key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := r.db.Where("`key` = ?", key).First(&throttle).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *throttleRepo) GetLoginForUpdate(key string) (*models.LoginThrottle, error) {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+		return nil, err
+	}
+	var throttle models.LoginThrottle
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("`key` = ?", key).First(&throttle).Error
+	return &throttle, err
+}
+
+func (r *throttleRepo) SaveLogin(throttle *models.LoginThrottle) error {
+	return r.db.Save(throttle).Error
+}
+
+func (r *throttleRepo) DeleteLogin(key string) (bool, error) {
+	res := r.db.Where("`key` = ?", key).Delete(&models.LoginThrottle{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *throttleRepo) ListLocked(now time.Time) ([]models.LoginThrottle, error) {
+	var locked []models.LoginThrottle
+	err := r.db.Where("locked_until > ?", now).Order("locked_until").Find(&locked).Error
+	return locked, err
+}
+
+func (r *throttleRepo) PruneLogins(before, now time.Time) (int64, error) {
+	res := r.db.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, now).
+		Delete(&models.LoginThrottle{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *throttleRepo) GetBucketForUpdate(key string, full float64, now time.Time) (*models.RateLimitBucket, error) {
+	fresh := &models.RateLimitBucket{Key: key, Tokens: full, RefilledAt: now}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(fresh).Error; err != nil {
+		return nil, err
+	}
+	var bucket models.RateLimitBucket
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("`key` = ?", key).First(&bucket).Error
+	return &bucket, err
+}
+
+func (r *throttleRepo) SaveBucket(bucket *models.RateLimitBucket) error {
+	return r.db.Save(bucket).Error
+}
+
+func (r *throttleRepo) PruneBuckets(before time.Time) (int64, error) {
+	res := r.db.Where("refilled_at < ?", before).Delete(&models.RateLimitBucket{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *throttleRepo) WithTx(tx *gorm.DB) ThrottleRepository {
+	return &throttleRepo{db: tx}
+}
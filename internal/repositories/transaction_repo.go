@@ -1,13 +1,47 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/journal"
 	"github.com/Mahesh252k/banking-api/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository interface {
+	// Create posts the transaction and appends it to the journal hash
+	// chain. Postings are serialized on the chain head until the caller's
+	// transaction commits.
 	Create(transaction *models.Transaction) error
+	// ListChain returns chained transactions after sequence afterSeq, in
+	// sequence order.
+	ListChain(afterSeq int64, limit int) ([]models.Transaction, error)
+	// ListUnsealed returns transactions posted before the chain existed,
+	// oldest first. An unchained row newer than the start of the chain has
+	// been tampered with and is never offered for sealing.
+	ListUnsealed(limit int) ([]models.Transaction, error)
+	CountUnsealed() (int64, error)
+	// Seal appends an existing unchained transaction to the chain.
+	Seal(transaction *models.Transaction) error
+	GetHead() (*models.JournalHead, error)
+
+	// Debits counts and sums money that left the account since the given
+	// time; Credits sums money that arrived.
+	Debits(accountID int, since time.Time) (int64, float64, error)
+	Credits(accountID int, since time.Time) (float64, error)
+	// HasPaid reports whether fromAccountID ever transferred to toAccountID.
+	HasPaid(fromAccountID, toAccountID int) (bool, error)
+	// HasPaidBeneficiary reports whether fromAccountID ever paid the
+	// external beneficiary.
+	HasPaidBeneficiary(fromAccountID, beneficiaryID int) (bool, error)
+	// CustomerDebits sums money that left any of the customer's accounts
+	// since the given time.
+	CustomerDebits(customerID int, since time.Time) (float64, error)
+
+	WithTx(tx *gorm.DB) TransactionRepository
 }
 type transactionRepo struct {
 	db *gorm.DB
@@ -18,5 +52,162 @@ func NewTransactionRepo(db *gorm.DB) TransactionRepository {
 }
 
 func (r *transactionRepo) Create(transaction *models.Transaction) error {
-	return r.db.Create(transaction).Error
+	if transaction.CreatedAt.IsZero() {
+		// the hash covers whole seconds; truncating here stops the database
+		// rounding the stored time up into the next second
+		transaction.CreatedAt = time.Now().Truncate(time.Second)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		return link(tx, transaction)
+	})
+}
+
+func (r *transactionRepo) Seal(transaction *models.Transaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := link(tx, transaction)
+		if errors.Is(err, errAlreadyLinked) {
+			// sealed by another run in the meantime
+			return nil
+		}
+		return err
+	})
+}
+
+var errAlreadyLinked = errors.New("transaction is already in the journal")
+
+// link gives a stored transaction the next sequence and its hash, and
+// moves the chain head to it.
+func link(tx *gorm.DB, transaction *models.Transaction) error {
+	head, err := lockHead(tx)
+	if err != nil {
+		return err
+	}
+
+	seq := head.Sequence + 1
+	transaction.Sequence = &seq
+	transaction.PrevHash = head.Hash
+	transaction.Hash = journal.Hash(head.Hash, transaction)
+
+	res := tx.Model(&models.Transaction{}).Where("id = ? AND sequence IS NULL", transaction.ID).Updates(map[string]any{
+		"sequence":  seq,
+		"prev_hash": transaction.PrevHash,
+		"hash":      transaction.Hash,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errAlreadyLinked
+	}
+
+	head.Sequence = seq
+	head.Hash = transaction.Hash
+	return tx.Save(head).Error
+}
+
+func lockHead(tx *gorm.DB) (*models.JournalHead, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.JournalHead{ID: 1, Hash: journal.Genesis}).Error
+	if err != nil {
+		return nil, err
+	}
+	var head models.JournalHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, 1).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+func (r *transactionRepo) ListChain(afterSeq int64, limit int) ([]models.Transaction, error) {
+	var txns []models.Transaction
+	err := r.db.Where("sequence > ?", afterSeq).Order("sequence").Limit(limit).Find(&txns).Error
+	return txns, err
+}
+
+func (r *transactionRepo) ListUnsealed(limit int) ([]models.Transaction, error) {
+	query := r.db.Where("sequence IS NULL")
+
+	var first models.Transaction
+	err := r.db.Where("sequence IS NOT NULL").Order("id").Limit(1).Find(&first).Error
+	if err != nil {
+		return nil, err
+	}
+	if first.ID != 0 {
+		query = query.Where("id < ?", first.ID)
+	}
+
+	var txns []models.Transaction
+	err = query.Order("id").Limit(limit).Find(&txns).Error
+	return txns, err
+}
+
+func (r *transactionRepo) CountUnsealed() (int64, error) {
+	var n int64
+	err := r.db.Model(&models.Transaction{}).Where("sequence IS NULL").Count(&n).Error
+	return n, err
+}
+
+func (r *transactionRepo) GetHead() (*models.JournalHead, error) {
+	var head models.JournalHead
+	if err := r.db.First(&head, 1).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.JournalHead{Hash: journal.Genesis}, nil
+		}
+		return nil, err
+	}
+	return &head, nil
+}
+
+func (r *transactionRepo) Debits(accountID int, since time.Time) (int64, float64, error) {
+	var row struct {
+		Count int64
+		Total float64
+	}
+	err := r.db.Model(&models.Transaction{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS total").
+		Where("from_account_id = ? AND created_at >= ?", accountID, since).
+		Scan(&row).Error
+	return row.Count, row.Total, err
+}
+
+func (r *transactionRepo) Credits(accountID int, since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("to_account_id = ? AND created_at >= ?", accountID, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *transactionRepo) HasPaid(fromAccountID, toAccountID int) (bool, error) {
+	var n int64
+	err := r.db.Model(&models.Transaction{}).
+		Where("from_account_id = ? AND to_account_id = ?", fromAccountID, toAccountID).
+		Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *transactionRepo) HasPaidBeneficiary(fromAccountID, beneficiaryID int) (bool, error) {
+	var n int64
+	err := r.db.Model(&models.Transaction{}).
+		Where("from_account_id = ? AND beneficiary_id = ?", fromAccountID, beneficiaryID).
+		Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *transactionRepo) CustomerDebits(customerID int, since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("from_account_id IN (?) AND created_at >= ?",
+			r.db.Model(&models.Account{}).Select("id").Where("customer_id = ?", customerID), since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *transactionRepo) WithTx(tx *gorm.DB) TransactionRepository {
+	return &transactionRepo{db: tx}
 }
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	GetSubscription(id int) (*models.WebhookSubscription, error)
+	ListSubscriptionsByCustomerID(customerID int) ([]models.WebhookSubscription, error)
+	// ListActiveSubscriptions returns the customer's active subscriptions.
+	ListActiveSubscriptions(customerID int) ([]models.WebhookSubscription, error)
+	UpdateSubscription(sub *models.WebhookSubscription) error
+	DeleteSubscription(id int) error
+
+	// CreateDelivery ignores a second delivery of the same event to the
+	// same subscription.
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	GetDelivery(id int) (*models.WebhookDelivery, error)
+	ListDeliveries(subscriptionID int, status string, limit int) ([]models.WebhookDelivery, error)
+	// ListDue returns pending deliveries whose next attempt is due, with
+	// their subscriptions.
+	ListDue(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	// Claim moves a due delivery's next attempt to leaseUntil, reporting
+	// false if another worker claimed it first.
+	Claim(delivery *models.WebhookDelivery, leaseUntil time.Time) (bool, error)
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	CreateAttempt(attempt *models.WebhookAttempt) error
+}
+
+type webhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) CreateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+func (r *webhookRepo) GetSubscription(id int) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepo) ListSubscriptionsByCustomerID(customerID int) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.Where("customer_id = ?", customerID).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepo) ListActiveSubscriptions(customerID int) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.Where("customer_id = ? AND active = ?", customerID, true).Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepo) UpdateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Save(sub).Error
+}
+
+func (r *webhookRepo) DeleteSubscription(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		pending := tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, models.WebhookDeliveryPending)
+		if err := pending.Update("status", models.WebhookDeliveryDead).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookSubscription{}, id).Error
+	})
+}
+
+func (r *webhookRepo) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+}
+
+func (r *webhookRepo) GetDelivery(id int) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Preload("Subscription").
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&delivery, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepo) ListDeliveries(subscriptionID int, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	q := r.db.Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepo) ListDue(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepo) Claim(delivery *models.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		delivery.NextAttemptAt = leaseUntil
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *webhookRepo) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Omit("Subscription", "AttemptLog").Save(delivery).Error
+}
+
+func (r *webhookRepo) CreateAttempt(attempt *models.WebhookAttempt) error {
+	return r.db.Create(attempt).Error
+}
@@ -0,0 +1,221 @@
+// Package sanctions loads a sanctions list from a local file and screens
+// names against it with fuzzy matching. Two formats are read: the UN
+// Security Council consolidated list XML, and a CSV with the columns
+//
+//	id,name,type,program,aliases
+//
+// where aliases are separated by semicolons. Only id and name are required.
+package sanctions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// Entry is one listed person or organisation.
+type Entry struct {
+	ID      string
+	Type    string
+	Program string
+	Names   []string // the primary name first, then aliases
+
+	normalized []string
+}
+
+// List is a loaded sanctions list. Version is a hash of the file, so a
+// changed file is a new version even if its name is the same.
+type List struct {
+	Source  string
+	Version string
+	Entries []Entry
+}
+
+// Load reads a list, choosing the format by file extension.
+func Load(path string) (*List, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	list := &List{Source: path, Version: hex.EncodeToString(sum[:])}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		list.Entries, err = ParseConsolidatedXML(bytes.NewReader(data))
+	case ".csv":
+		list.Entries, err = ParseCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("sanctions list %s: unknown format, want .xml or .csv", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sanctions list %s: %w", path, err)
+	}
+	for i := range list.Entries {
+		list.Entries[i].prepare()
+	}
+	return list, nil
+}
+
+func (e *Entry) prepare() {
+	e.normalized = make([]string, len(e.Names))
+	for i, name := range e.Names {
+		e.normalized[i] = Normalize(name)
+	}
+}
+
+// Screen returns the entries with a name scoring at least threshold
+// against any of names, best first, one match per entry.
+func (l *List) Screen(threshold float64, names ...string) []models.SanctionsMatch {
+	var screened, originals []string
+	for _, name := range names {
+		if n := Normalize(name); n != "" {
+			screened = append(screened, n)
+			originals = append(originals, name)
+		}
+	}
+
+	var matches []models.SanctionsMatch
+	for i := range l.Entries {
+		entry := &l.Entries[i]
+		best := models.SanctionsMatch{Score: -1}
+		for j, listed := range entry.normalized {
+			for k, name := range screened {
+				if score := Score(name, listed); score > best.Score {
+					best = models.SanctionsMatch{
+						ScreenedName: originals[k],
+						EntryID:      entry.ID,
+						EntryName:    entry.Names[0],
+						MatchedName:  entry.Names[j],
+						Score:        score,
+					}
+				}
+			}
+		}
+		if best.Score >= threshold {
+			matches = append(matches, best)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// ParseCSV reads the CSV format described in the package comment.
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["id"]; !ok {
+		return nil, errors.New("missing id column")
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errors.New("missing name column")
+	}
+	field := func(row []string, col string) string {
+		if i, ok := cols[col]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var entries []Entry
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := field(row, "name")
+		if name == "" {
+			continue
+		}
+		entry := Entry{ID: field(row, "id"), Type: field(row, "type"), Program: field(row, "program"), Names: []string{name}}
+		for _, alias := range strings.Split(field(row, "aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Names = append(entry.Names, alias)
+			}
+		}
+		entries = append(entries, entry)
+	}
+}
+
+type consolidatedList struct {
+	Individuals []struct {
+		DataID   string   `xml:"DATAID"`
+		First    string   `xml:"FIRST_NAME"`
+		Second   string   `xml:"SECOND_NAME"`
+		Third    string   `xml:"THIRD_NAME"`
+		Fourth   string   `xml:"FOURTH_NAME"`
+		ListType string   `xml:"UN_LIST_TYPE"`
+		Aliases  []string `xml:"INDIVIDUAL_ALIAS>ALIAS_NAME"`
+	} `xml:"INDIVIDUALS>INDIVIDUAL"`
+	Entities []struct {
+		DataID   string   `xml:"DATAID"`
+		Name     string   `xml:"FIRST_NAME"`
+		ListType string   `xml:"UN_LIST_TYPE"`
+		Aliases  []string `xml:"ENTITY_ALIAS>ALIAS_NAME"`
+	} `xml:"ENTITIES>ENTITY"`
+}
+
+// ParseConsolidatedXML reads the UN Security Council consolidated list.
+func ParseConsolidatedXML(r io.Reader) ([]Entry, error) {
+	var doc consolidatedList
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, ind := range doc.Individuals {
+		name := strings.Join(strings.Fields(strings.Join([]string{ind.First, ind.Second, ind.Third, ind.Fourth}, " ")), " ")
+		if name == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			ID: ind.DataID, Type: "individual", Program: ind.ListType,
+			Names: append([]string{name}, nonEmpty(ind.Aliases)...),
+		})
+	}
+	for _, ent := range doc.Entities {
+		name := strings.TrimSpace(ent.Name)
+		if name == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			ID: ent.DataID, Type: "entity", Program: ent.ListType,
+			Names: append([]string{name}, nonEmpty(ent.Aliases)...),
+		})
+	}
+	return entries, nil
+}
+
+func nonEmpty(names []string) []string {
+	var out []string
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -1,6 +1,13 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
 	"github.com/Mahesh252k/banking-api/internal/models"
 	"github.com/Mahesh252k/banking-api/internal/repositories"
 	"gorm.io/gorm"
@@ -8,19 +15,58 @@ import (
 
 type AccountService interface {
 	CreateAccount(req *models.CreateAccountRequest, customerID, branchID int) (*models.Account, error)
+	// Transfer posts immediately; it is for the bank's own flows. Customer
+	// transfers go through TransferWithOptions and the fraud rules.
 	Transfer(fromAccountID, toAccountID int, amount float64) error
+	TransferWithOptions(fromAccountID, toAccountID int, amount float64, opts TransferOptions) (*models.TransferResult, error)
+	// ReviewHeldTransfer posts (approve) or releases a transfer held by the
+	// fraud rules.
+	ReviewHeldTransfer(id int, approve bool, note string) (*models.HeldTransfer, error)
 	Deposit(accountID int, amount float64) error
+	PostDeposit(tx *gorm.DB, accountID int, amount float64) (*models.Transaction, error)
 	GetStatement(accountID int) ([]models.Transaction, error)
+	GetAccount(accountID int) (*models.Account, error)
+	PostWithdrawal(tx *gorm.DB, accountID int, amount float64) (*models.Transaction, error)
+	PostWithdrawalReversal(tx *gorm.DB, transactionID int) (*models.Transaction, error)
+	// WithAudit returns the service acting for meta's actor in the audit
+	// log. Without it changes are attributed to the system.
+	WithAudit(meta audit.Meta) AccountService
 }
 
 type accountService struct {
 	db     *gorm.DB
 	repo   repositories.AccountRepository
 	txRepo repositories.TransactionRepository
+	events events.Recorder
+	audit  audit.Recorder
+	meta   audit.Meta
+	fraud  FraudService
+}
+
+// TransferOptions carries what the caller knows beyond the request itself.
+// StepUpVerified means the customer re-verified for this transfer, which
+// satisfies challenge rules.
+type TransferOptions struct {
+	StepUpVerified bool
 }
 
-func NewAccountService(db *gorm.DB, repo repositories.AccountRepository, txRepo repositories.TransactionRepository) AccountService {
-	return &accountService{db: db, repo: repo, txRepo: txRepo}
+// NewAccountService builds the service. fraud may be nil, in which case
+// no fraud rules apply.
+func NewAccountService(
+	db *gorm.DB,
+	repo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+	fraud FraudService,
+) AccountService {
+	return &accountService{db: db, repo: repo, txRepo: txRepo, events: recorder, audit: auditor, fraud: fraud}
+}
+
+func (s *accountService) WithAudit(meta audit.Meta) AccountService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
 }
 
 func (s *accountService) CreateAccount(req *models.CreateAccountRequest, customerID, branchID int) (*models.Account, error) {
@@ -31,76 +77,330 @@ func (s *accountService) CreateAccount(req *models.CreateAccountRequest, custome
 		Currency:   req.Currency,
 		Balance:    0.0,
 	}
-	if err := s.repo.Create(account); err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(account); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "account.create",
+			EntityType: "account",
+			EntityID:   account.ID,
+			After: map[string]any{
+				"customer_id": customerID,
+				"branch_id":   branchID,
+				"owner":       account.Owner,
+				"currency":    account.Currency,
+				"balance":     account.Balance,
+			},
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return account, nil
 }
 
 func (s *accountService) Transfer(fromAccountID, toAccountID int, amount float64) error {
+	if fromAccountID == toAccountID {
+		return models.ErrSameAccount
+	}
+
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		fromAcc, err := s.repo.GetByID(fromAccountID)
+		fromAcc, toAcc, err := s.lockTransfer(tx, fromAccountID, toAccountID, amount)
 		if err != nil {
 			return err
 		}
-		toAcc, err := s.repo.GetByID(toAccountID)
+		_, err = s.postTransfer(tx, fromAcc, toAcc, amount)
+		return err
+	})
+}
+
+func (s *accountService) TransferWithOptions(fromAccountID, toAccountID int, amount float64, opts TransferOptions) (*models.TransferResult, error) {
+	if fromAccountID == toAccountID {
+		return nil, models.ErrSameAccount
+	}
+
+	var result *models.TransferResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		fromAcc, toAcc, err := s.lockTransfer(tx, fromAccountID, toAccountID, amount)
 		if err != nil {
 			return err
 		}
 
-		if fromAcc.Balance < amount {
-			return models.ErrInsufficientFunds
+		decision := &models.FraudDecision{Action: models.FraudAllow}
+		if s.fraud != nil {
+			decision, err = s.fraud.Assess(tx, FraudInput{
+				Operation:   models.FraudOpTransfer,
+				AccountID:   fromAccountID,
+				ToAccountID: toAccountID,
+				Amount:      amount,
+			})
+			if err != nil {
+				return err
+			}
 		}
 
-		// update balances in memory
-		fromAcc.Balance -= amount
-		toAcc.Balance += amount
+		switch {
+		case decision.Action == models.FraudHold:
+			held, err := s.holdTransfer(tx, fromAcc, toAccountID, amount, decision)
+			if err != nil {
+				return err
+			}
+			result = &models.TransferResult{Status: models.TransferHeld, HeldTransferID: held.ID}
+			return nil
+		case decision.Action == models.FraudChallenge && !opts.StepUpVerified:
+			return &models.StepUpRequiredError{Rules: decision.Rules}
+		}
 
-		// persist
-		if err := s.repo.UpdateBalance(fromAcc); err != nil {
+		record, err := s.postTransfer(tx, fromAcc, toAcc, amount)
+		if err != nil {
 			return err
 		}
-		if err := s.repo.UpdateBalance(toAcc); err != nil {
+		result = &models.TransferResult{Status: models.TransferCompleted, TransactionID: record.ID}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockTransfer locks both accounts and checks the source can pay.
+func (s *accountService) lockTransfer(tx *gorm.DB, fromAccountID, toAccountID int, amount float64) (*models.Account, *models.Account, error) {
+	repo := s.repo.WithTx(tx)
+
+	fromAcc, err := repo.GetForUpdate(fromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	toAcc, err := repo.GetForUpdate(toAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// funds reserved for pending operations are not available
+	if fromAcc.Balance-fromAcc.HeldAmount < amount {
+		return nil, nil, models.ErrInsufficientFunds
+	}
+	return fromAcc, toAcc, nil
+}
+
+// postTransfer moves the money between two locked accounts and records the
+// transaction, its audit entry and its events.
+func (s *accountService) postTransfer(tx *gorm.DB, fromAcc, toAcc *models.Account, amount float64) (*models.Transaction, error) {
+	repo := s.repo.WithTx(tx)
+	fromAccountID, toAccountID := fromAcc.ID, toAcc.ID
+	before := map[string]any{"from_balance": fromAcc.Balance, "to_balance": toAcc.Balance}
+
+	// update balances in memory
+	fromAcc.Balance -= amount
+	toAcc.Balance += amount
+
+	// persist
+	if err := repo.UpdateBalance(fromAcc); err != nil {
+		return nil, err
+	}
+	if err := repo.UpdateBalance(toAcc); err != nil {
+		return nil, err
+	}
+
+	// record transaction
+	txRecord := &models.Transaction{
+		FromAccountID: &fromAccountID,
+		ToAccountID:   &toAccountID,
+		Amount:        amount,
+	}
+	if err := s.txRepo.WithTx(tx).Create(txRecord); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "account.transfer",
+		EntityType: "transaction",
+		EntityID:   txRecord.ID,
+		Before:     before,
+		After: map[string]any{
+			"from_account_id": fromAccountID,
+			"to_account_id":   toAccountID,
+			"amount":          amount,
+			"from_balance":    fromAcc.Balance,
+			"to_balance":      toAcc.Balance,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	// and the events, committed together with the money movement
+	if err := s.events.Record(tx, events.New(events.TransferCompleted, fromAcc.CustomerID, map[string]any{
+		"transaction_id":  txRecord.ID,
+		"from_account_id": fromAccountID,
+		"to_account_id":   toAccountID,
+		"amount":          amount,
+		"currency":        fromAcc.Currency,
+		"balance":         fromAcc.Balance,
+	})); err != nil {
+		return nil, err
+	}
+	err := s.events.Record(tx, events.New(events.TransferReceived, toAcc.CustomerID, map[string]any{
+		"transaction_id":  txRecord.ID,
+		"from_account_id": fromAccountID,
+		"to_account_id":   toAccountID,
+		"amount":          amount,
+		"currency":        toAcc.Currency,
+		"balance":         toAcc.Balance,
+	}))
+	return txRecord, err
+}
+
+// holdTransfer reserves the amount on the source account and queues the
+// transfer for review.
+func (s *accountService) holdTransfer(tx *gorm.DB, fromAcc *models.Account, toAccountID int, amount float64, decision *models.FraudDecision) (*models.HeldTransfer, error) {
+	fromAcc.HeldAmount += amount
+	if err := s.repo.WithTx(tx).UpdateHeldAmount(fromAcc); err != nil {
+		return nil, err
+	}
+
+	held := &models.HeldTransfer{
+		CustomerID:    fromAcc.CustomerID,
+		FromAccountID: fromAcc.ID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		Status:        models.HeldTransferPending,
+		Rules:         strings.Join(decision.Rules, "; "),
+	}
+	if err := s.fraud.HoldTransfer(tx, held); err != nil {
+		return nil, err
+	}
+	return held, s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "account.transfer_held",
+		EntityType: "held_transfer",
+		EntityID:   held.ID,
+		After:      held,
+	})
+}
+
+func (s *accountService) ReviewHeldTransfer(id int, approve bool, note string) (*models.HeldTransfer, error) {
+	if s.fraud == nil {
+		return nil, models.ErrHeldTransferNotFound
+	}
+
+	var held *models.HeldTransfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		held, err = s.fraud.LockHeldTransfer(tx, id)
+		if err != nil {
 			return err
 		}
+		if held.Status != models.HeldTransferPending {
+			return models.ErrHeldTransferNotPending
+		}
+		before := *held
 
-		// record transaction
-		txRecord := &models.Transaction{
-			FromAccountID: &fromAccountID,
-			ToAccountID:   &toAccountID,
-			Amount:        amount,
+		repo := s.repo.WithTx(tx)
+		fromAcc, err := repo.GetForUpdate(held.FromAccountID)
+		if err != nil {
+			return err
 		}
-		if err := s.txRepo.Create(txRecord); err != nil {
+		fromAcc.HeldAmount -= held.Amount
+		if err := repo.UpdateHeldAmount(fromAcc); err != nil {
 			return err
 		}
 
-		return nil
+		now := time.Now()
+		held.ReviewNote = note
+		held.ReviewedBy = s.meta.ActorID
+		held.ReviewedAt = &now
+		held.Status = models.HeldTransferRejected
+		if approve {
+			toAcc, err := repo.GetForUpdate(held.ToAccountID)
+			if err != nil {
+				return err
+			}
+			// the reserved amount was released above, so this only fails if
+			// the balance itself went down meanwhile
+			if fromAcc.Balance-fromAcc.HeldAmount < held.Amount {
+				return models.ErrInsufficientFunds
+			}
+			record, err := s.postTransfer(tx, fromAcc, toAcc, held.Amount)
+			if err != nil {
+				return err
+			}
+			held.Status = models.HeldTransferApproved
+			held.TransactionID = &record.ID
+		}
+
+		if err := s.fraud.UpdateHeldTransfer(tx, held); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "fraud.review",
+			EntityType: "held_transfer",
+			EntityID:   held.ID,
+			Before:     before,
+			After:      held,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
+	return held, nil
 }
 
 func (s *accountService) Deposit(accountID int, amount float64) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		account, err := s.repo.GetByID(accountID)
+		account, deposit, err := s.postDeposit(tx, accountID, amount)
 		if err != nil {
 			return err
 		}
-
-		account.Balance += amount
-		if err := s.repo.UpdateBalance(account); err != nil {
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "account.deposit",
+			EntityType: "account",
+			EntityID:   accountID,
+			Before:     map[string]any{"balance": account.Balance - amount},
+			After:      map[string]any{"balance": account.Balance, "transaction_id": deposit.ID},
+		}); err != nil {
 			return err
 		}
+		return s.events.Record(tx, events.New(events.DepositReceived, account.CustomerID, map[string]any{
+			"transaction_id": deposit.ID,
+			"account_id":     accountID,
+			"amount":         amount,
+			"currency":       account.Currency,
+			"balance":        account.Balance,
+		}))
+	})
+}
 
-		depositTx := &models.Transaction{
-			FromAccountID: nil,
-			ToAccountID:   &accountID,
-			Amount:        amount,
-		}
-		if err := s.txRepo.Create(depositTx); err != nil {
-			return err
-		}
+// PostDeposit credits accountID as part of the caller's transaction tx, so
+// the credit commits or rolls back together with the caller's own writes.
+func (s *accountService) PostDeposit(tx *gorm.DB, accountID int, amount float64) (*models.Transaction, error) {
+	_, deposit, err := s.postDeposit(tx, accountID, amount)
+	return deposit, err
+}
 
-		return nil
-	})
+func (s *accountService) postDeposit(tx *gorm.DB, accountID int, amount float64) (*models.Account, *models.Transaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	account, err := repo.GetForUpdate(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account.Balance += amount
+	if err := repo.UpdateBalance(account); err != nil {
+		return nil, nil, err
+	}
+
+	depositTx := &models.Transaction{
+		FromAccountID: nil,
+		ToAccountID:   &accountID,
+		Amount:        amount,
+	}
+	if err := s.txRepo.WithTx(tx).Create(depositTx); err != nil {
+		return nil, nil, err
+	}
+
+	return account, depositTx, nil
 }
 
 func (s *accountService) GetStatement(accountID int) ([]models.Transaction, error) {
@@ -113,3 +413,56 @@ func (s *accountService) GetStatement(accountID int) ([]models.Transaction, erro
 
 	return txns, err
 }
+
+func (s *accountService) GetAccount(accountID int) (*models.Account, error) {
+	account, err := s.repo.GetByID(accountID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrAccountNotFound
+	}
+	return account, err
+}
+
+// PostWithdrawal debits cash paid out of accountID as part of tx. Held
+// funds are not available for withdrawal.
+func (s *accountService) PostWithdrawal(tx *gorm.DB, accountID int, amount float64) (*models.Transaction, error) {
+	repo := s.repo.WithTx(tx)
+
+	account, err := repo.GetForUpdate(accountID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if account.Balance-account.HeldAmount < amount {
+		return nil, models.ErrInsufficientFunds
+	}
+
+	account.Balance -= amount
+	if err := repo.UpdateBalance(account); err != nil {
+		return nil, err
+	}
+
+	withdrawal := &models.Transaction{
+		FromAccountID: &accountID,
+		ToAccountID:   nil,
+		Amount:        amount,
+	}
+	if err := s.txRepo.WithTx(tx).Create(withdrawal); err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// PostWithdrawalReversal credits back a withdrawal posted by PostWithdrawal.
+// Callers are responsible for reversing a withdrawal only once.
+func (s *accountService) PostWithdrawalReversal(tx *gorm.DB, transactionID int) (*models.Transaction, error) {
+	var original models.Transaction
+	if err := tx.First(&original, transactionID).Error; err != nil {
+		return nil, err
+	}
+	if original.FromAccountID == nil || original.ToAccountID != nil {
+		return nil, fmt.Errorf("transaction %d is not a withdrawal", transactionID)
+	}
+	return s.PostDeposit(tx, *original.FromAccountID, original.Amount)
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+	"gorm.io/gorm"
+)
+
+type APIClientService interface {
+	// Token runs the OAuth2 client credentials grant. scope is the
+	// space-separated scope parameter; empty asks for every scope the
+	// client holds.
+	Token(clientID, secret, scope string) (*models.ClientToken, error)
+	// Authenticate checks a client's credentials, e.g. before issuing it a
+	// consent-bound token.
+	Authenticate(clientID, secret string) (*models.APIClient, error)
+	// Check reports whether a client token issued at issuedAt is still
+	// good and returns the client.
+	Check(clientID string, issuedAt time.Time) (*models.APIClient, error)
+
+	ListClients() ([]models.APIClient, error)
+	GetClient(id int) (*models.APIClient, error)
+	CreateClient(req *models.CreateAPIClientRequest) (*models.APIClientSecret, error)
+	// UpdateClient edits a client. Changing its scopes or deactivating it
+	// revokes its tokens.
+	UpdateClient(id int, req *models.UpdateAPIClientRequest) (*models.APIClient, error)
+	// RotateSecret issues a new secret. The old one keeps working for the
+	// configured grace period.
+	RotateSecret(id int) (*models.APIClientSecret, error)
+
+	WithAudit(meta audit.Meta) APIClientService
+}
+
+// APIClientConfig sets how long client tokens last and how long a
+// rotated-out secret is still accepted.
+type APIClientConfig struct {
+	TokenTTL    time.Duration
+	SecretGrace time.Duration
+}
+
+type apiClientService struct {
+	db        *gorm.DB
+	repo      repositories.APIClientRepository
+	customers repositories.CustomerRepository
+	audit     audit.Recorder
+	meta      audit.Meta
+	cfg       APIClientConfig
+}
+
+func NewAPIClientService(
+	db *gorm.DB,
+	repo repositories.APIClientRepository,
+	customers repositories.CustomerRepository,
+	auditor audit.Recorder,
+	cfg APIClientConfig,
+) APIClientService {
+	return &apiClientService{
+		db:        db,
+		repo:      repo,
+		customers: customers,
+		audit:     auditor,
+		cfg:       cfg,
+	}
+}
+
+func (s *apiClientService) WithAudit(meta audit.Meta) APIClientService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+// Secrets are long and random, so a fast hash is enough to keep them
+// out of the database.
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func secretMatches(hash, secret string) bool {
+	return hash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(hashClientSecret(secret))) == 1
+}
+
+// authenticate checks the client's current secret, or its previous one
+// during the grace period after a rotation.
+func authenticate(client *models.APIClient, secret string, now time.Time) bool {
+	if secretMatches(client.SecretHash, secret) {
+		return true
+	}
+	return client.PreviousSecretExpiresAt != nil && now.Before(*client.PreviousSecretExpiresAt) &&
+		secretMatches(client.PreviousSecretHash, secret)
+}
+
+func (s *apiClientService) Authenticate(clientID, secret string) (*models.APIClient, error) {
+	client, err := s.repo.GetByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !client.Active || !authenticate(client, secret, time.Now()) {
+		entry := audit.Entry{Action: "api_client.token_failed", EntityType: "api_client"}
+		if client != nil {
+			entry.EntityID = client.ID
+		}
+		if err := s.audit.Record(s.db, s.meta, entry); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrInvalidClient
+	}
+	return client, nil
+}
+
+func (s *apiClientService) Token(clientID, secret, scope string) (*models.ClientToken, error) {
+	client, err := s.Authenticate(clientID, secret)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	// a narrower scope than the client holds is fine; anything it does
+	// not hold fails the whole request
+	scopes := client.Scopes
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, want := range requested {
+			granted := false
+			for _, have := range client.Scopes {
+				granted = granted || have == want
+			}
+			if !granted {
+				return nil, fmt.Errorf("%w: %s", models.ErrInvalidScope, want)
+			}
+		}
+		if scopes, err = validScopes(requested); err != nil {
+			return nil, err
+		}
+	}
+
+	if !client.Provider() {
+		customer, err := s.customers.GetByID(client.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		if customer == nil {
+			return nil, models.ErrInvalidClient
+		}
+		if customer.ScreeningStatus == models.ScreeningBlocked {
+			return nil, models.ErrSanctionsBlocked
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).MarkTokenIssued(client.ID, now); err != nil {
+			return err
+		}
+		meta := s.meta
+		meta.ActorType, meta.ActorID = models.ActorAPIClient, client.ID
+		return s.audit.Record(tx, meta, audit.Entry{
+			Action:     "api_client.token",
+			EntityType: "api_client",
+			EntityID:   client.ID,
+			After:      map[string]any{"scopes": scopes},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateClientToken(client.CustomerID, client.ClientID, scopes, s.cfg.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ClientToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.cfg.TokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+func (s *apiClientService) Check(clientID string, issuedAt time.Time) (*models.APIClient, error) {
+	client, err := s.repo.GetByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	// iat has one-second resolution, so a token from the same second as
+	// the revocation is refused too
+	if client == nil || !client.Active || (client.TokensRevokedAt != nil && issuedAt.Unix() <= client.TokensRevokedAt.Unix()) {
+		return nil, models.ErrSessionRevoked
+	}
+	return client, nil
+}
+
+func (s *apiClientService) ListClients() ([]models.APIClient, error) {
+	return s.repo.List()
+}
+
+func (s *apiClientService) GetClient(id int) (*models.APIClient, error) {
+	client, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, models.ErrAPIClientNotFound
+	}
+	return client, nil
+}
+
+// validScopes checks the scopes exist and returns them sorted without
+// duplicates.
+func validScopes(scopes []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, scope := range models.AllScopes {
+		known[scope] = true
+	}
+	seen := map[string]bool{}
+	var valid []string
+	for _, scope := range scopes {
+		if !known[scope] {
+			return nil, fmt.Errorf("%w: %s", models.ErrUnknownScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+	sort.Strings(valid)
+	return valid, nil
+}
+
+// clientScopes validates the scopes of a customer's client; providers
+// get none.
+func clientScopes(provider bool, scopes []string) ([]string, error) {
+	if provider {
+		if len(scopes) > 0 {
+			return nil, models.ErrProviderScopes
+		}
+		return []string{}, nil
+	}
+	if len(scopes) == 0 {
+		return nil, models.ErrNoScopes
+	}
+	return validScopes(scopes)
+}
+
+// newClientSecret returns a fresh secret and its hash.
+func newClientSecret() (string, string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hashClientSecret(secret), nil
+}
+
+func (s *apiClientService) CreateClient(req *models.CreateAPIClientRequest) (*models.APIClientSecret, error) {
+	scopes, err := clientScopes(req.CustomerID == 0, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.CustomerID != 0 {
+		customer, err := s.customers.GetByID(req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		if customer == nil {
+			return nil, models.ErrCustomerNotFound
+		}
+	}
+
+	var id [12]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	secret, hash, err := newClientSecret()
+	if err != nil {
+		return nil, err
+	}
+	client := &models.APIClient{
+		ClientID:           "cl_" + hex.EncodeToString(id[:]),
+		Name:               strings.TrimSpace(req.Name),
+		CustomerID:         req.CustomerID,
+		SecretHash:         hash,
+		Scopes:             scopes,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		RateLimitBurst:     req.RateLimitBurst,
+		Active:             true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(client); err != nil {
+			return err
+		}
+		if err := repo.SetScopes(client.ID, scopes); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "api_client.create",
+			EntityType: "api_client",
+			EntityID:   client.ID,
+			After:      client,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIClientSecret{Client: client, ClientSecret: secret}, nil
+}
+
+func (s *apiClientService) UpdateClient(id int, req *models.UpdateAPIClientRequest) (*models.APIClient, error) {
+	var client *models.APIClient
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		client, err = repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if client == nil {
+			return models.ErrAPIClientNotFound
+		}
+		before := *client
+
+		if req.Name != nil {
+			client.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.RateLimitPerMinute != nil {
+			client.RateLimitPerMinute = *req.RateLimitPerMinute
+		}
+		if req.RateLimitBurst != nil {
+			client.RateLimitBurst = *req.RateLimitBurst
+		}
+		revoke := false
+		if req.Active != nil && *req.Active != client.Active {
+			client.Active = *req.Active
+			revoke = !client.Active
+		}
+		if req.Scopes != nil {
+			scopes, err := clientScopes(client.Provider(), *req.Scopes)
+			if err != nil {
+				return err
+			}
+			if strings.Join(scopes, " ") != strings.Join(client.Scopes, " ") {
+				if err := repo.SetScopes(client.ID, scopes); err != nil {
+					return err
+				}
+				client.Scopes = scopes
+				revoke = true
+			}
+		}
+		if revoke {
+			now := time.Now()
+			client.TokensRevokedAt = &now
+		}
+
+		if err := repo.Update(client); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "api_client.update",
+			EntityType: "api_client",
+			EntityID:   client.ID,
+			Before:     before,
+			After:      client,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *apiClientService) RotateSecret(id int) (*models.APIClientSecret, error) {
+	secret, hash, err := newClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var client *models.APIClient
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		client, err = repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if client == nil {
+			return models.ErrAPIClientNotFound
+		}
+		// rotating twice within the grace period drops the oldest secret
+		now := time.Now()
+		graceEnds := now.Add(s.cfg.SecretGrace)
+		client.PreviousSecretHash = client.SecretHash
+		client.PreviousSecretExpiresAt = &graceEnds
+		client.SecretHash = hash
+		client.SecretRotatedAt = &now
+		if err := repo.Update(client); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "api_client.rotate_secret",
+			EntityType: "api_client",
+			EntityID:   client.ID,
+			After:      map[string]any{"previous_secret_expires_at": graceEnds},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIClientSecret{Client: client, ClientSecret: secret}, nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type BeneficiaryService interface {
+	AddBeneficiary(req *models.AddBeneficiaryRequest, customerID int) (*models.Beneficiary, error)
+	ListBeneficiaries(customerID int) ([]models.Beneficiary, error)
+	GetBeneficiary(id, customerID int) (*models.Beneficiary, error)
+	UpdateNickname(id, customerID int, nickname string) (*models.Beneficiary, error)
+	// DeleteBeneficiary refuses while payments to the beneficiary are
+	// still on their way to the rail.
+	DeleteBeneficiary(id, customerID int) error
+	// LockBeneficiary is GetBeneficiary with a row lock inside tx, so the
+	// beneficiary cannot be deleted while a payment to it is recorded.
+	LockBeneficiary(tx *gorm.DB, id, customerID int) (*models.Beneficiary, error)
+	CheckTransferAllowed(beneficiary *models.Beneficiary, amount float64) error
+}
+
+// BeneficiaryPolicy controls how soon a newly added beneficiary may receive
+// large transfers. Transfers above CoolingOffLimit are refused until
+// CoolingOff has elapsed since the beneficiary was added.
+type BeneficiaryPolicy struct {
+	CoolingOff      time.Duration
+	CoolingOffLimit float64
+}
+
+type beneficiaryService struct {
+	db        *gorm.DB
+	repo      repositories.BeneficiaryRepository
+	payments  repositories.OutgoingPaymentRepository
+	policy    BeneficiaryPolicy
+	sanctions SanctionsService
+}
+
+// NewBeneficiaryService builds the service. sanctions may be nil, in which
+// case new beneficiaries are not screened.
+func NewBeneficiaryService(
+	db *gorm.DB,
+	repo repositories.BeneficiaryRepository,
+	payments repositories.OutgoingPaymentRepository,
+	policy BeneficiaryPolicy,
+	sanctions SanctionsService,
+) BeneficiaryService {
+	return &beneficiaryService{db: db, repo: repo, payments: payments, policy: policy, sanctions: sanctions}
+}
+
+var (
+	domesticAccountPattern = regexp.MustCompile(`^[0-9]{6,20}$`)
+	ibanPattern            = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern             = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ifscPattern            = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+)
+
+// normalizeIdentifier strips spaces and dashes and upper-cases the value,
+// so "gb82 west 1234..." and "GB82WEST1234..." are stored identically.
+func normalizeIdentifier(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	return strings.NewReplacer(" ", "", "-", "").Replace(s)
+}
+
+// validateAccountNumber accepts either a domestic account number (6-20
+// digits) or an IBAN with a valid mod-97 check digit.
+func validateAccountNumber(accountNumber string) error {
+	if domesticAccountPattern.MatchString(accountNumber) {
+		return nil
+	}
+	if !ibanPattern.MatchString(accountNumber) {
+		return models.ErrInvalidAccountNumber
+	}
+
+	rearranged := accountNumber[4:] + accountNumber[:4]
+	var digits strings.Builder
+	for _, r := range rearranged {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return models.ErrInvalidAccountNumber
+	}
+	return nil
+}
+
+// validateBankCode accepts a BIC (8 or 11 characters) or an IFSC code.
+func validateBankCode(bankCode string) error {
+	if bicPattern.MatchString(bankCode) || ifscPattern.MatchString(bankCode) {
+		return nil
+	}
+	return models.ErrInvalidBankCode
+}
+
+func (s *beneficiaryService) AddBeneficiary(req *models.AddBeneficiaryRequest, customerID int) (*models.Beneficiary, error) {
+	accountNumber := normalizeIdentifier(req.AccountNumber)
+	bankCode := normalizeIdentifier(req.BankCode)
+
+	if err := validateAccountNumber(accountNumber); err != nil {
+		return nil, err
+	}
+	if err := validateBankCode(bankCode); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByAccount(customerID, accountNumber, bankCode)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, models.ErrDuplicateBeneficiary
+	}
+
+	now := time.Now()
+	beneficiary := &models.Beneficiary{
+		CustomerID:      customerID,
+		Name:            strings.TrimSpace(req.Name),
+		Nickname:        strings.TrimSpace(req.Nickname),
+		AccountNumber:   accountNumber,
+		BankName:        strings.TrimSpace(req.BankName),
+		BankCode:        bankCode,
+		CoolingOffUntil: now.Add(s.policy.CoolingOff),
+		ScreeningStatus: models.ScreeningClear,
+		CreatedAt:       now,
+	}
+	if s.sanctions == nil {
+		if err := s.repo.Create(beneficiary); err != nil {
+			return nil, duplicateBeneficiary(err)
+		}
+		return beneficiary, nil
+	}
+
+	screening := s.sanctions.Screen(beneficiary.Name, beneficiary.BankName)
+	if screening.Status == models.ScreeningBlocked {
+		// keep a record of the refusal; there is no beneficiary to attach it to
+		_, err := s.sanctions.RecordHits(s.db, models.SanctionsSubjectBeneficiary, 0, customerID, screening, models.SanctionsHitBlocked)
+		if err != nil {
+			return nil, err
+		}
+		return nil, models.ErrSanctionsBlocked
+	}
+
+	beneficiary.ScreeningStatus = screening.Status
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(beneficiary); err != nil {
+			return err
+		}
+		_, err := s.sanctions.RecordHits(tx, models.SanctionsSubjectBeneficiary, beneficiary.ID, customerID, screening, models.SanctionsHitPending)
+		return err
+	})
+	if err != nil {
+		return nil, duplicateBeneficiary(err)
+	}
+	return beneficiary, nil
+}
+
+// duplicateBeneficiary turns the unique-index violation of a concurrent
+// add of the same account, which got past the check first, into the
+// error the check would have given.
+func duplicateBeneficiary(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return models.ErrDuplicateBeneficiary
+	}
+	return err
+}
+
+func (s *beneficiaryService) ListBeneficiaries(customerID int) ([]models.Beneficiary, error) {
+	return s.repo.ListByCustomerID(customerID)
+}
+
+// GetBeneficiary returns the beneficiary only if it belongs to customerID.
+func (s *beneficiaryService) GetBeneficiary(id, customerID int) (*models.Beneficiary, error) {
+	beneficiary, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if beneficiary == nil || beneficiary.CustomerID != customerID {
+		return nil, models.ErrBeneficiaryNotFound
+	}
+	return beneficiary, nil
+}
+
+func (s *beneficiaryService) UpdateNickname(id, customerID int, nickname string) (*models.Beneficiary, error) {
+	beneficiary, err := s.GetBeneficiary(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiary.Nickname = strings.TrimSpace(nickname)
+	if err := s.repo.UpdateNickname(id, beneficiary.Nickname); err != nil {
+		return nil, err
+	}
+	return beneficiary, nil
+}
+
+func (s *beneficiaryService) DeleteBeneficiary(id, customerID int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.LockBeneficiary(tx, id, customerID); err != nil {
+			return err
+		}
+		open, err := s.payments.WithTx(tx).CountOpenByBeneficiary(id)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return models.ErrBeneficiaryInUse
+		}
+		return s.repo.WithTx(tx).Delete(id)
+	})
+}
+
+func (s *beneficiaryService) LockBeneficiary(tx *gorm.DB, id, customerID int) (*models.Beneficiary, error) {
+	beneficiary, err := s.repo.WithTx(tx).GetForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+	if beneficiary == nil || beneficiary.CustomerID != customerID {
+		return nil, models.ErrBeneficiaryNotFound
+	}
+	return beneficiary, nil
+}
+
+// CheckTransferAllowed refuses beneficiaries held by sanctions screening and
+// enforces the cooling-off period: while it is running, only amounts up to
+// the policy limit may be sent to the beneficiary.
+func (s *beneficiaryService) CheckTransferAllowed(beneficiary *models.Beneficiary, amount float64) error {
+	switch beneficiary.ScreeningStatus {
+	case models.ScreeningBlocked:
+		return models.ErrSanctionsBlocked
+	case models.ScreeningReview:
+		return models.ErrSanctionsUnderReview
+	}
+	if amount > s.policy.CoolingOffLimit && time.Now().Before(beneficiary.CoolingOffUntil) {
+		return models.ErrBeneficiaryCoolingOff
+	}
+	return nil
+}
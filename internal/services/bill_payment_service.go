@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/billers"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type BillPaymentService interface {
+	PayBill(req *models.CreateBillPaymentRequest, customerID int, opts TransferOptions) (*models.BillPayment, error)
+	ListPayments(customerID int) ([]models.BillPayment, error)
+	GetPayment(id, customerID int) (*models.BillPayment, error)
+	CancelScheduled(id, customerID int) (*models.BillPayment, error)
+	RunScheduled() (int, error)
+	// ResumeStuck picks up payments an executor left in processing without
+	// handing them to the biller, and returns how many it claimed.
+	ResumeStuck() (int, error)
+	ApplyResult(result billers.Result) error
+	// WithAudit returns a copy that attributes audit entries to meta.
+	WithAudit(meta audit.Meta) BillPaymentService
+}
+
+type billPaymentService struct {
+	db          *gorm.DB
+	repo        repositories.BillPaymentRepository
+	billerSvc   BillerService
+	accountRepo repositories.AccountRepository
+	accountSvc  AccountService
+	customers   repositories.CustomerRepository
+	connector   billers.Connector
+	fraud       FraudService
+	kyc         KYCService
+	audit       audit.Recorder
+	meta        audit.Meta
+}
+
+// NewBillPaymentService debits bill payments through accountSvc and hands
+// them to connector for confirmation. Confirmed payments are credited to
+// the biller's settlement account. fraud and kyc may be nil, in which case
+// no fraud rules or KYC limits apply.
+func NewBillPaymentService(
+	db *gorm.DB,
+	repo repositories.BillPaymentRepository,
+	billerSvc BillerService,
+	accountRepo repositories.AccountRepository,
+	accountSvc AccountService,
+	customers repositories.CustomerRepository,
+	connector billers.Connector,
+	fraud FraudService,
+	kyc KYCService,
+	auditor audit.Recorder,
+) BillPaymentService {
+	s := &billPaymentService{
+		db:          db,
+		repo:        repo,
+		billerSvc:   billerSvc,
+		accountRepo: accountRepo,
+		accountSvc:  accountSvc,
+		customers:   customers,
+		connector:   connector,
+		fraud:       fraud,
+		kyc:         kyc,
+		audit:       auditor,
+	}
+	connector.OnResult(s.ApplyResult)
+	return s
+}
+
+func (s *billPaymentService) WithAudit(meta audit.Meta) BillPaymentService {
+	clone := *s
+	clone.meta = meta
+	return &clone
+}
+
+// PayBill executes a bill payment now, or stores it for later when
+// ScheduledFor is in the future. The fraud rules run when the customer
+// asks, where a challenge can be met with opts.StepUpVerified, and again
+// when the payment is debited, where only a hold stops it.
+func (s *billPaymentService) PayBill(req *models.CreateBillPaymentRequest, customerID int, opts TransferOptions) (*models.BillPayment, error) {
+	registration, err := s.billerSvc.GetRegistration(req.RegistrationID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !registration.Biller.Active {
+		return nil, models.ErrBillerInactive
+	}
+	if _, err := OwnedAccount(s.accountRepo, req.AccountID, customerID); err != nil {
+		return nil, err
+	}
+	if err := screenDebit(s.db, s.fraud, billFraudInput(req.AccountID, req.Amount), opts.StepUpVerified); err != nil {
+		return nil, err
+	}
+
+	payment := &models.BillPayment{
+		CustomerID:     customerID,
+		RegistrationID: registration.ID,
+		AccountID:      req.AccountID,
+		Amount:         req.Amount,
+		Status:         models.BillPaymentProcessing,
+	}
+	scheduled := req.ScheduledFor != nil && req.ScheduledFor.After(time.Now())
+	if scheduled {
+		payment.Status = models.BillPaymentScheduled
+		payment.ScheduledFor = req.ScheduledFor
+	}
+	if err := s.repo.Create(payment); err != nil {
+		return nil, err
+	}
+	payment.Registration = registration
+
+	if !scheduled {
+		if _, err := s.execute(payment.ID, time.Time{}); err != nil {
+			log.Printf("bill payment %d: %v", payment.ID, err)
+		}
+		if executed, err := s.repo.GetByID(payment.ID); err == nil && executed != nil {
+			return executed, nil
+		}
+	}
+	return payment, nil
+}
+
+// billClaimTimeout is how long a payment may stay claimed without reaching
+// the biller before ResumeStuck assumes its executor died.
+const billClaimTimeout = 5 * time.Minute
+
+// billReference is the reference a payment goes to the biller under.
+func billReference(id int) string {
+	return fmt.Sprintf("BP%010d", id)
+}
+
+// execute claims a payment in processing that is unclaimed, or was claimed
+// before staleBefore, and debits the customer and saves the reference in
+// the same transaction; only then does it submit to the biller. A crash in
+// between leaves the payment for ResumeStuck, which submits it again under
+// the same reference. It reports whether it claimed the payment.
+func (s *billPaymentService) execute(id int, staleBefore time.Time) (bool, error) {
+	var payment *models.BillPayment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		claimed, err := repo.GetForUpdate(id)
+		if err != nil || claimed == nil {
+			return err
+		}
+		if claimed.Status != models.BillPaymentProcessing || claimed.SubmittedAt != nil {
+			return nil
+		}
+		if claimed.ClaimedAt != nil && !claimed.ClaimedAt.Before(staleBefore) {
+			return nil
+		}
+
+		now := time.Now()
+		claimed.ClaimedAt = &now
+		if claimed.DebitedAt == nil {
+			reason, err := s.debit(tx, claimed)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				claimed.Status = models.BillPaymentFailed
+				claimed.FailureReason = reason
+				claimed.CompletedAt = &now
+				return repo.Update(claimed)
+			}
+		}
+		payment = claimed
+		return repo.Update(claimed)
+	})
+	if err != nil || payment == nil {
+		return false, err
+	}
+
+	registration := payment.Registration
+	if err := s.connector.Submit(payment, registration.Biller, registration); err != nil {
+		// the biller refused it, so the money comes back rather than
+		// staying in transit
+		if rejectErr := s.reject(payment.ID, fmt.Sprintf("biller unavailable: %v", err)); rejectErr != nil {
+			log.Printf("bill payment %d: %v", payment.ID, rejectErr)
+		}
+		return true, fmt.Errorf("submit to %s failed: %w", s.connector.Name(), err)
+	}
+	return true, s.repo.RecordSubmission(payment.ID, time.Now())
+}
+
+// debit takes the payment out of the customer's account as part of tx and
+// assigns its biller reference. A payment that cannot go ahead returns
+// the reason instead.
+func (s *billPaymentService) debit(tx *gorm.DB, payment *models.BillPayment) (string, error) {
+	registration := payment.Registration
+	switch {
+	case registration.DeletedAt.Valid:
+		return "biller registration was removed", nil
+	case !registration.Biller.Active:
+		return models.ErrBillerInactive.Error(), nil
+	}
+
+	// a scheduled payment runs long after the customer asked; they may
+	// have been blocked since
+	err := CheckScreening(s.customers.WithTx(tx), payment.CustomerID)
+	if screeningDecline(err) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// and what else they moved out since counts against the KYC limits
+	if s.kyc != nil {
+		err := s.kyc.CheckTransfer(payment.CustomerID, payment.Amount)
+		if errors.Is(err, models.ErrKYCLimitExceeded) || errors.Is(err, models.ErrKYCRejected) {
+			return err.Error(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// the customer already answered any challenge when asking for it
+	err = screenDebit(tx, s.fraud, billFraudInput(payment.AccountID, payment.Amount), true)
+	if errors.Is(err, models.ErrSuspectedFraud) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	withdrawal, err := s.accountSvc.PostWithdrawal(tx, payment.AccountID, payment.Amount)
+	if errors.Is(err, models.ErrInsufficientFunds) || errors.Is(err, models.ErrAccountNotFound) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	payment.DebitTransactionID = &withdrawal.ID
+	payment.DebitedAt = &now
+	payment.ConnectorReference = billReference(payment.ID)
+	return "", s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "bill_payment.debit",
+		EntityType: "bill_payment",
+		EntityID:   payment.ID,
+		After: map[string]any{
+			"account_id":          payment.AccountID,
+			"amount":              payment.Amount,
+			"biller_id":           registration.BillerID,
+			"transaction_id":      withdrawal.ID,
+			"connector_reference": payment.ConnectorReference,
+		},
+	})
+}
+
+// billFraudInput describes a bill payment to the fraud rules. Billers are
+// not judged as new payees; the registration is the customer's own.
+func billFraudInput(accountID int, amount float64) FraudInput {
+	return FraudInput{Operation: models.FraudOpTransfer, AccountID: accountID, Amount: amount}
+}
+
+// reject marks a payment in processing rejected and reverses its debit in
+// the same transaction, so a payment is refunded once even if the biller
+// answers twice. The refund is a ledger credit and cannot fail for lack of
+// funds.
+func (s *billPaymentService) reject(id int, reason string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		payment, err := repo.GetForUpdate(id)
+		if err != nil || payment == nil || payment.Status != models.BillPaymentProcessing {
+			return err
+		}
+
+		after := map[string]any{"reason": reason}
+		if payment.DebitTransactionID != nil {
+			refund, err := s.accountSvc.PostWithdrawalReversal(tx, *payment.DebitTransactionID)
+			if err != nil {
+				return fmt.Errorf("refund bill payment %d: %w", payment.ID, err)
+			}
+			after["refund_transaction_id"] = refund.ID
+		}
+
+		now := time.Now()
+		payment.Status = models.BillPaymentRejected
+		payment.FailureReason = reason
+		payment.CompletedAt = &now
+		if err := repo.Update(payment); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "bill_payment.reject",
+			EntityType: "bill_payment",
+			EntityID:   payment.ID,
+			After:      after,
+		})
+	})
+}
+
+// ApplyResult records the biller's answer for a payment in processing. A
+// confirmation credits the biller's settlement account.
+func (s *billPaymentService) ApplyResult(result billers.Result) error {
+	existing, err := s.repo.GetByConnectorReference(result.Reference)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return models.ErrBillPaymentNotFound
+	}
+
+	if !result.Confirmed {
+		return s.reject(existing.ID, result.Reason)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		payment, err := repo.GetForUpdate(existing.ID)
+		if err != nil || payment == nil || payment.Status != models.BillPaymentProcessing {
+			return err
+		}
+
+		settlementAccountID := payment.Registration.Biller.SettlementAccountID
+		credit, err := s.accountSvc.PostDeposit(tx, settlementAccountID, payment.Amount)
+		if err != nil {
+			return fmt.Errorf("settle bill payment %d: %w", payment.ID, err)
+		}
+
+		now := time.Now()
+		payment.Status = models.BillPaymentConfirmed
+		payment.CompletedAt = &now
+		if err := repo.Update(payment); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "bill_payment.confirm",
+			EntityType: "bill_payment",
+			EntityID:   payment.ID,
+			After: map[string]any{
+				"settlement_account_id": settlementAccountID,
+				"transaction_id":        credit.ID,
+			},
+		})
+	})
+}
+
+// RunScheduled executes scheduled payments that have fallen due and
+// returns how many it picked up.
+func (s *billPaymentService) RunScheduled() (int, error) {
+	due, err := s.repo.ListDueScheduled(time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, payment := range due {
+		won, err := s.repo.TransitionStatus(payment.ID, models.BillPaymentScheduled, models.BillPaymentProcessing)
+		if err != nil {
+			return count, err
+		}
+		if !won {
+			continue // cancelled or picked up elsewhere
+		}
+		if _, err := s.execute(payment.ID, time.Time{}); err != nil {
+			log.Printf("bill payment %d: %v", payment.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *billPaymentService) ResumeStuck() (int, error) {
+	staleBefore := time.Now().Add(-billClaimTimeout)
+	stuck, err := s.repo.ListStuck(staleBefore, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, payment := range stuck {
+		claimed, err := s.execute(payment.ID, staleBefore)
+		if err != nil {
+			log.Printf("bill payment %d: %v", payment.ID, err)
+		}
+		if claimed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *billPaymentService) ListPayments(customerID int) ([]models.BillPayment, error) {
+	return s.repo.ListByCustomerID(customerID)
+}
+
+func (s *billPaymentService) GetPayment(id, customerID int) (*models.BillPayment, error) {
+	payment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil || payment.CustomerID != customerID {
+		return nil, models.ErrBillPaymentNotFound
+	}
+	return payment, nil
+}
+
+func (s *billPaymentService) CancelScheduled(id, customerID int) (*models.BillPayment, error) {
+	payment, err := s.GetPayment(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	won, err := s.repo.TransitionStatus(id, models.BillPaymentScheduled, models.BillPaymentCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		return nil, models.ErrBillPaymentNotCancellable
+	}
+	payment.Status = models.BillPaymentCancelled
+	return payment, nil
+}
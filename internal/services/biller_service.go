@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// BillerService manages the admin-maintained biller directory and the
+// customers' registrations with billers.
+type BillerService interface {
+	CreateBiller(req *models.CreateBillerRequest) (*models.Biller, error)
+	UpdateBiller(id int, req *models.UpdateBillerRequest) (*models.Biller, error)
+	ListBillers(activeOnly bool) ([]models.Biller, error)
+
+	RegisterBiller(req *models.RegisterBillerRequest, customerID int) (*models.BillerRegistration, error)
+	ListRegistrations(customerID int) ([]models.BillerRegistration, error)
+	GetRegistration(id, customerID int) (*models.BillerRegistration, error)
+	DeleteRegistration(id, customerID int) error
+}
+
+type billerService struct {
+	db               *gorm.DB
+	billerRepo       repositories.BillerRepository
+	registrationRepo repositories.BillerRegistrationRepository
+	accountRepo      repositories.AccountRepository
+}
+
+func NewBillerService(
+	db *gorm.DB,
+	billerRepo repositories.BillerRepository,
+	registrationRepo repositories.BillerRegistrationRepository,
+	accountRepo repositories.AccountRepository,
+) BillerService {
+	return &billerService{db: db, billerRepo: billerRepo, registrationRepo: registrationRepo, accountRepo: accountRepo}
+}
+
+func (s *billerService) checkSettlementAccount(id int) error {
+	if _, err := s.accountRepo.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrAccountNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *billerService) CreateBiller(req *models.CreateBillerRequest) (*models.Biller, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	existing, err := s.billerRepo.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, models.ErrDuplicateBiller
+	}
+	if _, err := regexp.Compile(req.ConsumerNumberPattern); err != nil {
+		return nil, err
+	}
+	if err := s.checkSettlementAccount(req.SettlementAccountID); err != nil {
+		return nil, err
+	}
+
+	biller := &models.Biller{
+		Code:                  code,
+		Name:                  strings.TrimSpace(req.Name),
+		Category:              strings.ToLower(strings.TrimSpace(req.Category)),
+		ConsumerNumberPattern: req.ConsumerNumberPattern,
+		SettlementAccountID:   req.SettlementAccountID,
+		Active:                true,
+	}
+	if err := s.billerRepo.Create(biller); err != nil {
+		return nil, err
+	}
+	return biller, nil
+}
+
+func (s *billerService) UpdateBiller(id int, req *models.UpdateBillerRequest) (*models.Biller, error) {
+	biller, err := s.billerRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if biller == nil {
+		return nil, models.ErrBillerNotFound
+	}
+
+	if req.Name != nil {
+		biller.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Category != nil {
+		biller.Category = strings.ToLower(strings.TrimSpace(*req.Category))
+	}
+	if req.ConsumerNumberPattern != nil {
+		if _, err := regexp.Compile(*req.ConsumerNumberPattern); err != nil {
+			return nil, err
+		}
+		biller.ConsumerNumberPattern = *req.ConsumerNumberPattern
+	}
+	if req.SettlementAccountID != nil {
+		if err := s.checkSettlementAccount(*req.SettlementAccountID); err != nil {
+			return nil, err
+		}
+		biller.SettlementAccountID = *req.SettlementAccountID
+	}
+	if req.Active != nil {
+		biller.Active = *req.Active
+	}
+
+	if err := s.billerRepo.Update(biller); err != nil {
+		return nil, err
+	}
+	return biller, nil
+}
+
+func (s *billerService) ListBillers(activeOnly bool) ([]models.Biller, error) {
+	return s.billerRepo.List(activeOnly)
+}
+
+func (s *billerService) RegisterBiller(req *models.RegisterBillerRequest, customerID int) (*models.BillerRegistration, error) {
+	biller, err := s.billerRepo.GetByID(req.BillerID)
+	if err != nil {
+		return nil, err
+	}
+	if biller == nil {
+		return nil, models.ErrBillerNotFound
+	}
+	if !biller.Active {
+		return nil, models.ErrBillerInactive
+	}
+
+	consumerNumber := strings.TrimSpace(req.ConsumerNumber)
+	if biller.ConsumerNumberPattern != "" {
+		pattern, err := regexp.Compile("^(?:" + biller.ConsumerNumberPattern + ")$")
+		if err != nil {
+			return nil, err
+		}
+		if !pattern.MatchString(consumerNumber) {
+			return nil, models.ErrInvalidConsumerNumber
+		}
+	}
+
+	existing, err := s.registrationRepo.Find(customerID, biller.ID, consumerNumber)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if !existing.DeletedAt.Valid {
+			return nil, models.ErrDuplicateBillerRegistration
+		}
+		// re-registering a deleted registration brings it back
+		existing.Nickname = strings.TrimSpace(req.Nickname)
+		if err := s.registrationRepo.Restore(existing); err != nil {
+			return nil, err
+		}
+		existing.DeletedAt = gorm.DeletedAt{}
+		existing.Biller = biller
+		return existing, nil
+	}
+
+	registration := &models.BillerRegistration{
+		CustomerID:     customerID,
+		BillerID:       biller.ID,
+		ConsumerNumber: consumerNumber,
+		Nickname:       strings.TrimSpace(req.Nickname),
+	}
+	if err := s.registrationRepo.Create(registration); err != nil {
+		return nil, err
+	}
+	registration.Biller = biller
+	return registration, nil
+}
+
+func (s *billerService) ListRegistrations(customerID int) ([]models.BillerRegistration, error) {
+	return s.registrationRepo.ListByCustomerID(customerID)
+}
+
+func (s *billerService) GetRegistration(id, customerID int) (*models.BillerRegistration, error) {
+	registration, err := s.registrationRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if registration == nil || registration.CustomerID != customerID {
+		return nil, models.ErrBillerRegistrationNotFound
+	}
+	return registration, nil
+}
+
+func (s *billerService) DeleteRegistration(id, customerID int) error {
+	if _, err := s.GetRegistration(id, customerID); err != nil {
+		return err
+	}
+	return s.registrationRepo.Delete(id)
+}
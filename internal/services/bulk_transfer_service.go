@@ -0,0 +1,568 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// MaxBulkTransferRows caps the size of a single bulk upload.
+const MaxBulkTransferRows = 1000
+
+type BulkTransferService interface {
+	CreateBatch(customerID, fromAccountID int, fileName string, rows []models.BulkTransferRow, opts TransferOptions) (*models.BulkTransferBatch, error)
+	GetBatch(id, customerID int) (*models.BulkTransferBatch, error)
+	WriteReport(id, customerID int, w io.Writer) error
+	// ResumeProcessing picks up processing batches nobody has moved on
+	// for a while, e.g. after a restart or a database error, and returns
+	// how many it claimed.
+	ResumeProcessing() (int, error)
+	// WithAudit returns a copy that attributes audit entries to meta.
+	WithAudit(meta audit.Meta) BulkTransferService
+}
+
+type bulkTransferService struct {
+	db          *gorm.DB
+	repo        repositories.BulkTransferRepository
+	accountRepo repositories.AccountRepository
+	txRepo      repositories.TransactionRepository
+	fraud       FraudService
+	events      events.Recorder
+	audit       audit.Recorder
+	meta        audit.Meta
+}
+
+func NewBulkTransferService(
+	db *gorm.DB,
+	repo repositories.BulkTransferRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	fraud FraudService,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+) BulkTransferService {
+	return &bulkTransferService{
+		db:          db,
+		repo:        repo,
+		accountRepo: accountRepo,
+		txRepo:      txRepo,
+		fraud:       fraud,
+		events:      recorder,
+		audit:       auditor,
+	}
+}
+
+func (s *bulkTransferService) WithAudit(meta audit.Meta) BulkTransferService {
+	clone := *s
+	clone.meta = meta
+	return &clone
+}
+
+// ParseBulkTransferCSV reads rows from a CSV with the header
+// to_account_id,amount,reference. Malformed values are kept as zero so
+// that CreateBatch reports them together with every other invalid row.
+func ParseBulkTransferCSV(r io.Reader) ([]models.BulkTransferRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("csv must contain a header and at least one row")
+	}
+
+	header := strings.ToLower(strings.Join(records[0], ","))
+	if strings.ReplaceAll(header, " ", "") != "to_account_id,amount,reference" {
+		return nil, errors.New("csv header must be to_account_id,amount,reference")
+	}
+
+	rows := make([]models.BulkTransferRow, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		var row models.BulkTransferRow
+		if len(rec) > 0 {
+			row.ToAccountID, _ = strconv.Atoi(strings.TrimSpace(rec[0]))
+		}
+		if len(rec) > 1 {
+			row.Amount, _ = strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		}
+		if len(rec) > 2 {
+			row.Reference = strings.TrimSpace(rec[2])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// CreateBatch validates every row, reserves the batch total on the debit
+// account and starts executing the items in the background. If any row is
+// invalid nothing is reserved and a *models.BulkValidationError lists the
+// offending rows. The fraud rules see the batch total and the amount to
+// each payee; a challenge needs opts.StepUpVerified and a hold declines
+// the whole batch.
+func (s *bulkTransferService) CreateBatch(customerID, fromAccountID int, fileName string, rows []models.BulkTransferRow, opts TransferOptions) (*models.BulkTransferBatch, error) {
+	if len(rows) > MaxBulkTransferRows {
+		return nil, models.ErrBulkBatchTooLarge
+	}
+	if _, err := OwnedAccount(s.accountRepo, fromAccountID, customerID); err != nil {
+		return nil, err
+	}
+
+	var rowErrors []models.BulkRowError
+	var total float64
+	known := map[int]bool{}
+	for i, row := range rows {
+		line := i + 1
+		switch {
+		case row.ToAccountID <= 0:
+			rowErrors = append(rowErrors, models.BulkRowError{Row: line, Error: "invalid to_account_id"})
+			continue
+		case row.ToAccountID == fromAccountID:
+			rowErrors = append(rowErrors, models.BulkRowError{Row: line, Error: models.ErrSameAccount.Error()})
+			continue
+		case row.Amount <= 0:
+			rowErrors = append(rowErrors, models.BulkRowError{Row: line, Error: "amount must be greater than zero"})
+			continue
+		case len(row.Reference) > 140:
+			rowErrors = append(rowErrors, models.BulkRowError{Row: line, Error: "reference is longer than 140 characters"})
+			continue
+		}
+
+		exists, ok := known[row.ToAccountID]
+		if !ok {
+			_, err := s.accountRepo.GetByID(row.ToAccountID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			exists = err == nil
+			known[row.ToAccountID] = exists
+		}
+		if !exists {
+			rowErrors = append(rowErrors, models.BulkRowError{Row: line, Error: models.ErrAccountNotFound.Error()})
+			continue
+		}
+		total += row.Amount
+	}
+	if len(rowErrors) > 0 {
+		return nil, &models.BulkValidationError{Rows: rowErrors}
+	}
+
+	now := time.Now()
+	batch := &models.BulkTransferBatch{
+		CustomerID:    customerID,
+		FromAccountID: fromAccountID,
+		FileName:      fileName,
+		Status:        models.BulkBatchProcessing,
+		ItemCount:     len(rows),
+		TotalAmount:   total,
+		ClaimedAt:     &now,
+	}
+	for i, row := range rows {
+		batch.Items = append(batch.Items, models.BulkTransferItem{
+			LineNumber:  i + 1,
+			ToAccountID: row.ToAccountID,
+			Amount:      row.Amount,
+			Reference:   row.Reference,
+			Status:      models.BulkItemPending,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		accountRepo := s.accountRepo.WithTx(tx)
+
+		from, err := accountRepo.GetForUpdate(fromAccountID)
+		if err != nil {
+			return err
+		}
+		if from.Balance-from.HeldAmount < total {
+			return models.ErrInsufficientFunds
+		}
+		if err := s.assess(tx, fromAccountID, total, rows, opts); err != nil {
+			return err
+		}
+		from.HeldAmount += total
+		if err := accountRepo.UpdateHeldAmount(from); err != nil {
+			return err
+		}
+		if err := s.repo.WithTx(tx).CreateBatch(batch); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "bulk_transfer.create",
+			EntityType: "bulk_transfer_batch",
+			EntityID:   batch.ID,
+			After: map[string]any{
+				"from_account_id": fromAccountID,
+				"file_name":       fileName,
+				"item_count":      batch.ItemCount,
+				"total_amount":    total,
+				"held_amount":     from.HeldAmount,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.process(batch.ID)
+	return batch, nil
+}
+
+// assess runs the fraud rules once for the batch total and once for the
+// amount going to each payee, so the new-payee rules see every first
+// payment. The strictest outcome wins.
+func (s *bulkTransferService) assess(tx *gorm.DB, fromAccountID int, total float64, rows []models.BulkTransferRow, opts TransferOptions) error {
+	if s.fraud == nil {
+		return nil
+	}
+
+	inputs := []FraudInput{{Operation: models.FraudOpTransfer, AccountID: fromAccountID, Amount: total}}
+	perPayee := map[int]int{}
+	for _, row := range rows {
+		i, ok := perPayee[row.ToAccountID]
+		if !ok {
+			i = len(inputs)
+			perPayee[row.ToAccountID] = i
+			inputs = append(inputs, FraudInput{Operation: models.FraudOpTransfer, AccountID: fromAccountID, ToAccountID: row.ToAccountID})
+		}
+		inputs[i].Amount += row.Amount
+	}
+
+	var challenged []string
+	for _, in := range inputs {
+		decision, err := s.fraud.Assess(tx, in)
+		if err != nil {
+			return err
+		}
+		switch decision.Action {
+		case models.FraudHold:
+			return models.ErrSuspectedFraud
+		case models.FraudChallenge:
+			challenged = append(challenged, decision.Rules...)
+		}
+	}
+	if len(challenged) > 0 && !opts.StepUpVerified {
+		return &models.StepUpRequiredError{Rules: challenged}
+	}
+	return nil
+}
+
+// bulkClaimTimeout is how long a batch may go without an item being
+// processed before ResumeProcessing assumes its processor died.
+const bulkClaimTimeout = 5 * time.Minute
+
+// process executes the pending items of a batch one by one. Each item is
+// its own database transaction that moves the money and releases the
+// item's share of the hold, so an interrupted batch can simply be resumed.
+// A database error fails the rest of the batch rather than leaving its
+// funds held. The caller must hold the claim on the batch.
+func (s *bulkTransferService) process(batchID int) {
+	items, err := s.repo.ListPendingItems(batchID)
+	if err != nil {
+		log.Printf("bulk batch %d: %v", batchID, err)
+		return
+	}
+
+	batch, err := s.repo.GetBatch(batchID)
+	if err != nil || batch == nil {
+		log.Printf("bulk batch %d: cannot load batch: %v", batchID, err)
+		return
+	}
+
+	for _, item := range items {
+		if err := s.executeItem(batch.FromAccountID, item.ID); err != nil {
+			log.Printf("bulk batch %d item %d: %v", batchID, item.ID, err)
+			if err := s.abort(batch.FromAccountID, batchID); err != nil {
+				// still processing, so ResumeProcessing picks it up once
+				// the claim goes stale
+				log.Printf("bulk batch %d: cannot fail remaining items: %v", batchID, err)
+				return
+			}
+			break
+		}
+		if err := s.repo.TouchBatch(batchID, time.Now()); err != nil {
+			log.Printf("bulk batch %d: renew claim: %v", batchID, err)
+		}
+	}
+
+	if err := s.finish(batchID); err != nil {
+		log.Printf("bulk batch %d: %v", batchID, err)
+	}
+}
+
+func (s *bulkTransferService) executeItem(fromAccountID, itemID int) error {
+	// first try to post the transfer; on a business failure, release the
+	// hold and mark the item failed in a separate transaction
+	var itemErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		accountRepo := s.accountRepo.WithTx(tx)
+
+		item, err := repo.GetItemForUpdate(itemID)
+		if err != nil {
+			return err
+		}
+		if item.Status != models.BulkItemPending {
+			return nil
+		}
+
+		from, err := accountRepo.GetForUpdate(fromAccountID)
+		if err != nil {
+			return err
+		}
+		to, err := accountRepo.GetForUpdate(item.ToAccountID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				itemErr = models.ErrAccountNotFound
+				return itemErr
+			}
+			return err
+		}
+		before := map[string]any{"from_balance": from.Balance, "to_balance": to.Balance}
+
+		from.Balance -= item.Amount
+		from.HeldAmount -= item.Amount
+		to.Balance += item.Amount
+		if err := accountRepo.UpdateBalance(from); err != nil {
+			return err
+		}
+		if err := accountRepo.UpdateHeldAmount(from); err != nil {
+			return err
+		}
+		if err := accountRepo.UpdateBalance(to); err != nil {
+			return err
+		}
+
+		record := &models.Transaction{FromAccountID: &fromAccountID, ToAccountID: &item.ToAccountID, Amount: item.Amount}
+		if err := s.txRepo.WithTx(tx).Create(record); err != nil {
+			return err
+		}
+
+		item.Status = models.BulkItemCompleted
+		item.TransactionID = &record.ID
+		if err := repo.UpdateItem(item); err != nil {
+			return err
+		}
+
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "bulk_transfer.item",
+			EntityType: "transaction",
+			EntityID:   record.ID,
+			Before:     before,
+			After: map[string]any{
+				"batch_id":        item.BatchID,
+				"line_number":     item.LineNumber,
+				"from_account_id": fromAccountID,
+				"to_account_id":   item.ToAccountID,
+				"amount":          item.Amount,
+				"reference":       item.Reference,
+				"from_balance":    from.Balance,
+				"to_balance":      to.Balance,
+			},
+		}); err != nil {
+			return err
+		}
+		if err := s.events.Record(tx, events.New(events.TransferCompleted, from.CustomerID, map[string]any{
+			"transaction_id":  record.ID,
+			"from_account_id": fromAccountID,
+			"to_account_id":   item.ToAccountID,
+			"amount":          item.Amount,
+			"currency":        from.Currency,
+			"balance":         from.Balance,
+			"bulk_batch_id":   item.BatchID,
+			"reference":       item.Reference,
+		})); err != nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.TransferReceived, to.CustomerID, map[string]any{
+			"transaction_id":  record.ID,
+			"from_account_id": fromAccountID,
+			"to_account_id":   item.ToAccountID,
+			"amount":          item.Amount,
+			"currency":        to.Currency,
+			"balance":         to.Balance,
+			"reference":       item.Reference,
+		}))
+	})
+	if itemErr == nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		item, err := s.repo.WithTx(tx).GetItemForUpdate(itemID)
+		if err != nil {
+			return err
+		}
+		if item.Status != models.BulkItemPending {
+			return nil
+		}
+
+		from, err := s.accountRepo.WithTx(tx).GetForUpdate(fromAccountID)
+		if err != nil {
+			return err
+		}
+		return s.failItem(tx, from, item, itemErr)
+	})
+}
+
+// failItem releases the item's share of the hold on the locked debit
+// account and marks it failed.
+func (s *bulkTransferService) failItem(tx *gorm.DB, from *models.Account, item *models.BulkTransferItem, cause error) error {
+	from.HeldAmount -= item.Amount
+	if err := s.accountRepo.WithTx(tx).UpdateHeldAmount(from); err != nil {
+		return err
+	}
+
+	item.Status = models.BulkItemFailed
+	item.Error = cause.Error()
+	if err := s.repo.WithTx(tx).UpdateItem(item); err != nil {
+		return err
+	}
+	return s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "bulk_transfer.item_failed",
+		EntityType: "bulk_transfer_item",
+		EntityID:   item.ID,
+		After: map[string]any{
+			"batch_id":        item.BatchID,
+			"line_number":     item.LineNumber,
+			"from_account_id": from.ID,
+			"to_account_id":   item.ToAccountID,
+			"amount":          item.Amount,
+			"error":           item.Error,
+			"held_amount":     from.HeldAmount,
+		},
+	})
+}
+
+// errBulkBatchStopped is what the report shows for items abort failed;
+// the cause itself is only logged.
+var errBulkBatchStopped = errors.New("not executed: the batch stopped on an internal error")
+
+// abort fails every item still pending after the batch stopped, releasing
+// what is left of the hold in one transaction.
+func (s *bulkTransferService) abort(fromAccountID, batchID int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		from, err := s.accountRepo.WithTx(tx).GetForUpdate(fromAccountID)
+		if err != nil {
+			return err
+		}
+		items, err := repo.ListPendingItems(batchID)
+		if err != nil {
+			return err
+		}
+		for _, pending := range items {
+			item, err := repo.GetItemForUpdate(pending.ID)
+			if err != nil {
+				return err
+			}
+			if item.Status != models.BulkItemPending {
+				continue
+			}
+			if err := s.failItem(tx, from, item, errBulkBatchStopped); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *bulkTransferService) finish(batchID int) error {
+	batch, err := s.repo.GetBatch(batchID)
+	if err != nil {
+		return err
+	}
+
+	batch.SucceededCount, batch.FailedCount = 0, 0
+	for _, item := range batch.Items {
+		switch item.Status {
+		case models.BulkItemCompleted:
+			batch.SucceededCount++
+		case models.BulkItemFailed:
+			batch.FailedCount++
+		}
+	}
+
+	switch {
+	case batch.FailedCount == 0:
+		batch.Status = models.BulkBatchCompleted
+	case batch.SucceededCount == 0:
+		batch.Status = models.BulkBatchFailed
+	default:
+		batch.Status = models.BulkBatchPartiallyCompleted
+	}
+	now := time.Now()
+	batch.CompletedAt = &now
+	return s.repo.UpdateBatch(batch)
+}
+
+func (s *bulkTransferService) ResumeProcessing() (int, error) {
+	staleBefore := time.Now().Add(-bulkClaimTimeout)
+	ids, err := s.repo.ListStaleBatchIDs(staleBefore)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, id := range ids {
+		claimed, err := s.repo.ClaimBatch(id, staleBefore, time.Now())
+		if err != nil {
+			return count, err
+		}
+		if !claimed {
+			continue
+		}
+		s.process(id)
+		count++
+	}
+	return count, nil
+}
+
+func (s *bulkTransferService) GetBatch(id, customerID int) (*models.BulkTransferBatch, error) {
+	batch, err := s.repo.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil || batch.CustomerID != customerID {
+		return nil, models.ErrBulkBatchNotFound
+	}
+	return batch, nil
+}
+
+// WriteReport writes the per-row results of a batch as CSV.
+func (s *bulkTransferService) WriteReport(id, customerID int, w io.Writer) error {
+	batch, err := s.GetBatch(id, customerID)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"line", "to_account_id", "amount", "reference", "status", "transaction_id", "error"})
+	for _, item := range batch.Items {
+		txID := ""
+		if item.TransactionID != nil {
+			txID = strconv.Itoa(*item.TransactionID)
+		}
+		cw.Write([]string{
+			strconv.Itoa(item.LineNumber),
+			strconv.Itoa(item.ToAccountID),
+			strconv.FormatFloat(item.Amount, 'f', 2, 64),
+			item.Reference,
+			item.Status,
+			txID,
+			item.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
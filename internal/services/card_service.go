@@ -0,0 +1,533 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/cards"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// CardConfig configures card issuing and settlement.
+type CardConfig struct {
+	// BIN is the issuer prefix for new PANs.
+	BIN string
+	// HashKey keys the PAN and CVV hashes; cards are disabled without it.
+	HashKey []byte
+	// ValidityYears is how long a new card is valid.
+	ValidityYears int
+	// SettlementAccountID receives captured amounts; 0 only debits the customer.
+	SettlementAccountID int
+}
+
+type CardService interface {
+	IssueCard(req *models.IssueCardRequest, customerID, accountID int) (*models.IssuedCard, error)
+	ListCards(customerID int) ([]models.Card, error)
+	GetCard(id, customerID int) (*models.Card, error)
+	UpdateCard(id, customerID int, req *models.UpdateCardRequest) (*models.Card, error)
+	BlockCard(id, customerID int) (*models.Card, error)
+	UnblockCard(id, customerID int) (*models.Card, error)
+	ListAuthorizations(cardID, customerID int) ([]models.CardAuthorization, error)
+	ExpireCards() (int64, error)
+	// CardForPAN finds the card with the given number for card-present
+	// channels that do their own checks, marking it expired if it is.
+	CardForPAN(pan string) (*models.Card, error)
+	// SpentSince sums the card's approved and captured authorizations
+	// since the given time, for channels enforcing the daily limit.
+	SpentSince(cardID int, since time.Time) (float64, error)
+
+	Authorize(req *models.CardAuthorizationRequest) (*models.CardAuthorization, error)
+	Capture(authID int, amount float64) (*models.CardAuthorization, error)
+	Reverse(authID int) (*models.CardAuthorization, error)
+}
+
+type cardService struct {
+	db          *gorm.DB
+	repo        repositories.CardRepository
+	authRepo    repositories.CardAuthorizationRepository
+	accountRepo repositories.AccountRepository
+	txRepo      repositories.TransactionRepository
+	customers   repositories.CustomerRepository
+	fraud       FraudService
+	kyc         KYCService
+	config      CardConfig
+}
+
+// NewCardService builds the service. fraud and kyc may be nil, in which
+// case authorizations are not screened or held to the KYC limits.
+func NewCardService(
+	db *gorm.DB,
+	repo repositories.CardRepository,
+	authRepo repositories.CardAuthorizationRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	customers repositories.CustomerRepository,
+	fraud FraudService,
+	kyc KYCService,
+	config CardConfig,
+) CardService {
+	return &cardService{
+		db:          db,
+		repo:        repo,
+		authRepo:    authRepo,
+		accountRepo: accountRepo,
+		txRepo:      txRepo,
+		customers:   customers,
+		fraud:       fraud,
+		kyc:         kyc,
+		config:      config,
+	}
+}
+
+func (s *cardService) IssueCard(req *models.IssueCardRequest, customerID, accountID int) (*models.IssuedCard, error) {
+	if len(s.config.HashKey) == 0 {
+		return nil, models.ErrCardsNotConfigured
+	}
+	if _, err := OwnedAccount(s.accountRepo, accountID, customerID); err != nil {
+		return nil, err
+	}
+
+	pan, err := cards.GeneratePAN(s.config.BIN)
+	if err != nil {
+		return nil, err
+	}
+	cvv, err := cards.RandomDigits(3)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := time.Now().AddDate(s.config.ValidityYears, 0, 0)
+	card := &models.Card{
+		AccountID:           accountID,
+		CustomerID:          customerID,
+		PANHash:             cards.Hash(s.config.HashKey, pan),
+		MaskedPAN:           cards.MaskPAN(pan),
+		Last4:               pan[len(pan)-4:],
+		CVVHash:             cards.Hash(s.config.HashKey, pan, cvv),
+		ExpiryMonth:         int(expiry.Month()),
+		ExpiryYear:          expiry.Year(),
+		CardholderName:      strings.ToUpper(strings.TrimSpace(req.CardholderName)),
+		Status:              models.CardStatusActive,
+		PerTransactionLimit: req.PerTransactionLimit,
+		DailyLimit:          req.DailyLimit,
+	}
+	if err := s.repo.Create(card); err != nil {
+		return nil, err
+	}
+	return &models.IssuedCard{Card: card, PAN: pan, CVV: cvv}, nil
+}
+
+func (s *cardService) ListCards(customerID int) ([]models.Card, error) {
+	return s.repo.ListByCustomerID(customerID)
+}
+
+func (s *cardService) GetCard(id, customerID int) (*models.Card, error) {
+	card, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil || card.CustomerID != customerID {
+		return nil, models.ErrCardNotFound
+	}
+	return card, nil
+}
+
+func (s *cardService) UpdateCard(id, customerID int, req *models.UpdateCardRequest) (*models.Card, error) {
+	card, err := s.GetCard(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PerTransactionLimit != nil {
+		card.PerTransactionLimit = *req.PerTransactionLimit
+	}
+	if req.DailyLimit != nil {
+		card.DailyLimit = *req.DailyLimit
+	}
+	if req.AllowedMCCs != nil {
+		list, ok := cards.NormalizeMCCList(*req.AllowedMCCs)
+		if !ok {
+			return nil, models.ErrInvalidMCCList
+		}
+		card.AllowedMCCs = list
+	}
+	if req.BlockedMCCs != nil {
+		list, ok := cards.NormalizeMCCList(*req.BlockedMCCs)
+		if !ok {
+			return nil, models.ErrInvalidMCCList
+		}
+		card.BlockedMCCs = list
+	}
+
+	if err := s.repo.Update(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) BlockCard(id, customerID int) (*models.Card, error) {
+	card, err := s.GetCard(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != models.CardStatusActive {
+		return nil, models.ErrCardNotBlockable
+	}
+	card.Status = models.CardStatusBlocked
+	if err := s.repo.Update(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) UnblockCard(id, customerID int) (*models.Card, error) {
+	card, err := s.GetCard(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != models.CardStatusBlocked {
+		return nil, models.ErrCardNotUnblockable
+	}
+	if cardExpired(card, time.Now()) {
+		card.Status = models.CardStatusExpired
+	} else {
+		card.Status = models.CardStatusActive
+	}
+	if err := s.repo.Update(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) ListAuthorizations(cardID, customerID int) ([]models.CardAuthorization, error) {
+	if _, err := s.GetCard(cardID, customerID); err != nil {
+		return nil, err
+	}
+	return s.authRepo.ListByCardID(cardID)
+}
+
+func (s *cardService) ExpireCards() (int64, error) {
+	return s.repo.ExpireDue(time.Now())
+}
+
+func (s *cardService) SpentSince(cardID int, since time.Time) (float64, error) {
+	return s.authRepo.SpentSince(cardID, since)
+}
+
+func (s *cardService) CardForPAN(pan string) (*models.Card, error) {
+	if len(s.config.HashKey) == 0 {
+		return nil, models.ErrCardsNotConfigured
+	}
+	card, err := s.repo.GetByPANHash(cards.Hash(s.config.HashKey, cards.NormalizePAN(pan)))
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, models.ErrCardNotFound
+	}
+	if card.Status == models.CardStatusActive && cardExpired(card, time.Now()) {
+		card.Status = models.CardStatusExpired
+		if err := s.repo.Update(card); err != nil {
+			return nil, err
+		}
+	}
+	return card, nil
+}
+
+// cardExpired reports whether the card is past the end of its expiry month.
+func cardExpired(card *models.Card, now time.Time) bool {
+	end := time.Date(card.ExpiryYear, time.Month(card.ExpiryMonth)+1, 1, 0, 0, 0, 0, now.Location())
+	return !now.Before(end)
+}
+
+// Authorize checks a network authorization request against the card and
+// its account and, if approved, places a hold for the amount. Repeating a
+// request with the same NetworkReference returns the original outcome.
+func (s *cardService) Authorize(req *models.CardAuthorizationRequest) (*models.CardAuthorization, error) {
+	if len(s.config.HashKey) == 0 {
+		return nil, models.ErrCardsNotConfigured
+	}
+
+	existing, err := s.authRepo.GetByNetworkReference(req.NetworkReference)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	pan := cards.NormalizePAN(req.PAN)
+	if !cards.LuhnValid(pan) {
+		return &models.CardAuthorization{Status: models.CardAuthDeclined, DeclineReason: "invalid card number", NetworkReference: req.NetworkReference}, nil
+	}
+	card, err := s.repo.GetByPANHash(cards.Hash(s.config.HashKey, pan))
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return &models.CardAuthorization{Status: models.CardAuthDeclined, DeclineReason: "unknown card", NetworkReference: req.NetworkReference}, nil
+	}
+
+	auth := &models.CardAuthorization{
+		CardID:               card.ID,
+		AccountID:            card.AccountID,
+		NetworkReference:     req.NetworkReference,
+		Amount:               req.Amount,
+		Currency:             strings.ToUpper(req.Currency),
+		MerchantName:         req.MerchantName,
+		MerchantCategoryCode: req.MerchantCategoryCode,
+		Status:               models.CardAuthDeclined,
+	}
+
+	reason, err := s.checkCard(card, pan, req)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		auth.DeclineReason = reason
+		if err := s.authRepo.Create(auth); err != nil {
+			return nil, err
+		}
+		return auth, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		accountRepo := s.accountRepo.WithTx(tx)
+		account, err := accountRepo.GetForUpdate(card.AccountID)
+		if err != nil {
+			return err
+		}
+
+		// the network cannot challenge the cardholder or wait for review,
+		// so anything but allow is declined
+		suspect := false
+		if s.fraud != nil {
+			decision, err := s.fraud.Assess(tx, FraudInput{
+				Operation: models.FraudOpWithdrawal,
+				AccountID: account.ID,
+				Amount:    auth.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			suspect = decision.Action != models.FraudAllow
+		}
+
+		switch {
+		case account.Currency != "" && !strings.EqualFold(account.Currency, auth.Currency):
+			auth.DeclineReason = "currency not supported"
+		case account.Balance-account.HeldAmount < auth.Amount:
+			auth.DeclineReason = models.ErrInsufficientFunds.Error()
+		case suspect:
+			auth.DeclineReason = models.ErrSuspectedFraud.Error()
+		default:
+			account.HeldAmount += auth.Amount
+			if err := accountRepo.UpdateHeldAmount(account); err != nil {
+				return err
+			}
+			code, err := cards.RandomDigits(6)
+			if err != nil {
+				return err
+			}
+			auth.Status = models.CardAuthApproved
+			auth.AuthCode = code
+		}
+		return s.authRepo.WithTx(tx).Create(auth)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// checkCard returns a decline reason, or "" if the card may be used for req.
+func (s *cardService) checkCard(card *models.Card, pan string, req *models.CardAuthorizationRequest) (string, error) {
+	now := time.Now()
+	if card.Status == models.CardStatusActive && cardExpired(card, now) {
+		card.Status = models.CardStatusExpired
+		if err := s.repo.Update(card); err != nil {
+			return "", err
+		}
+	}
+
+	switch card.Status {
+	case models.CardStatusBlocked:
+		return "card blocked", nil
+	case models.CardStatusExpired:
+		return "expired card", nil
+	}
+
+	if req.Expiry != fmt.Sprintf("%02d/%02d", card.ExpiryMonth, card.ExpiryYear%100) {
+		return "expiry date mismatch", nil
+	}
+	if req.CVV != "" && cards.Hash(s.config.HashKey, pan, req.CVV) != card.CVVHash {
+		return "invalid CVV", nil
+	}
+	if !cards.MCCAllowed(req.MerchantCategoryCode, card.AllowedMCCs, card.BlockedMCCs) {
+		return "merchant category not allowed", nil
+	}
+	if card.PerTransactionLimit > 0 && req.Amount > card.PerTransactionLimit {
+		return "exceeds per-transaction limit", nil
+	}
+	if card.DailyLimit > 0 {
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		spent, err := s.authRepo.SpentSince(card.ID, startOfDay)
+		if err != nil {
+			return "", err
+		}
+		if spent+req.Amount > card.DailyLimit {
+			return "exceeds daily limit", nil
+		}
+	}
+	err := CheckScreening(s.customers, card.CustomerID)
+	if screeningDecline(err) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if s.kyc != nil {
+		err := s.kyc.CheckTransfer(card.CustomerID, req.Amount)
+		if errors.Is(err, models.ErrKYCLimitExceeded) || errors.Is(err, models.ErrKYCRejected) {
+			return err.Error(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// Capture posts an approved authorization to the account, releasing the
+// hold. amount of 0 captures the full authorized amount; a smaller amount
+// releases the difference.
+func (s *cardService) Capture(authID int, amount float64) (*models.CardAuthorization, error) {
+	var auth *models.CardAuthorization
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		authRepo := s.authRepo.WithTx(tx)
+		accountRepo := s.accountRepo.WithTx(tx)
+
+		var err error
+		auth, err = authRepo.GetForUpdate(authID)
+		if err != nil {
+			return err
+		}
+		if auth == nil {
+			return models.ErrCardAuthorizationNotFound
+		}
+		if auth.Status != models.CardAuthApproved {
+			return models.ErrCardAuthorizationState
+		}
+		if amount == 0 {
+			amount = auth.Amount
+		}
+		if amount > auth.Amount {
+			return models.ErrCaptureExceedsAuthorized
+		}
+
+		account, err := accountRepo.GetForUpdate(auth.AccountID)
+		if err != nil {
+			return err
+		}
+		account.HeldAmount -= auth.Amount
+		account.Balance -= amount
+		if err := accountRepo.UpdateHeldAmount(account); err != nil {
+			return err
+		}
+		if err := accountRepo.UpdateBalance(account); err != nil {
+			return err
+		}
+
+		record := &models.Transaction{FromAccountID: &auth.AccountID, Amount: amount}
+		if s.config.SettlementAccountID != 0 {
+			settlement, err := accountRepo.GetForUpdate(s.config.SettlementAccountID)
+			if err != nil {
+				return err
+			}
+			settlement.Balance += amount
+			if err := accountRepo.UpdateBalance(settlement); err != nil {
+				return err
+			}
+			record.ToAccountID = &settlement.ID
+		}
+		if err := s.txRepo.WithTx(tx).Create(record); err != nil {
+			return err
+		}
+
+		auth.Status = models.CardAuthCaptured
+		auth.CapturedAmount = amount
+		auth.TransactionID = &record.ID
+		return authRepo.Update(auth)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// Reverse undoes an authorization: an approved one releases its hold, a
+// captured one is refunded to the account.
+func (s *cardService) Reverse(authID int) (*models.CardAuthorization, error) {
+	var auth *models.CardAuthorization
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		authRepo := s.authRepo.WithTx(tx)
+		accountRepo := s.accountRepo.WithTx(tx)
+
+		var err error
+		auth, err = authRepo.GetForUpdate(authID)
+		if err != nil {
+			return err
+		}
+		if auth == nil {
+			return models.ErrCardAuthorizationNotFound
+		}
+
+		account, err := accountRepo.GetForUpdate(auth.AccountID)
+		if err != nil {
+			return err
+		}
+
+		switch auth.Status {
+		case models.CardAuthApproved:
+			account.HeldAmount -= auth.Amount
+			if err := accountRepo.UpdateHeldAmount(account); err != nil {
+				return err
+			}
+
+		case models.CardAuthCaptured:
+			record := &models.Transaction{ToAccountID: &auth.AccountID, Amount: auth.CapturedAmount}
+			if s.config.SettlementAccountID != 0 {
+				settlement, err := accountRepo.GetForUpdate(s.config.SettlementAccountID)
+				if err != nil {
+					return err
+				}
+				settlement.Balance -= auth.CapturedAmount
+				if err := accountRepo.UpdateBalance(settlement); err != nil {
+					return err
+				}
+				record.FromAccountID = &settlement.ID
+			}
+			account.Balance += auth.CapturedAmount
+			if err := accountRepo.UpdateBalance(account); err != nil {
+				return err
+			}
+			if err := s.txRepo.WithTx(tx).Create(record); err != nil {
+				return err
+			}
+
+		default:
+			return models.ErrCardAuthorizationState
+		}
+
+		auth.Status = models.CardAuthReversed
+		return authRepo.Update(auth)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
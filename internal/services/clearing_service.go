@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/iso20022"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/paymentrail"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// ClearingFileRail is the rail name recorded on payments sent by pain.001 file.
+const ClearingFileRail = "pain.001"
+
+// StatusImportResult summarises what a pain.002/pacs.002 import changed.
+type StatusImportResult struct {
+	MessageID string   `json:"message_id"`
+	Accepted  int      `json:"accepted"`
+	Rejected  int      `json:"rejected"`
+	Pending   int      `json:"pending"`
+	Unmatched []string `json:"unmatched"`
+	Errors    []string `json:"errors"`
+}
+
+// BankIdentity is how this bank appears in outgoing clearing files.
+type BankIdentity struct {
+	Name string
+	BIC  string
+}
+
+type ClearingService interface {
+	ExportPain001(w io.Writer, limit int) (*models.ClearingFile, error)
+	// WritePain001 writes an exported file again, e.g. after its first
+	// write failed.
+	WritePain001(w io.Writer, messageID string) (*models.ClearingFile, error)
+	ImportStatusReport(r io.Reader) (*StatusImportResult, error)
+}
+
+type clearingService struct {
+	db          *gorm.DB
+	fileRepo    repositories.ClearingFileRepository
+	paymentRepo repositories.OutgoingPaymentRepository
+	paymentSvc  OutgoingPaymentService
+	bank        BankIdentity
+}
+
+func NewClearingService(
+	db *gorm.DB,
+	fileRepo repositories.ClearingFileRepository,
+	paymentRepo repositories.OutgoingPaymentRepository,
+	paymentSvc OutgoingPaymentService,
+	bank BankIdentity,
+) ClearingService {
+	return &clearingService{db: db, fileRepo: fileRepo, paymentRepo: paymentRepo, paymentSvc: paymentSvc, bank: bank}
+}
+
+func endToEndID(paymentID int) string {
+	return fmt.Sprintf("OP%010d", paymentID)
+}
+
+// ExportPain001 marks up to limit initiated payments submitted in a
+// pain.001 file and writes it to w. If nothing is pending it writes
+// nothing and returns a nil file. The file is only written once the
+// payments are committed, so a failed commit sends nothing; if the write
+// fails, WritePain001 writes the recorded file again.
+func (s *clearingService) ExportPain001(w io.Writer, limit int) (*models.ClearingFile, error) {
+	var file *models.ClearingFile
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		paymentRepo := s.paymentRepo.WithTx(tx)
+
+		locked, err := paymentRepo.LockByStatus(models.PaymentStatusInitiated, limit)
+		if err != nil {
+			return err
+		}
+		payments := locked[:0]
+		for _, p := range locked {
+			if p.Beneficiary == nil {
+				log.Printf("outgoing payment %d: beneficiary %d is gone, not exporting", p.ID, p.BeneficiaryID)
+				continue
+			}
+			payments = append(payments, p)
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		msg := &iso20022.Pain001{
+			MessageID:      fmt.Sprintf("PAIN001-%s%03d", now.UTC().Format("20060102150405"), now.Nanosecond()/int(time.Millisecond)),
+			CreatedAt:      now,
+			InitiatingName: s.bank.Name,
+			DebtorAgentBIC: s.bank.BIC,
+			ExecutionDate:  now,
+		}
+
+		batches := map[int]*iso20022.DebtorBatch{}
+		var order []int
+		var total float64
+		for _, p := range payments {
+			b, ok := batches[p.AccountID]
+			if !ok {
+				b = &iso20022.DebtorBatch{
+					PaymentInfoID: fmt.Sprintf("%s-%d", msg.MessageID, p.AccountID),
+					DebtorAcct:    models.FormatAccountNumber(p.AccountID),
+				}
+				if p.Account != nil {
+					b.DebtorName = p.Account.Owner
+				}
+				batches[p.AccountID] = b
+				order = append(order, p.AccountID)
+			}
+			b.Transfers = append(b.Transfers, iso20022.CreditTransfer{
+				EndToEndID:    endToEndID(p.ID),
+				Amount:        p.Amount,
+				Currency:      p.Currency,
+				CreditorName:  p.Beneficiary.Name,
+				CreditorAcct:  p.Beneficiary.AccountNumber,
+				CreditorAgent: p.Beneficiary.BankCode,
+				Remittance:    p.Reference,
+			})
+			total += p.Amount
+		}
+		for _, id := range order {
+			msg.Batches = append(msg.Batches, *batches[id])
+		}
+
+		var buf bytes.Buffer
+		if err := msg.Write(&buf); err != nil {
+			return err
+		}
+		file = &models.ClearingFile{
+			MessageID:  msg.MessageID,
+			Type:       "pain.001",
+			Direction:  models.ClearingDirectionOutbound,
+			EntryCount: len(payments),
+			ControlSum: total,
+			Content:    buf.String(),
+		}
+		if err := s.fileRepo.WithTx(tx).Create(file); err != nil {
+			return err
+		}
+
+		for i := range payments {
+			p := &payments[i]
+			p.Status = models.PaymentStatusSubmitted
+			p.Rail = ClearingFileRail
+			p.RailReference = endToEndID(p.ID)
+			p.ClearingFileID = &file.ID
+			p.SubmittedAt = &now
+			if err := paymentRepo.Update(p); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil || file == nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, file.Content); err != nil {
+		return file, fmt.Errorf("write %s: %w", file.MessageID, err)
+	}
+	return file, nil
+}
+
+func (s *clearingService) WritePain001(w io.Writer, messageID string) (*models.ClearingFile, error) {
+	file, err := s.fileRepo.GetByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || file.Direction != models.ClearingDirectionOutbound || file.Content == "" {
+		return nil, fmt.Errorf("no exported file %q", messageID)
+	}
+	if _, err := io.WriteString(w, file.Content); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ImportStatusReport applies a pain.002 or pacs.002 report: accepted
+// payments are settled, rejected ones are returned and re-credited to the
+// customer. A report is recorded only once every entry was applied;
+// importing a recorded report again fails with
+// models.ErrFileAlreadyImported, while one with failed entries can be
+// imported again to retry them, entries already applied being skipped.
+func (s *clearingService) ImportStatusReport(r io.Reader) (*StatusImportResult, error) {
+	report, err := iso20022.ParseStatusReport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.fileRepo.GetByMessageID(report.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, models.ErrFileAlreadyImported
+	}
+
+	statuses := report.Transactions
+	if len(statuses) == 0 && report.GroupStatus == iso20022.StatusRJCT {
+		// whole file rejected: expand to every payment of the original message
+		original, err := s.fileRepo.GetByMessageID(report.OriginalMessageID)
+		if err != nil {
+			return nil, err
+		}
+		if original == nil {
+			return nil, fmt.Errorf("original message %q not found", report.OriginalMessageID)
+		}
+		payments, err := s.paymentRepo.ListByClearingFileID(original.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range payments {
+			statuses = append(statuses, iso20022.TransactionStatus{
+				OriginalEndToEndID: p.RailReference,
+				Status:             iso20022.StatusRJCT,
+			})
+		}
+	}
+
+	result := &StatusImportResult{MessageID: report.MessageID}
+	for _, st := range statuses {
+		update := paymentrail.StatusUpdate{RailReference: st.OriginalEndToEndID}
+		switch {
+		case iso20022.IsAccepted(st.Status):
+			update.Status = models.PaymentStatusSettled
+		case st.Status == iso20022.StatusRJCT:
+			update.Status = models.PaymentStatusReturned
+			update.Reason = st.ReasonCode
+			if st.AdditionalInfo != "" {
+				update.Reason += " " + st.AdditionalInfo
+			}
+		default:
+			result.Pending++
+			continue
+		}
+
+		if err := s.paymentSvc.ApplyStatusUpdate(update); err != nil {
+			if errors.Is(err, models.ErrPaymentNotFound) {
+				result.Unmatched = append(result.Unmatched, st.OriginalEndToEndID)
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", st.OriginalEndToEndID, err))
+			}
+			continue
+		}
+		if update.Status == models.PaymentStatusSettled {
+			result.Accepted++
+		} else {
+			result.Rejected++
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+	err = s.fileRepo.Create(&models.ClearingFile{
+		MessageID:  report.MessageID,
+		Type:       report.MessageType,
+		Direction:  models.ClearingDirectionInbound,
+		EntryCount: len(statuses),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+	"gorm.io/gorm"
+)
+
+type ConsentService interface {
+	// Request records a consent a third-party provider asks for. It waits
+	// for a customer to authorize it.
+	Request(client *models.APIClient, req *models.CreateConsentRequest) (*models.Consent, error)
+	GetForProvider(clientID int, id string) (*models.Consent, error)
+	// RevokeForProvider lets the provider give up a consent it holds.
+	RevokeForProvider(clientID int, id string) error
+
+	// GetForCustomer returns a consent the customer decided on, or one
+	// still awaiting a decision; the provider passes its ID to the
+	// customer to authorize.
+	GetForCustomer(customerID int, id string) (*models.Consent, error)
+	ListForCustomer(customerID int) ([]models.Consent, error)
+	// Authorize grants the consent over the customer's chosen accounts.
+	Authorize(customerID int, id string, accountIDs []int) (*models.Consent, error)
+	Reject(customerID int, id string) (*models.Consent, error)
+	Revoke(customerID int, id string) error
+
+	// Token issues the provider a token bound to an authorized consent.
+	Token(client *models.APIClient, id string) (*models.ClientToken, error)
+	// Check returns the consent if a token of the client bound to it may
+	// still be used.
+	Check(clientID int, id string) (*models.Consent, error)
+	// Expire marks consents past their expiry as expired.
+	Expire() (int64, error)
+
+	WithAudit(meta audit.Meta) ConsentService
+}
+
+// ConsentConfig caps how long a consent may last and how long a token
+// bound to one lasts.
+type ConsentConfig struct {
+	MaxValidity time.Duration
+	TokenTTL    time.Duration
+}
+
+type consentService struct {
+	db       *gorm.DB
+	repo     repositories.ConsentRepository
+	accounts repositories.AccountRepository
+	audit    audit.Recorder
+	meta     audit.Meta
+	cfg      ConsentConfig
+}
+
+func NewConsentService(
+	db *gorm.DB,
+	repo repositories.ConsentRepository,
+	accounts repositories.AccountRepository,
+	auditor audit.Recorder,
+	cfg ConsentConfig,
+) ConsentService {
+	return &consentService{
+		db:       db,
+		repo:     repo,
+		accounts: accounts,
+		audit:    auditor,
+		cfg:      cfg,
+	}
+}
+
+func (s *consentService) WithAudit(meta audit.Meta) ConsentService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+// validConsentPermissions checks the permissions exist and returns them
+// sorted without duplicates.
+func validConsentPermissions(permissions []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, p := range models.AllConsentPermissions {
+		known[p] = true
+	}
+	seen := map[string]bool{}
+	var valid []string
+	for _, p := range permissions {
+		if !known[p] {
+			return nil, fmt.Errorf("%w: unknown permission %s", models.ErrInvalidConsent, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			valid = append(valid, p)
+		}
+	}
+	sort.Strings(valid)
+	return valid, nil
+}
+
+func (s *consentService) Request(client *models.APIClient, req *models.CreateConsentRequest) (*models.Consent, error) {
+	if !client.Provider() {
+		return nil, models.ErrNotThirdPartyProvider
+	}
+	permissions, err := validConsentPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(s.cfg.MaxValidity)) {
+		return nil, fmt.Errorf("%w: expires_at must be in the next %s", models.ErrInvalidConsent, s.cfg.MaxValidity)
+	}
+	consent := &models.Consent{
+		ClientID:    client.ID,
+		Status:      models.ConsentAwaitingAuthorization,
+		Permissions: permissions,
+		AccountIDs:  []int{},
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if consent.Allows(models.ConsentPayments) {
+		if req.MaxPaymentAmount <= 0 {
+			return nil, fmt.Errorf("%w: payments need max_payment_amount", models.ErrInvalidConsent)
+		}
+		consent.MaxPaymentAmount = req.MaxPaymentAmount
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	consent.ID = hex.EncodeToString(id[:])
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(consent); err != nil {
+			return err
+		}
+		if err := repo.SetPermissions(consent.ID, permissions); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "consent.request",
+			EntityType: "consent",
+			After:      consent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+func (s *consentService) GetForProvider(clientID int, id string) (*models.Consent, error) {
+	consent, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if consent == nil || consent.ClientID != clientID {
+		return nil, models.ErrConsentNotFound
+	}
+	return consent, nil
+}
+
+func (s *consentService) RevokeForProvider(clientID int, id string) error {
+	return s.revoke(id, func(consent *models.Consent) bool { return consent.ClientID == clientID })
+}
+
+func (s *consentService) GetForCustomer(customerID int, id string) (*models.Consent, error) {
+	consent, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if consent == nil || (consent.CustomerID != customerID && consent.Status != models.ConsentAwaitingAuthorization) {
+		return nil, models.ErrConsentNotFound
+	}
+	return consent, nil
+}
+
+func (s *consentService) ListForCustomer(customerID int) ([]models.Consent, error) {
+	return s.repo.ListByCustomer(customerID)
+}
+
+// decide locks a consent awaiting the customer's decision and applies it.
+func (s *consentService) decide(customerID int, id, action string, apply func(tx *gorm.DB, consent *models.Consent) error) (*models.Consent, error) {
+	var consent *models.Consent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		consent, err = s.repo.WithTx(tx).GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if consent == nil {
+			return models.ErrConsentNotFound
+		}
+		if consent.Status != models.ConsentAwaitingAuthorization || !time.Now().Before(consent.ExpiresAt) {
+			return models.ErrConsentNotPending
+		}
+		consent.CustomerID = customerID
+		if err := apply(tx, consent); err != nil {
+			return err
+		}
+		if err := s.repo.WithTx(tx).Update(consent); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     action,
+			EntityType: "consent",
+			EntityID:   customerID,
+			After:      consent,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+func (s *consentService) Authorize(customerID int, id string, accountIDs []int) (*models.Consent, error) {
+	owned, err := s.accounts.ListByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	mine := map[int]bool{}
+	for _, account := range owned {
+		mine[account.ID] = true
+	}
+	seen := map[int]bool{}
+	var selected []int
+	for _, accountID := range accountIDs {
+		if !mine[accountID] {
+			return nil, models.ErrAccountNotFound
+		}
+		if !seen[accountID] {
+			seen[accountID] = true
+			selected = append(selected, accountID)
+		}
+	}
+	sort.Ints(selected)
+
+	return s.decide(customerID, id, "consent.authorize", func(tx *gorm.DB, consent *models.Consent) error {
+		now := time.Now()
+		consent.Status = models.ConsentAuthorized
+		consent.AuthorizedAt = &now
+		consent.AccountIDs = selected
+		return s.repo.WithTx(tx).SetAccounts(consent.ID, selected)
+	})
+}
+
+func (s *consentService) Reject(customerID int, id string) (*models.Consent, error) {
+	return s.decide(customerID, id, "consent.reject", func(tx *gorm.DB, consent *models.Consent) error {
+		consent.Status = models.ConsentRejected
+		return nil
+	})
+}
+
+func (s *consentService) Revoke(customerID int, id string) error {
+	return s.revoke(id, func(consent *models.Consent) bool { return consent.CustomerID == customerID })
+}
+
+// revoke withdraws a consent that owns says belongs to the caller.
+// Revoking twice is not an error.
+func (s *consentService) revoke(id string, owns func(*models.Consent) bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		consent, err := repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if consent == nil || !owns(consent) {
+			return models.ErrConsentNotFound
+		}
+		switch consent.Status {
+		case models.ConsentRevoked:
+			return nil
+		case models.ConsentAuthorized, models.ConsentAwaitingAuthorization:
+		default:
+			return models.ErrConsentNotAuthorized
+		}
+		now := time.Now()
+		consent.Status = models.ConsentRevoked
+		consent.RevokedAt = &now
+		if err := repo.Update(consent); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "consent.revoke",
+			EntityType: "consent",
+			EntityID:   consent.CustomerID,
+			After:      consent,
+		})
+	})
+}
+
+func (s *consentService) Token(client *models.APIClient, id string) (*models.ClientToken, error) {
+	consent, err := s.Check(client.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	ttl := s.cfg.TokenTTL
+	if left := time.Until(consent.ExpiresAt); left < ttl {
+		ttl = left
+	}
+	token, err := auth.GenerateConsentToken(consent.CustomerID, client.ClientID, consent.ID, consent.Permissions, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ClientToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       strings.Join(consent.Permissions, " "),
+	}, nil
+}
+
+func (s *consentService) Check(clientID int, id string) (*models.Consent, error) {
+	consent, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if consent == nil || consent.ClientID != clientID {
+		return nil, models.ErrConsentNotFound
+	}
+	if consent.Status != models.ConsentAuthorized || !time.Now().Before(consent.ExpiresAt) {
+		return nil, models.ErrConsentNotAuthorized
+	}
+	return consent, nil
+}
+
+func (s *consentService) Expire() (int64, error) {
+	return s.repo.ExpireDue(time.Now())
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// DeviceService remembers the devices customers sign in from and raises
+// login.new_device the first time an existing customer uses a new one.
+type DeviceService interface {
+	// RecordLogin notes a sign-in. deviceID is an optional client-supplied
+	// identifier; without one the user agent identifies the device.
+	RecordLogin(customerID int, deviceID, userAgent, ip string) error
+}
+
+type deviceService struct {
+	db     *gorm.DB
+	repo   repositories.DeviceRepository
+	events events.Recorder
+}
+
+func NewDeviceService(db *gorm.DB, repo repositories.DeviceRepository, recorder events.Recorder) DeviceService {
+	return &deviceService{db: db, repo: repo, events: recorder}
+}
+
+func deviceFingerprint(deviceID, userAgent string) string {
+	key := "ua:" + userAgent
+	if deviceID != "" {
+		key = "id:" + deviceID
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *deviceService) RecordLogin(customerID int, deviceID, userAgent, ip string) error {
+	now := time.Now()
+	fingerprint := deviceFingerprint(deviceID, userAgent)
+
+	device, err := s.repo.Find(customerID, fingerprint)
+	if err != nil {
+		return err
+	}
+	if device != nil {
+		device.LastIP = ip
+		device.LastSeenAt = now
+		return s.repo.Update(device)
+	}
+
+	// the very first device (at registration) is not news
+	known, err := s.repo.CountByCustomerID(customerID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := s.repo.WithTx(tx).Create(&models.CustomerDevice{
+			CustomerID:  customerID,
+			Fingerprint: fingerprint,
+			UserAgent:   userAgent,
+			LastIP:      ip,
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+		})
+		if err != nil || known == 0 {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.LoginNewDevice, customerID, map[string]any{
+			"user_agent": userAgent,
+			"ip":         ip,
+		}))
+	})
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/fraud"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// FraudInput describes an operation about to be posted from AccountID.
+// The payee is ToAccountID for an internal transfer or BeneficiaryID for
+// an outgoing payment; both are zero when there is no payee to judge,
+// such as for withdrawals.
+type FraudInput struct {
+	Operation     string
+	AccountID     int
+	ToAccountID   int
+	BeneficiaryID int
+	Amount        float64
+}
+
+type FraudService interface {
+	// Assess runs the active rules against the operation, reading recent
+	// activity through tx.
+	Assess(tx *gorm.DB, in FraudInput) (*models.FraudDecision, error)
+
+	CreateRule(req *models.CreateFraudRuleRequest) (*models.FraudRule, error)
+	ListRules() ([]models.FraudRule, error)
+	UpdateRule(id int, req *models.UpdateFraudRuleRequest) (*models.FraudRule, error)
+	// SeedDefaultRules installs the default rule set when there are no
+	// rules yet.
+	SeedDefaultRules() error
+
+	ListHeldTransfers(status string, limit int) ([]models.HeldTransfer, error)
+	GetHeldTransfer(id int) (*models.HeldTransfer, error)
+	// HoldTransfer, LockHeldTransfer and UpdateHeldTransfer take part in
+	// the account service's transaction.
+	HoldTransfer(tx *gorm.DB, held *models.HeldTransfer) error
+	LockHeldTransfer(tx *gorm.DB, id int) (*models.HeldTransfer, error)
+	UpdateHeldTransfer(tx *gorm.DB, held *models.HeldTransfer) error
+}
+
+type fraudService struct {
+	repo     repositories.FraudRepository
+	txRepo   repositories.TransactionRepository
+	location *time.Location
+}
+
+// NewFraudService evaluates unusual-hour rules in location.
+func NewFraudService(repo repositories.FraudRepository, txRepo repositories.TransactionRepository, location *time.Location) FraudService {
+	if location == nil {
+		location = time.Local
+	}
+	return &fraudService{repo: repo, txRepo: txRepo, location: location}
+}
+
+// DefaultFraudRules is a conservative starting point; staff tune them
+// through the admin API.
+var DefaultFraudRules = []models.FraudRule{
+	{Name: "more than 10 debits in an hour", Type: models.FraudVelocityCount, Operation: models.FraudOpAny,
+		Threshold: 10, WindowSeconds: 3600, Action: models.FraudChallenge, Active: true},
+	{Name: "more than 10000 out in a day", Type: models.FraudVelocityAmount, Operation: models.FraudOpAny,
+		Threshold: 10000, WindowSeconds: 86400, Action: models.FraudHold, Active: true},
+	{Name: "first transfer of 1000 or more to a payee", Type: models.FraudNewPayee, Operation: models.FraudOpTransfer,
+		MinAmount: 1000, Action: models.FraudChallenge, Active: true},
+	{Name: "first transfer of 5000 or more to a payee", Type: models.FraudNewPayee, Operation: models.FraudOpTransfer,
+		MinAmount: 5000, Action: models.FraudHold, Active: true},
+	{Name: "500 or more between midnight and 5am", Type: models.FraudUnusualHour, Operation: models.FraudOpAny,
+		MinAmount: 500, StartHour: 0, EndHour: 5, Action: models.FraudChallenge, Active: true},
+	{Name: "80% of a day's credits moved straight out", Type: models.FraudRapidInOut, Operation: models.FraudOpAny,
+		MinAmount: 1000, Threshold: 0.8, WindowSeconds: 86400, Action: models.FraudHold, Active: true},
+}
+
+func (s *fraudService) Assess(tx *gorm.DB, in FraudInput) (*models.FraudDecision, error) {
+	rules, err := s.repo.WithTx(tx).ListActiveRules()
+	if err != nil {
+		return nil, err
+	}
+	op := fraud.Operation{Type: in.Operation, Amount: in.Amount, Now: time.Now().In(s.location)}
+	return fraud.Evaluate(rules, op, &accountFacts{repo: s.txRepo.WithTx(tx), in: in})
+}
+
+// accountFacts answers fraud.Facts from the transaction table.
+type accountFacts struct {
+	repo repositories.TransactionRepository
+	in   FraudInput
+}
+
+func (f *accountFacts) Debits(since time.Time) (int64, float64, error) {
+	return f.repo.Debits(f.in.AccountID, since)
+}
+
+func (f *accountFacts) Credits(since time.Time) (float64, error) {
+	return f.repo.Credits(f.in.AccountID, since)
+}
+
+func (f *accountFacts) NewPayee() (bool, error) {
+	var paid bool
+	var err error
+	switch {
+	case f.in.BeneficiaryID != 0:
+		paid, err = f.repo.HasPaidBeneficiary(f.in.AccountID, f.in.BeneficiaryID)
+	case f.in.ToAccountID != 0:
+		paid, err = f.repo.HasPaid(f.in.AccountID, f.in.ToAccountID)
+	default:
+		return false, nil
+	}
+	return !paid, err
+}
+
+// screenDebit runs the rules for flows that have no review queue: a hold
+// declines the operation as suspected fraud and a challenge needs the
+// customer to have stepped up. fraud may be nil.
+func screenDebit(tx *gorm.DB, fraud FraudService, in FraudInput, stepUp bool) error {
+	if fraud == nil {
+		return nil
+	}
+	decision, err := fraud.Assess(tx, in)
+	if err != nil {
+		return err
+	}
+	switch {
+	case decision.Action == models.FraudHold:
+		return models.ErrSuspectedFraud
+	case decision.Action == models.FraudChallenge && !stepUp:
+		return &models.StepUpRequiredError{Rules: decision.Rules}
+	}
+	return nil
+}
+
+func (s *fraudService) CreateRule(req *models.CreateFraudRuleRequest) (*models.FraudRule, error) {
+	existing, err := s.repo.GetRuleByName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, models.ErrDuplicateFraudRule
+	}
+
+	rule := &models.FraudRule{
+		Name:          req.Name,
+		Type:          req.Type,
+		Operation:     req.Operation,
+		MinAmount:     req.MinAmount,
+		Threshold:     req.Threshold,
+		WindowSeconds: req.WindowSeconds,
+		StartHour:     req.StartHour,
+		EndHour:       req.EndHour,
+		Action:        req.Action,
+		Active:        true,
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	if err := s.repo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *fraudService) ListRules() ([]models.FraudRule, error) {
+	return s.repo.ListRules()
+}
+
+func (s *fraudService) UpdateRule(id int, req *models.UpdateFraudRuleRequest) (*models.FraudRule, error) {
+	rule, err := s.repo.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, models.ErrFraudRuleNotFound
+	}
+
+	if req.MinAmount != nil {
+		rule.MinAmount = *req.MinAmount
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.WindowSeconds != nil {
+		rule.WindowSeconds = *req.WindowSeconds
+	}
+	if req.StartHour != nil {
+		rule.StartHour = *req.StartHour
+	}
+	if req.EndHour != nil {
+		rule.EndHour = *req.EndHour
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	if err := s.repo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *fraudService) SeedDefaultRules() error {
+	n, err := s.repo.CountRules()
+	if err != nil || n > 0 {
+		return err
+	}
+	for _, rule := range DefaultFraudRules {
+		rule := rule
+		if err := s.repo.CreateRule(&rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fraudService) ListHeldTransfers(status string, limit int) ([]models.HeldTransfer, error) {
+	return s.repo.ListHeldTransfers(status, limit)
+}
+
+func (s *fraudService) GetHeldTransfer(id int) (*models.HeldTransfer, error) {
+	held, err := s.repo.GetHeldTransfer(id)
+	if err != nil {
+		return nil, err
+	}
+	if held == nil {
+		return nil, models.ErrHeldTransferNotFound
+	}
+	return held, nil
+}
+
+func (s *fraudService) HoldTransfer(tx *gorm.DB, held *models.HeldTransfer) error {
+	return s.repo.WithTx(tx).CreateHeldTransfer(held)
+}
+
+func (s *fraudService) LockHeldTransfer(tx *gorm.DB, id int) (*models.HeldTransfer, error) {
+	held, err := s.repo.WithTx(tx).GetHeldTransferForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+	if held == nil {
+		return nil, models.ErrHeldTransferNotFound
+	}
+	return held, nil
+}
+
+func (s *fraudService) UpdateHeldTransfer(tx *gorm.DB, held *models.HeldTransfer) error {
+	return s.repo.WithTx(tx).UpdateHeldTransfer(held)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/iso20022"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// InboundImportResult summarises an inbound credit file import.
+type InboundImportResult struct {
+	MessageID  string   `json:"message_id"`
+	Posted     int      `json:"posted"`
+	Suspense   int      `json:"suspense"`
+	Duplicates int      `json:"duplicates"`
+	Errors     []string `json:"errors"`
+}
+
+type InboundPaymentService interface {
+	ImportCamt054(r io.Reader) (*InboundImportResult, error)
+	ImportCSV(r io.Reader) (*InboundImportResult, error)
+	ListSuspense() ([]models.InboundCredit, error)
+	AssignSuspense(creditID, accountID int) (*models.InboundCredit, error)
+	// WithAudit returns the service acting for meta's actor in the audit
+	// log. Without it changes are attributed to the system.
+	WithAudit(meta audit.Meta) InboundPaymentService
+}
+
+type inboundPaymentService struct {
+	db          *gorm.DB
+	repo        repositories.InboundCreditRepository
+	fileRepo    repositories.ClearingFileRepository
+	accountRepo repositories.AccountRepository
+	accountSvc  AccountService
+	events      events.Recorder
+	audit       audit.Recorder
+	meta        audit.Meta
+}
+
+func NewInboundPaymentService(
+	db *gorm.DB,
+	repo repositories.InboundCreditRepository,
+	fileRepo repositories.ClearingFileRepository,
+	accountRepo repositories.AccountRepository,
+	accountSvc AccountService,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+) InboundPaymentService {
+	return &inboundPaymentService{
+		db:          db,
+		repo:        repo,
+		fileRepo:    fileRepo,
+		accountRepo: accountRepo,
+		accountSvc:  accountSvc,
+		events:      recorder,
+		audit:       auditor,
+	}
+}
+
+func (s *inboundPaymentService) WithAudit(meta audit.Meta) InboundPaymentService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *inboundPaymentService) ImportCamt054(r io.Reader) (*InboundImportResult, error) {
+	n, err := iso20022.ParseCamt054(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.importCredits(n, "camt.054")
+}
+
+func (s *inboundPaymentService) ImportCSV(r io.Reader) (*InboundImportResult, error) {
+	n, err := iso20022.ParseCreditCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.importCredits(n, "csv")
+}
+
+// importCredits records the file and posts each credit. Every credit is
+// keyed by its bank reference, so importing the same file again (or a file
+// that overlaps an earlier one, even concurrently) only reports
+// duplicates.
+func (s *inboundPaymentService) importCredits(n *iso20022.CreditNotification, fileType string) (*InboundImportResult, error) {
+	file, err := s.fileRepo.GetByMessageID(n.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		var total float64
+		for _, c := range n.Credits {
+			total += c.Amount
+		}
+		file = &models.ClearingFile{
+			MessageID:  n.MessageID,
+			Type:       fileType,
+			Direction:  models.ClearingDirectionInbound,
+			EntryCount: len(n.Credits),
+			ControlSum: total,
+		}
+		if err := s.fileRepo.Create(file); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &InboundImportResult{MessageID: n.MessageID}
+	for _, c := range n.Credits {
+		exists, err := s.repo.ExistsByReference(c.Reference)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", c.Reference, err))
+			continue
+		}
+		if exists {
+			result.Duplicates++
+			continue
+		}
+
+		credit, err := s.postCredit(file.ID, c)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// another import posted it since the check above
+			result.Duplicates++
+			continue
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", c.Reference, err))
+			continue
+		}
+		if credit.Status == models.InboundCreditPosted {
+			result.Posted++
+		} else {
+			result.Suspense++
+		}
+	}
+	return result, nil
+}
+
+func (s *inboundPaymentService) postCredit(fileID int, c iso20022.InboundCredit) (*models.InboundCredit, error) {
+	credit := &models.InboundCredit{
+		ClearingFileID: fileID,
+		Reference:      c.Reference,
+		EndToEndID:     c.EndToEndID,
+		AccountNumber:  c.CreditorAcct,
+		Amount:         c.Amount,
+		Currency:       c.Currency,
+		DebtorName:     c.DebtorName,
+		Remittance:     c.Remittance,
+		BookingDate:    c.BookingDate,
+		Status:         models.InboundCreditSuspense,
+	}
+	if credit.BookingDate.IsZero() {
+		credit.BookingDate = time.Now()
+	}
+
+	accountID, reason := s.matchAccount(c)
+	if accountID == 0 {
+		credit.SuspenseReason = reason
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := s.repo.WithTx(tx).Create(credit); err != nil {
+				return err
+			}
+			return s.audit.Record(tx, s.meta, audit.Entry{
+				Action:     "inbound_credit.suspend",
+				EntityType: "inbound_credit",
+				EntityID:   credit.ID,
+				After:      credit,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		return credit, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		credit.Status = models.InboundCreditPosted
+		if err := s.credit(tx, credit, accountID); err != nil {
+			return err
+		}
+		if err := s.repo.WithTx(tx).Create(credit); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "inbound_credit.post",
+			EntityType: "inbound_credit",
+			EntityID:   credit.ID,
+			After:      credit,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
+
+// credit posts the inbound credit to accountID inside tx and records the
+// deposit event, so the customer hears of money from other banks as of
+// any other deposit.
+func (s *inboundPaymentService) credit(tx *gorm.DB, credit *models.InboundCredit, accountID int) error {
+	posted, err := s.accountSvc.PostDeposit(tx, accountID, credit.Amount)
+	if err != nil {
+		return err
+	}
+	account, err := s.accountRepo.WithTx(tx).GetByID(accountID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	credit.AccountID = &accountID
+	credit.TransactionID = &posted.ID
+	credit.PostedAt = &now
+	return s.events.Record(tx, events.New(events.DepositReceived, account.CustomerID, map[string]any{
+		"transaction_id": posted.ID,
+		"account_id":     accountID,
+		"amount":         credit.Amount,
+		"currency":       account.Currency,
+		"balance":        account.Balance,
+		"reference":      credit.Reference,
+		"debtor_name":    credit.DebtorName,
+	}))
+}
+
+var accountNumberInText = regexp.MustCompile(`\b[0-9]{12}\b`)
+
+// matchAccount finds the internal account for a credit, first by the
+// creditor account number and then by an account number quoted in the
+// remittance information or end-to-end reference. When nothing matches it
+// returns 0 and the reason the credit goes to suspense.
+func (s *inboundPaymentService) matchAccount(c iso20022.InboundCredit) (int, string) {
+	candidates := []string{c.CreditorAcct}
+	candidates = append(candidates, accountNumberInText.FindAllString(c.Remittance, -1)...)
+	candidates = append(candidates, accountNumberInText.FindAllString(c.EndToEndID, -1)...)
+
+	reason := "no matching account"
+	for _, candidate := range candidates {
+		id, ok := models.ParseAccountNumber(candidate)
+		if !ok {
+			continue
+		}
+		account, err := s.accountRepo.GetByID(id)
+		if err != nil {
+			continue
+		}
+		if c.Currency != "" && !strings.EqualFold(account.Currency, c.Currency) {
+			reason = fmt.Sprintf("currency %s does not match account %s", c.Currency, models.FormatAccountNumber(id))
+			continue
+		}
+		return id, ""
+	}
+	return 0, reason
+}
+
+func (s *inboundPaymentService) ListSuspense() ([]models.InboundCredit, error) {
+	return s.repo.ListByStatus(models.InboundCreditSuspense)
+}
+
+// AssignSuspense posts a suspended credit to the account chosen by staff.
+func (s *inboundPaymentService) AssignSuspense(creditID, accountID int) (*models.InboundCredit, error) {
+	if _, err := s.accountRepo.GetByID(accountID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAccountNotFound
+		}
+		return nil, err
+	}
+
+	var credit *models.InboundCredit
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		credit, err = repo.GetForUpdate(creditID)
+		if err != nil {
+			return err
+		}
+		if credit == nil {
+			return models.ErrInboundCreditNotFound
+		}
+		if credit.Status != models.InboundCreditSuspense {
+			return models.ErrInboundCreditNotInSuspense
+		}
+
+		before := *credit
+		credit.Status = models.InboundCreditAssigned
+		if err := s.credit(tx, credit, accountID); err != nil {
+			return err
+		}
+		if err := repo.Update(credit); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "inbound_credit.assign",
+			EntityType: "inbound_credit",
+			EntityID:   credit.ID,
+			Before:     before,
+			After:      credit,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credit, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Mahesh252k/banking-api/internal/journal"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+const journalBatchSize = 1000
+
+// JournalConfig holds the checkpoint keys. Without a Signer checkpoints
+// cannot be written; Keys verify existing ones and should include any
+// retired signing keys.
+type JournalConfig struct {
+	Signer *journal.Signer
+	Keys   journal.Keys
+}
+
+type JournalService interface {
+	// Verify walks the whole chain and reports the first broken link.
+	Verify() (*models.JournalReport, error)
+	// Checkpoint signs the current chain head. It returns nil when nothing
+	// was posted since the last checkpoint.
+	Checkpoint() (*models.JournalCheckpoint, error)
+	ListCheckpoints(limit int) ([]models.JournalCheckpoint, error)
+	// Seal appends transactions posted before the chain existed, oldest
+	// first, returning how many it chained.
+	Seal() (int, error)
+}
+
+type journalService struct {
+	txRepo         repositories.TransactionRepository
+	checkpointRepo repositories.JournalCheckpointRepository
+	config         JournalConfig
+}
+
+func NewJournalService(txRepo repositories.TransactionRepository, checkpointRepo repositories.JournalCheckpointRepository, config JournalConfig) JournalService {
+	return &journalService{txRepo: txRepo, checkpointRepo: checkpointRepo, config: config}
+}
+
+func (s *journalService) Verify() (*models.JournalReport, error) {
+	report := &models.JournalReport{}
+	fail := func(seq int64, txID int, reason string, args ...any) (*models.JournalReport, error) {
+		report.FirstBreak = &models.JournalBreak{Sequence: seq, TransactionID: txID, Reason: fmt.Sprintf(reason, args...)}
+		return report, nil
+	}
+
+	unsealed, err := s.txRepo.CountUnsealed()
+	if err != nil {
+		return nil, err
+	}
+	report.UnsealedTransactions = unsealed
+
+	// checkpoints by the sequence they vouch for; a bad signature is only
+	// reported when the walk reaches it, so breaks come out in order
+	checkpoints, err := s.checkpointRepo.List(0)
+	if err != nil {
+		return nil, err
+	}
+	bySeq := make(map[int64][]models.JournalCheckpoint)
+	var lastCheckpoint int64
+	for _, cp := range checkpoints {
+		bySeq[cp.Sequence] = append(bySeq[cp.Sequence], cp)
+		if cp.Sequence > lastCheckpoint {
+			lastCheckpoint = cp.Sequence
+		}
+	}
+
+	prev := journal.Genesis
+	var seq int64
+	for {
+		batch, err := s.txRepo.ListChain(seq, journalBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			t := &batch[i]
+			want := seq + 1
+			if *t.Sequence != want {
+				return fail(want, 0, "transaction %d is missing", want)
+			}
+			if t.PrevHash != prev {
+				return fail(want, t.ID, "previous hash does not match transaction %d", want-1)
+			}
+			if journal.Hash(prev, t) != t.Hash {
+				return fail(want, t.ID, "content does not match its hash")
+			}
+			for _, cp := range bySeq[want] {
+				if err := s.config.Keys.Verify(&cp); err != nil {
+					return fail(want, t.ID, "checkpoint %d: %v", cp.ID, err)
+				}
+				if cp.Hash != t.Hash {
+					return fail(want, t.ID, "hash differs from signed checkpoint %d", cp.ID)
+				}
+				report.CheckpointsVerified++
+			}
+			prev = t.Hash
+			seq = want
+			report.TransactionsChecked++
+		}
+		if len(batch) < journalBatchSize {
+			break
+		}
+	}
+	report.LastSequence = seq
+
+	// deleting from the end of the chain leaves every remaining link
+	// intact; the head and the checkpoints still remember how far it went
+	head, err := s.txRepo.GetHead()
+	if err != nil {
+		return nil, err
+	}
+	if head.Sequence != seq || head.Hash != prev {
+		return fail(seq+1, 0, "chain ends at %d but its head is at %d", seq, head.Sequence)
+	}
+	if lastCheckpoint > seq {
+		return fail(seq+1, 0, "chain ends at %d but was checkpointed at %d", seq, lastCheckpoint)
+	}
+
+	report.OK = true
+	return report, nil
+}
+
+func (s *journalService) Checkpoint() (*models.JournalCheckpoint, error) {
+	if s.config.Signer == nil {
+		return nil, models.ErrJournalSigningKeyMissing
+	}
+
+	head, err := s.txRepo.GetHead()
+	if err != nil {
+		return nil, err
+	}
+	if head.Sequence == 0 {
+		return nil, nil
+	}
+	latest, err := s.checkpointRepo.Latest()
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Sequence >= head.Sequence {
+		return nil, nil
+	}
+
+	cp := &models.JournalCheckpoint{Sequence: head.Sequence, Hash: head.Hash}
+	s.config.Signer.Sign(cp)
+	if err := s.checkpointRepo.Create(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func (s *journalService) ListCheckpoints(limit int) ([]models.JournalCheckpoint, error) {
+	return s.checkpointRepo.List(limit)
+}
+
+func (s *journalService) Seal() (int, error) {
+	sealed := 0
+	for {
+		batch, err := s.txRepo.ListUnsealed(journalBatchSize)
+		if err != nil {
+			return sealed, err
+		}
+		for i := range batch {
+			if err := s.txRepo.Seal(&batch[i]); err != nil {
+				return sealed, err
+			}
+			sealed++
+		}
+		if len(batch) < journalBatchSize {
+			return sealed, nil
+		}
+	}
+}
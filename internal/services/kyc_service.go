@@ -0,0 +1,483 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/kyc"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type KYCService interface {
+	GetProfile(customerID int) (*models.KYCProfile, error)
+	// UploadDocument stores an identity document and puts an unverified,
+	// rejected or expired customer up for review. A verified customer
+	// renewing ahead of expiry stays verified while staff review.
+	UploadDocument(customerID int, req *models.UploadKYCDocumentRequest, fileName string, file io.Reader) (*models.KYCDocument, error)
+	// OpenDocument returns the document and its file; close the file.
+	OpenDocument(id int) (*models.KYCDocument, io.ReadCloser, error)
+
+	// ListAwaitingReview returns customers with documents pending review.
+	ListAwaitingReview(limit int) ([]models.Customer, error)
+	ListCustomers(status string, limit int) ([]models.Customer, error)
+	// Approve verifies the customer on their pending documents; Reject
+	// turns the documents down.
+	Approve(customerID int, note string) (*models.KYCProfile, error)
+	Reject(customerID int, note string) (*models.KYCProfile, error)
+
+	// CheckAccountOpening and CheckTransfer apply the limits on customers
+	// who are not verified. CheckTransfer counts amount together with
+	// everything the customer moved out or has on hold within the
+	// transfer window, so every debit path must call it.
+	CheckAccountOpening(customerID int) error
+	CheckTransfer(customerID int, amount float64) error
+	// RequireVerified refuses anyone not currently verified.
+	RequireVerified(customerID int) error
+
+	// ProcessExpiry reminds customers whose verification is about to lapse
+	// and lapses the ones past expiry. It returns how many were touched.
+	ProcessExpiry() (int, error)
+
+	WithAudit(meta audit.Meta) KYCService
+}
+
+// KYCPolicy sets how long verification lasts and what customers who are
+// not verified (unverified, pending or expired) may do. Rejected customers
+// may not open accounts or move money at all.
+type KYCPolicy struct {
+	Validity     time.Duration
+	ReminderLead time.Duration
+	// UnverifiedMaxAccounts is how many accounts an unverified customer
+	// may hold; zero means none.
+	UnverifiedMaxAccounts int
+	// UnverifiedTransferLimit caps what an unverified customer may move
+	// out across all accounts within UnverifiedTransferWindow; zero blocks
+	// transfers.
+	UnverifiedTransferLimit  float64
+	UnverifiedTransferWindow time.Duration
+	MaxDocumentBytes         int64
+}
+
+type kycService struct {
+	db          *gorm.DB
+	repo        repositories.KYCRepository
+	customers   repositories.CustomerRepository
+	accountRepo repositories.AccountRepository
+	txRepo      repositories.TransactionRepository
+	store       kyc.Store
+	events      events.Recorder
+	audit       audit.Recorder
+	meta        audit.Meta
+	policy      KYCPolicy
+}
+
+func NewKYCService(
+	db *gorm.DB,
+	repo repositories.KYCRepository,
+	customers repositories.CustomerRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	store kyc.Store,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+	policy KYCPolicy,
+) KYCService {
+	return &kycService{
+		db:          db,
+		repo:        repo,
+		customers:   customers,
+		accountRepo: accountRepo,
+		txRepo:      txRepo,
+		store:       store,
+		events:      recorder,
+		audit:       auditor,
+		policy:      policy,
+	}
+}
+
+func (s *kycService) WithAudit(meta audit.Meta) KYCService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+// kycStatus is the customer's verification state, treating a lapsed
+// verification as expired even before the expiry job has run.
+func kycStatus(customer *models.Customer, now time.Time) string {
+	if customer.KYCStatus == models.KYCVerified && customer.KYCExpiresAt != nil && customer.KYCExpiresAt.Before(now) {
+		return models.KYCExpired
+	}
+	if customer.KYCStatus == "" {
+		return models.KYCUnverified
+	}
+	return customer.KYCStatus
+}
+
+func (s *kycService) getCustomer(id int) (*models.Customer, error) {
+	customer, err := s.customers.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, models.ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+func (s *kycService) GetProfile(customerID int) (*models.KYCProfile, error) {
+	customer, err := s.getCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.profile(s.repo, customer)
+}
+
+func (s *kycService) profile(repo repositories.KYCRepository, customer *models.Customer) (*models.KYCProfile, error) {
+	docs, err := repo.ListDocuments(customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.KYCProfile{
+		CustomerID: customer.ID,
+		Status:     kycStatus(customer, time.Now()),
+		VerifiedAt: customer.KYCVerifiedAt,
+		ExpiresAt:  customer.KYCExpiresAt,
+		Documents:  docs,
+	}, nil
+}
+
+func (s *kycService) UploadDocument(customerID int, req *models.UploadKYCDocumentRequest, fileName string, file io.Reader) (*models.KYCDocument, error) {
+	var expiresOn *time.Time
+	if req.ExpiresOn != "" {
+		t, err := time.Parse("2006-01-02", req.ExpiresOn)
+		if err != nil {
+			return nil, err
+		}
+		expiresOn = &t
+	}
+
+	stored, err := s.store.Put(file, s.policy.MaxDocumentBytes)
+	switch {
+	case errors.Is(err, kyc.ErrTooLarge):
+		return nil, models.ErrKYCDocumentTooLarge
+	case errors.Is(err, kyc.ErrUnsupportedType):
+		return nil, models.ErrInvalidKYCDocument
+	case err != nil:
+		return nil, err
+	}
+
+	doc := &models.KYCDocument{
+		CustomerID:     customerID,
+		Type:           req.Type,
+		DocumentNumber: req.DocumentNumber,
+		IssuingCountry: req.IssuingCountry,
+		ExpiresOn:      expiresOn,
+		FileName:       fileName,
+		ContentType:    stored.ContentType,
+		Size:           stored.Size,
+		SHA256:         stored.SHA256,
+		StorageKey:     stored.Key,
+		Status:         models.KYCDocumentPending,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		customer, err := repo.GetCustomerForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrCustomerNotFound
+		}
+		if err := repo.CreateDocument(doc); err != nil {
+			return err
+		}
+		if kycStatus(customer, time.Now()) != models.KYCVerified && customer.KYCStatus != models.KYCPending {
+			customer.KYCStatus = models.KYCPending
+			if err := repo.UpdateCustomer(customer); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "kyc.document_upload",
+			EntityType: "kyc_document",
+			EntityID:   doc.ID,
+			After:      doc,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *kycService) OpenDocument(id int) (*models.KYCDocument, io.ReadCloser, error) {
+	doc, err := s.repo.GetDocument(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, models.ErrKYCDocumentNotFound
+	}
+	file, err := s.store.Open(doc.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, file, nil
+}
+
+func (s *kycService) ListAwaitingReview(limit int) ([]models.Customer, error) {
+	return s.repo.ListAwaitingReview(limit)
+}
+
+func (s *kycService) ListCustomers(status string, limit int) ([]models.Customer, error) {
+	return s.repo.ListCustomers(status, limit)
+}
+
+func (s *kycService) Approve(customerID int, note string) (*models.KYCProfile, error) {
+	return s.review(customerID, true, note)
+}
+
+func (s *kycService) Reject(customerID int, note string) (*models.KYCProfile, error) {
+	return s.review(customerID, false, note)
+}
+
+func (s *kycService) review(customerID int, approve bool, note string) (*models.KYCProfile, error) {
+	var profile *models.KYCProfile
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		customer, err := repo.GetCustomerForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrCustomerNotFound
+		}
+		docs, err := repo.ListDocuments(customerID)
+		if err != nil {
+			return err
+		}
+		var pending []models.KYCDocument
+		for _, doc := range docs {
+			if doc.Status == models.KYCDocumentPending {
+				pending = append(pending, doc)
+			}
+		}
+		if len(pending) == 0 {
+			return models.ErrKYCNotPending
+		}
+		before := *customer
+
+		now := time.Now()
+		docStatus, action := models.KYCDocumentRejected, "kyc.reject"
+		if approve {
+			docStatus, action = models.KYCDocumentAccepted, "kyc.approve"
+			expiresAt := now.Add(s.policy.Validity)
+			// verification cannot outlast the identity document it rests
+			// on, so an expired one cannot be approved at all
+			for _, doc := range pending {
+				if doc.Type == models.KYCProofOfAddress || doc.ExpiresOn == nil {
+					continue
+				}
+				if !doc.ExpiresOn.After(now) {
+					return models.ErrKYCDocumentExpired
+				}
+				if doc.ExpiresOn.Before(expiresAt) {
+					expiresAt = *doc.ExpiresOn
+				}
+			}
+			customer.KYCStatus = models.KYCVerified
+			customer.KYCVerifiedAt = &now
+			customer.KYCExpiresAt = &expiresAt
+			customer.KYCRemindedAt = nil
+		} else if kycStatus(customer, now) != models.KYCVerified {
+			// turning down a renewal leaves a still-valid verification alone
+			customer.KYCStatus = models.KYCRejected
+		}
+
+		if err := repo.ReviewPendingDocuments(customerID, docStatus, note, s.meta.ActorID, now); err != nil {
+			return err
+		}
+		if err := repo.UpdateCustomer(customer); err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     action,
+			EntityType: "customer",
+			EntityID:   customer.ID,
+			Before:     kycState(&before),
+			After:      kycState(customer),
+		}); err != nil {
+			return err
+		}
+		profile, err = s.profile(repo, customer)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// kycState is the part of the customer the audit log needs to show.
+func kycState(c *models.Customer) map[string]any {
+	return map[string]any{"kyc_status": c.KYCStatus, "kyc_verified_at": c.KYCVerifiedAt, "kyc_expires_at": c.KYCExpiresAt}
+}
+
+func (s *kycService) CheckAccountOpening(customerID int) error {
+	customer, err := s.getCustomer(customerID)
+	if err != nil {
+		return err
+	}
+	switch kycStatus(customer, time.Now()) {
+	case models.KYCVerified:
+		return nil
+	case models.KYCRejected:
+		return models.ErrKYCRejected
+	}
+	accounts, err := s.accountRepo.ListByCustomerID(customerID)
+	if err != nil {
+		return err
+	}
+	if len(accounts) >= s.policy.UnverifiedMaxAccounts {
+		return models.ErrKYCRequired
+	}
+	return nil
+}
+
+func (s *kycService) CheckTransfer(customerID int, amount float64) error {
+	customer, err := s.getCustomer(customerID)
+	if err != nil {
+		return err
+	}
+	switch kycStatus(customer, time.Now()) {
+	case models.KYCVerified:
+		return nil
+	case models.KYCRejected:
+		return models.ErrKYCRejected
+	}
+	if amount > s.policy.UnverifiedTransferLimit {
+		return models.ErrKYCLimitExceeded
+	}
+
+	// splitting a large amount into small ones does not get around it
+	debited, err := s.txRepo.CustomerDebits(customerID, time.Now().Add(-s.policy.UnverifiedTransferWindow))
+	if err != nil {
+		return err
+	}
+	accounts, err := s.accountRepo.ListByCustomerID(customerID)
+	if err != nil {
+		return err
+	}
+	held := 0.0
+	for _, account := range accounts {
+		held += account.HeldAmount
+	}
+	if debited+held+amount > s.policy.UnverifiedTransferLimit {
+		return models.ErrKYCLimitExceeded
+	}
+	return nil
+}
+
+func (s *kycService) RequireVerified(customerID int) error {
+	customer, err := s.getCustomer(customerID)
+	if err != nil {
+		return err
+	}
+	switch kycStatus(customer, time.Now()) {
+	case models.KYCVerified:
+		return nil
+	case models.KYCRejected:
+		return models.ErrKYCRejected
+	}
+	return models.ErrKYCRequired
+}
+
+func (s *kycService) ProcessExpiry() (int, error) {
+	now := time.Now()
+	touched := 0
+
+	expiring, err := s.repo.ListExpiring(now.Add(s.policy.ReminderLead))
+	if err != nil {
+		return touched, err
+	}
+	for i := range expiring {
+		ok, err := s.remind(&expiring[i], now)
+		if err != nil {
+			return touched, err
+		}
+		if ok {
+			touched++
+		}
+	}
+
+	lapsed, err := s.repo.ListLapsed(now)
+	if err != nil {
+		return touched, err
+	}
+	for i := range lapsed {
+		ok, err := s.lapse(lapsed[i].ID, now)
+		if err != nil {
+			return touched, err
+		}
+		if ok {
+			touched++
+		}
+	}
+	return touched, nil
+}
+
+// remind marks the customer reminded and records the event in one
+// transaction, so each verification gets one reminder.
+func (s *kycService) remind(customer *models.Customer, now time.Time) (bool, error) {
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		claimed, err = s.repo.WithTx(tx).MarkReminded(customer.ID, now)
+		if err != nil || !claimed {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.KYCExpiring, customer.ID, map[string]any{
+			"expires_at": *customer.KYCExpiresAt,
+		}))
+	})
+	return claimed, err
+}
+
+func (s *kycService) lapse(customerID int, now time.Time) (bool, error) {
+	lapsed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		customer, err := repo.GetCustomerForUpdate(customerID)
+		if err != nil || customer == nil {
+			return err
+		}
+		// renewed or already lapsed by another worker
+		if customer.KYCStatus != models.KYCVerified || customer.KYCExpiresAt == nil || !customer.KYCExpiresAt.Before(now) {
+			return nil
+		}
+		before := *customer
+		customer.KYCStatus = models.KYCExpired
+		if err := repo.UpdateCustomer(customer); err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "kyc.expire",
+			EntityType: "customer",
+			EntityID:   customer.ID,
+			Before:     kycState(&before),
+			After:      kycState(customer),
+		}); err != nil {
+			return err
+		}
+		lapsed = true
+		return s.events.Record(tx, events.New(events.KYCExpired, customer.ID, map[string]any{
+			"expires_at": *customer.KYCExpiresAt,
+		}))
+	})
+	return lapsed, err
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
 	"github.com/Mahesh252k/banking-api/internal/models"
 	"github.com/Mahesh252k/banking-api/internal/repositories"
 	"gorm.io/gorm"
@@ -12,30 +14,52 @@ import (
 type LoanPaymentService interface {
 	MakePayment(paymentID int, loanID int) error
 	ListPayments(loanID int) ([]models.LoanPayment, error)
+	// SendReminders announces EMIs falling due within lead and EMIs that
+	// are past due, once each.
+	SendReminders(lead time.Duration) (int, error)
+	// WithAudit returns the service acting for meta's actor in the audit
+	// log.
+	WithAudit(meta audit.Meta) LoanPaymentService
 }
 
 type loanPaymentService struct {
 	db          *gorm.DB
 	loanRepo    repositories.LoanRepository
 	paymentRepo repositories.LoanPaymentRepository
+	events      events.Recorder
+	audit       audit.Recorder
+	meta        audit.Meta
 }
 
 func NewLoanPaymentService(
 	db *gorm.DB,
 	loanRepo repositories.LoanRepository,
 	paymentRepo repositories.LoanPaymentRepository,
+	recorder events.Recorder,
+	auditor audit.Recorder,
 ) LoanPaymentService {
 	return &loanPaymentService{
 		db:          db,
 		loanRepo:    loanRepo,
 		paymentRepo: paymentRepo,
+		events:      recorder,
+		audit:       auditor,
 	}
 }
 
+func (s *loanPaymentService) WithAudit(meta audit.Meta) LoanPaymentService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
 func (s *loanPaymentService) MakePayment(paymentID int, loanID int) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
+		loanRepo := s.loanRepo.WithTx(tx)
+		paymentRepo := s.paymentRepo.WithTx(tx)
+
 		// 1) Get payment
-		payment, err := s.paymentRepo.GetByID(paymentID)
+		payment, err := paymentRepo.GetByID(paymentID)
 		if err != nil {
 			return err
 		}
@@ -54,12 +78,12 @@ func (s *loanPaymentService) MakePayment(paymentID int, loanID int) error {
 		}
 
 		// 4) Mark payment paid
-		if err := s.paymentRepo.UpdateStatus(paymentID, "paid", time.Now()); err != nil {
+		if err := paymentRepo.UpdateStatus(paymentID, "paid", time.Now()); err != nil {
 			return err
 		}
 
 		// 5) Load loan (needed for TermsMonths)
-		loan, err := s.loanRepo.GetByID(loanID)
+		loan, err := loanRepo.GetByID(loanID)
 		if err != nil {
 			return err
 		}
@@ -68,7 +92,7 @@ func (s *loanPaymentService) MakePayment(paymentID int, loanID int) error {
 		}
 
 		// 6) Check if all payments are paid
-		payments, err := s.paymentRepo.ListByLoanID(loanID)
+		payments, err := paymentRepo.ListByLoanID(loanID)
 		if err != nil {
 			return err
 		}
@@ -81,16 +105,98 @@ func (s *loanPaymentService) MakePayment(paymentID int, loanID int) error {
 		}
 
 		// 7) If fully paid, close loan
+		loanStatus := loan.Status
 		if paidCount == loan.TermsMonths {
-			if err := s.loanRepo.UpdateStatus(loanID, "paid off"); err != nil {
+			if err := loanRepo.UpdateStatus(loanID, "paid off"); err != nil {
 				return err
 			}
+			loanStatus = "paid off"
 		}
 
-		return nil
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "loan_payment.pay",
+			EntityType: "loan_payment",
+			EntityID:   paymentID,
+			Before:     map[string]any{"status": payment.Status, "loan_status": loan.Status},
+			After:      map[string]any{"status": "paid", "loan_status": loanStatus, "loan_id": loanID, "amount": payment.Amount},
+		}); err != nil {
+			return err
+		}
+
+		return s.events.Record(tx, events.New(events.LoanPaymentPaid, loan.CustomerID, map[string]any{
+			"loan_id":    loanID,
+			"payment_id": paymentID,
+			"amount":     payment.Amount,
+			"due_date":   payment.DueDate,
+		}))
 	})
 }
 
 func (s *loanPaymentService) ListPayments(loanID int) ([]models.LoanPayment, error) {
 	return s.paymentRepo.ListByLoanID(loanID)
 }
+
+func (s *loanPaymentService) SendReminders(lead time.Duration) (int, error) {
+	now := time.Now()
+	sent := 0
+
+	due, err := s.paymentRepo.ListDueForReminder(now.Add(lead))
+	if err != nil {
+		return sent, err
+	}
+	for i := range due {
+		payment := &due[i]
+		eventType := events.LoanEMIDue
+		if payment.DueDate.Before(now) {
+			// it fell due before we got to remind; the overdue notice covers it
+			eventType = ""
+		}
+		ok, err := s.remind(payment, eventType, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok && eventType != "" {
+			sent++
+		}
+	}
+
+	overdue, err := s.paymentRepo.ListOverdueUnnotified(now)
+	if err != nil {
+		return sent, err
+	}
+	for i := range overdue {
+		ok, err := s.remind(&overdue[i], events.LoanEMIOverdue, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// remind marks the payment reminded (or overdue-notified) and records the
+// event in one transaction. An empty eventType only marks it.
+func (s *loanPaymentService) remind(payment *models.LoanPayment, eventType string, now time.Time) (bool, error) {
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.paymentRepo.WithTx(tx)
+		var err error
+		if eventType == events.LoanEMIOverdue {
+			claimed, err = repo.MarkOverdueNotified(payment.ID, now)
+		} else {
+			claimed, err = repo.MarkReminderSent(payment.ID, now)
+		}
+		if err != nil || !claimed || eventType == "" || payment.Loan == nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(eventType, payment.Loan.CustomerID, map[string]any{
+			"loan_id":    payment.LoanID,
+			"payment_id": payment.ID,
+			"amount":     payment.Amount,
+			"due_date":   payment.DueDate,
+		}))
+	})
+	return claimed, err
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"time"
 
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
 	"github.com/Mahesh252k/banking-api/internal/models"
 	"github.com/Mahesh252k/banking-api/internal/repositories"
 	"gorm.io/gorm"
@@ -13,16 +15,28 @@ type LoanService interface {
 	CreateLoan(req *models.CreateLoanRequest, customerID, branchID int) (*models.Loan, error)
 	ListLoans(customerID int) ([]models.Loan, error)
 	UpdateStatus(loanID int, status string) error
+	// WithAudit returns the service acting for meta's actor in the audit
+	// log.
+	WithAudit(meta audit.Meta) LoanService
 }
 
 type loanService struct {
 	db          *gorm.DB
 	loanRepo    repositories.LoanRepository
 	paymentRepo repositories.LoanPaymentRepository
+	events      events.Recorder
+	audit       audit.Recorder
+	meta        audit.Meta
 }
 
-func NewLoanService(db *gorm.DB, loanRepo repositories.LoanRepository, paymentRepo repositories.LoanPaymentRepository) LoanService {
-	return &loanService{db: db, loanRepo: loanRepo, paymentRepo: paymentRepo}
+func NewLoanService(db *gorm.DB, loanRepo repositories.LoanRepository, paymentRepo repositories.LoanPaymentRepository, recorder events.Recorder, auditor audit.Recorder) LoanService {
+	return &loanService{db: db, loanRepo: loanRepo, paymentRepo: paymentRepo, events: recorder, audit: auditor}
+}
+
+func (s *loanService) WithAudit(meta audit.Meta) LoanService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
 }
 
 // annualRate is like 10 for 10%
@@ -42,6 +56,9 @@ func (s *loanService) CreateLoan(req *models.CreateLoanRequest, customerID, bran
 	var loan *models.Loan
 
 	err := s.db.Transaction(func(tx *gorm.DB) error {
+		loanRepo := s.loanRepo.WithTx(tx)
+		paymentRepo := s.paymentRepo.WithTx(tx)
+
 		emi := calculateEMI(req.Amount, req.InterestRate, req.TermsMonths)
 		totalPayable := emi * float64(req.TermsMonths)
 
@@ -57,7 +74,7 @@ func (s *loanService) CreateLoan(req *models.CreateLoanRequest, customerID, bran
 			EndDate:      time.Now().AddDate(0, req.TermsMonths, 0),
 		}
 
-		if err := s.loanRepo.Create(loan); err != nil {
+		if err := loanRepo.Create(loan); err != nil {
 			return err
 		}
 
@@ -69,17 +86,40 @@ func (s *loanService) CreateLoan(req *models.CreateLoanRequest, customerID, bran
 				DueDate: dueDate,
 				Status:  "pending",
 			}
-			if err := s.paymentRepo.Create(payment); err != nil {
+			if err := paymentRepo.Create(payment); err != nil {
 				return err
 			}
 		}
 
-		fullLoan, err := s.loanRepo.GetByID(loan.ID)
+		fullLoan, err := loanRepo.GetByID(loan.ID)
 		if err != nil {
 			return err
 		}
 		loan = fullLoan
-		return nil
+
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "loan.create",
+			EntityType: "loan",
+			EntityID:   loan.ID,
+			After: map[string]any{
+				"customer_id":   customerID,
+				"amount":        loan.Amount,
+				"interest_rate": loan.InterestRate,
+				"terms_months":  loan.TermsMonths,
+				"total_payable": loan.TotalPayable,
+				"status":        loan.Status,
+			},
+		}); err != nil {
+			return err
+		}
+
+		return s.events.Record(tx, events.New(events.LoanCreated, customerID, map[string]any{
+			"loan_id":       loan.ID,
+			"amount":        loan.Amount,
+			"interest_rate": loan.InterestRate,
+			"terms_months":  loan.TermsMonths,
+			"total_payable": loan.TotalPayable,
+		}))
 	})
 
 	if err != nil {
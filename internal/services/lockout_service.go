@@ -0,0 +1,211 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Sign-in throttle key kinds.
+const (
+	LoginKeyUser  = "user"
+	LoginKeyStaff = "staff"
+	LoginKeyIP    = "ip"
+)
+
+// LoginKey names the throttle for a username or client address.
+func LoginKey(kind, value string) string {
+	return kind + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+type LockoutService interface {
+	// Check refuses when any key is locked or still waiting out the delay
+	// after its last failure, with a *models.LoginThrottledError.
+	Check(keys ...string) error
+	// Fail records a failed attempt against each key, locking those that
+	// reach their limit.
+	Fail(keys ...string) error
+	// Succeed forgets the key's failures.
+	Succeed(key string) error
+
+	ListLocked() ([]models.LoginThrottle, error)
+	Unlock(key string) error
+	Prune() (int64, error)
+
+	WithAudit(meta audit.Meta) LockoutService
+}
+
+// LockoutPolicy sets how one kind of key is throttled. After FreeAttempts
+// failures each attempt must wait BaseDelay, doubling per failure up to
+// MaxDelay; LockAfter failures lock the key for LockDuration. Failures
+// are forgotten after Window without one.
+type LockoutPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockDuration time.Duration
+	Window       time.Duration
+}
+
+// wait returns how long the key must wait at now, and whether that is a
+// lockout.
+func (p LockoutPolicy) wait(t *models.LoginThrottle, now time.Time) (time.Duration, bool) {
+	if t.LockedUntil != nil {
+		if now.Before(*t.LockedUntil) {
+			return t.LockedUntil.Sub(now), true
+		}
+		return 0, false
+	}
+	if now.Sub(t.LastFailureAt) > p.Window || t.Failures < p.FreeAttempts || p.BaseDelay <= 0 {
+		return 0, false
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < t.Failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if next := t.LastFailureAt.Add(delay); now.Before(next) {
+		return next.Sub(now), false
+	}
+	return 0, false
+}
+
+type lockoutService struct {
+	db       *gorm.DB
+	repo     repositories.ThrottleRepository
+	audit    audit.Recorder
+	meta     audit.Meta
+	policies map[string]LockoutPolicy
+}
+
+// NewLockoutService throttles each kind of key (LoginKeyUser, ...) by its
+// policy. Keys of other kinds are not throttled.
+func NewLockoutService(db *gorm.DB, repo repositories.ThrottleRepository, auditor audit.Recorder, policies map[string]LockoutPolicy) LockoutService {
+	return &lockoutService{db: db, repo: repo, audit: auditor, policies: policies}
+}
+
+func (s *lockoutService) WithAudit(meta audit.Meta) LockoutService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *lockoutService) policy(key string) (LockoutPolicy, bool) {
+	kind, _, _ := strings.Cut(key, ":")
+	p, ok := s.policies[kind]
+	return p, ok
+}
+
+func (s *lockoutService) Check(keys ...string) error {
+	now := time.Now()
+	var worst *models.LoginThrottledError
+	for _, key := range keys {
+		policy, ok := s.policy(key)
+		if !ok {
+			continue
+		}
+		throttle, err := s.repo.GetLogin(key)
+		if err != nil {
+			return err
+		}
+		if throttle == nil {
+			continue
+		}
+		wait, locked := policy.wait(throttle, now)
+		if wait > 0 && (worst == nil || wait > worst.RetryAfter) {
+			worst = &models.LoginThrottledError{RetryAfter: wait, Locked: locked}
+		}
+	}
+	if worst != nil {
+		return worst
+	}
+	return nil
+}
+
+func (s *lockoutService) Fail(keys ...string) error {
+	now := time.Now()
+	for _, key := range keys {
+		policy, ok := s.policy(key)
+		if !ok {
+			continue
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			repo := s.repo.WithTx(tx)
+			throttle, err := repo.GetLoginForUpdate(key)
+			if err != nil {
+				return err
+			}
+			// an expired lock or a quiet spell starts the count again
+			if throttle.LockedUntil != nil || now.Sub(throttle.LastFailureAt) > policy.Window {
+				if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+					return nil
+				}
+				throttle.Failures = 0
+				throttle.LockedUntil = nil
+			}
+			throttle.Failures++
+			throttle.LastFailureAt = now
+			if policy.LockAfter > 0 && throttle.Failures >= policy.LockAfter {
+				until := now.Add(policy.LockDuration)
+				throttle.LockedUntil = &until
+				if err := s.audit.Record(tx, s.meta, audit.Entry{
+					Action:     "auth.lockout",
+					EntityType: "login",
+					After:      throttle,
+				}); err != nil {
+					return err
+				}
+			}
+			return repo.SaveLogin(throttle)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *lockoutService) Succeed(key string) error {
+	_, err := s.repo.DeleteLogin(key)
+	return err
+}
+
+func (s *lockoutService) ListLocked() ([]models.LoginThrottle, error) {
+	return s.repo.ListLocked(time.Now())
+}
+
+func (s *lockoutService) Unlock(key string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		deleted, err := s.repo.WithTx(tx).DeleteLogin(key)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return models.ErrLockoutNotFound
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "auth.unlock",
+			EntityType: "login",
+			After:      map[string]string{"key": key},
+		})
+	})
+}
+
+// Prune forgets keys whose failures have all aged out of their window.
+func (s *lockoutService) Prune() (int64, error) {
+	var longest time.Duration
+	for _, p := range s.policies {
+		if p.Window > longest {
+			longest = p.Window
+		}
+	}
+	now := time.Now()
+	return s.repo.PruneLogins(now.Add(-longest), now)
+}
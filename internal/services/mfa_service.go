@@ -0,0 +1,359 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type MFAService interface {
+	Status(customerID int) (*models.MFAStatus, error)
+	// Enabled reports whether the customer has a confirmed authenticator.
+	Enabled(customerID int) (bool, error)
+
+	// BeginTOTP starts enrolling an authenticator app, replacing any
+	// enrolment not yet confirmed. ConfirmTOTP finishes it with a first
+	// code and returns the recovery codes, which are not shown again.
+	BeginTOTP(customerID int, password string) (*models.TOTPEnrolment, error)
+	ConfirmTOTP(customerID int, code string) ([]string, error)
+	// DisableTOTP takes a current code or a recovery code.
+	DisableTOTP(customerID int, password, code string) error
+	RegenerateRecoveryCodes(customerID int, code string) ([]string, error)
+
+	// VerifyTOTP checks a code from the customer's authenticator; each code
+	// is accepted once. VerifyRecoveryCode uses up a recovery code.
+	VerifyTOTP(customerID int, code string) error
+	VerifyRecoveryCode(customerID int, code string) error
+
+	WithAudit(meta audit.Meta) MFAService
+}
+
+// MFAConfig names the issuer shown in authenticator apps and how many
+// 30-second steps of clock drift to allow either way.
+type MFAConfig struct {
+	Issuer        string
+	Skew          int
+	RecoveryCodes int
+}
+
+type mfaService struct {
+	db        *gorm.DB
+	repo      repositories.MFARepository
+	customers repositories.CustomerRepository
+	sealer    *totp.Sealer
+	events    events.Recorder
+	audit     audit.Recorder
+	meta      audit.Meta
+	cfg       MFAConfig
+}
+
+func NewMFAService(
+	db *gorm.DB,
+	repo repositories.MFARepository,
+	customers repositories.CustomerRepository,
+	sealer *totp.Sealer,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+	cfg MFAConfig,
+) MFAService {
+	return &mfaService{
+		db:        db,
+		repo:      repo,
+		customers: customers,
+		sealer:    sealer,
+		events:    recorder,
+		audit:     auditor,
+		cfg:       cfg,
+	}
+}
+
+func (s *mfaService) WithAudit(meta audit.Meta) MFAService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *mfaService) Status(customerID int) (*models.MFAStatus, error) {
+	factor, err := s.repo.GetFactor(customerID)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.MFAStatus{}
+	if factor == nil || factor.ConfirmedAt == nil {
+		return status, nil
+	}
+	status.TOTPEnabled = true
+	status.EnabledAt = factor.ConfirmedAt
+	status.RecoveryCodesRemaining, err = s.repo.CountUnusedRecoveryCodes(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *mfaService) Enabled(customerID int) (bool, error) {
+	factor, err := s.repo.GetFactor(customerID)
+	if err != nil {
+		return false, err
+	}
+	return factor != nil && factor.ConfirmedAt != nil, nil
+}
+
+// checkPassword loads the customer and checks their password.
+func (s *mfaService) checkPassword(customerID int, password string) (*models.Customer, error) {
+	customer, err := s.customers.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, models.ErrCustomerNotFound
+	}
+	if bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(password)) != nil {
+		return nil, models.ErrIncorrectPassword
+	}
+	return customer, nil
+}
+
+func (s *mfaService) BeginTOTP(customerID int, password string) (*models.TOTPEnrolment, error) {
+	customer, err := s.checkPassword(customerID, password)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealer.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor != nil && factor.ConfirmedAt != nil {
+			return models.ErrMFAAlreadyEnabled
+		}
+		if factor == nil {
+			factor = &models.TOTPFactor{CustomerID: customerID}
+		}
+		factor.SealedSecret = sealed
+		factor.LastUsedStep = 0
+		return repo.SaveFactor(factor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.TOTPEnrolment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.Issuer, customer.Username, secret),
+	}, nil
+}
+
+// checkCode verifies a TOTP code against the locked factor and records
+// its step so it cannot be used again.
+func (s *mfaService) checkCode(repo repositories.MFARepository, factor *models.TOTPFactor, code string) error {
+	secret, err := s.sealer.Open(factor.SealedSecret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Verify(secret, code, time.Now(), s.cfg.Skew, factor.LastUsedStep)
+	if !ok {
+		return models.ErrInvalidMFACode
+	}
+	factor.LastUsedStep = step
+	return repo.SaveFactor(factor)
+}
+
+func (s *mfaService) ConfirmTOTP(customerID int, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor == nil || factor.ConfirmedAt != nil {
+			return models.ErrMFANotPending
+		}
+		now := time.Now()
+		factor.ConfirmedAt = &now
+		if err := s.checkCode(repo, factor, code); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(repo, customerID)
+		if err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "mfa.enable",
+			EntityType: "customer",
+			EntityID:   customerID,
+		}); err != nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.MFAEnabled, customerID, nil))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) DisableTOTP(customerID int, password, code string) error {
+	if _, err := s.checkPassword(customerID, password); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor == nil || factor.ConfirmedAt == nil {
+			return models.ErrMFANotEnabled
+		}
+		// a lost authenticator is the usual reason to disable, so a
+		// recovery code will do
+		if err := s.checkCode(repo, factor, code); errors.Is(err, models.ErrInvalidMFACode) {
+			used, err := repo.UseRecoveryCode(customerID, hashRecoveryCode(customerID, code), time.Now())
+			if err != nil {
+				return err
+			}
+			if !used {
+				return models.ErrInvalidMFACode
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if err := repo.DeleteFactor(customerID); err != nil {
+			return err
+		}
+		if err := repo.ReplaceRecoveryCodes(customerID, nil); err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "mfa.disable",
+			EntityType: "customer",
+			EntityID:   customerID,
+		}); err != nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.MFADisabled, customerID, nil))
+	})
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(customerID int, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor == nil || factor.ConfirmedAt == nil {
+			return models.ErrMFANotEnabled
+		}
+		if err := s.checkCode(repo, factor, code); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(repo, customerID)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "mfa.recovery_codes_regenerated",
+			EntityType: "customer",
+			EntityID:   customerID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) VerifyTOTP(customerID int, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor == nil || factor.ConfirmedAt == nil {
+			return models.ErrMFANotEnabled
+		}
+		return s.checkCode(repo, factor, code)
+	})
+}
+
+func (s *mfaService) VerifyRecoveryCode(customerID int, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		factor, err := repo.GetFactorForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if factor == nil || factor.ConfirmedAt == nil {
+			return models.ErrMFANotEnabled
+		}
+		used, err := repo.UseRecoveryCode(customerID, hashRecoveryCode(customerID, code), time.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return models.ErrInvalidMFACode
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "mfa.recovery_code_used",
+			EntityType: "customer",
+			EntityID:   customerID,
+		})
+	})
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// replaceRecoveryCodes issues a fresh set of codes like "ab3de-7fghk".
+func (s *mfaService) replaceRecoveryCodes(repo repositories.MFARepository, customerID int) ([]string, error) {
+	codes := make([]string, s.cfg.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(raw)[:10])
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(customerID, code)
+	}
+	if err := repo.ReplaceRecoveryCodes(customerID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed
+// back however they were written down.
+func hashRecoveryCode(customerID int, code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(strconv.Itoa(customerID) + ":" + normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+)
+
+// NotificationService backs the customer's inbox and preference endpoints;
+// delivery itself is done by notifications.Dispatcher.
+type NotificationService interface {
+	ListInbox(customerID int, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(id, customerID int) error
+	GetPreferences(customerID int) (*models.NotificationPreference, error)
+	UpdatePreferences(customerID int, req *models.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error)
+}
+
+type notificationService struct {
+	repo     repositories.NotificationRepository
+	prefRepo repositories.NotificationPreferenceRepository
+}
+
+func NewNotificationService(repo repositories.NotificationRepository, prefRepo repositories.NotificationPreferenceRepository) NotificationService {
+	return &notificationService{repo: repo, prefRepo: prefRepo}
+}
+
+func (s *notificationService) ListInbox(customerID int, unreadOnly bool) ([]models.Notification, error) {
+	return s.repo.ListByCustomerID(customerID, unreadOnly)
+}
+
+func (s *notificationService) MarkRead(id, customerID int) error {
+	found, err := s.repo.MarkRead(id, customerID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return models.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) GetPreferences(customerID int) (*models.NotificationPreference, error) {
+	pref, err := s.prefRepo.Get(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if pref == nil {
+		pref = models.DefaultNotificationPreference(customerID)
+	}
+	return pref, nil
+}
+
+func (s *notificationService) UpdatePreferences(customerID int, req *models.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	pref, err := s.GetPreferences(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		pref.Email = *req.Email
+	}
+	if req.SMS != nil {
+		pref.SMS = *req.SMS
+	}
+	if req.InApp != nil {
+		pref.InApp = *req.InApp
+	}
+	if req.LowBalanceThreshold != nil {
+		pref.LowBalanceThreshold = *req.LowBalanceThreshold
+	}
+	if req.MutedEvents != nil {
+		var muted []string
+		for _, e := range strings.Split(*req.MutedEvents, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				muted = append(muted, e)
+			}
+		}
+		pref.MutedEvents = strings.Join(muted, ",")
+	}
+
+	if err := s.prefRepo.Save(pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/paymentrail"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type OutgoingPaymentService interface {
+	CreatePayment(req *models.CreateOutgoingPaymentRequest, customerID, accountID int, opts TransferOptions) (*models.OutgoingPayment, error)
+	GetPayment(id, customerID int) (*models.OutgoingPayment, error)
+	ListPayments(accountID, customerID int) ([]models.OutgoingPayment, error)
+	SubmitPending() (int, error)
+	// ApplyStatusUpdate applies a rail update. One that fails for any
+	// reason but being out of order is kept for RetryStatusUpdates, so a
+	// return is never lost to a transient error.
+	ApplyStatusUpdate(update paymentrail.StatusUpdate) error
+	// RetryStatusUpdates applies the kept updates and returns how many
+	// went through.
+	RetryStatusUpdates() (int, error)
+}
+
+type outgoingPaymentService struct {
+	db                *gorm.DB
+	repo              repositories.OutgoingPaymentRepository
+	accountRepo       repositories.AccountRepository
+	txRepo            repositories.TransactionRepository
+	beneficiarySvc    BeneficiaryService
+	fraud             FraudService
+	rail              paymentrail.Rail
+	clearingAccountID int
+}
+
+// NewOutgoingPaymentService wires outbound payments to rail. Debits are
+// parked in the internal account clearingAccountID until the rail settles
+// or returns them. rail may be nil, in which case payments stay initiated
+// until something else (e.g. a clearing file export) picks them up. fraud
+// may be nil, in which case no fraud rules apply.
+func NewOutgoingPaymentService(
+	db *gorm.DB,
+	repo repositories.OutgoingPaymentRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	beneficiarySvc BeneficiaryService,
+	fraud FraudService,
+	rail paymentrail.Rail,
+	clearingAccountID int,
+) OutgoingPaymentService {
+	s := &outgoingPaymentService{
+		db:                db,
+		repo:              repo,
+		accountRepo:       accountRepo,
+		txRepo:            txRepo,
+		beneficiarySvc:    beneficiarySvc,
+		fraud:             fraud,
+		rail:              rail,
+		clearingAccountID: clearingAccountID,
+	}
+	if rail != nil {
+		rail.OnUpdate(s.ApplyStatusUpdate)
+	}
+	return s
+}
+
+// OwnedAccount loads an account and checks it belongs to customerID.
+func OwnedAccount(repo repositories.AccountRepository, accountID, customerID int) (*models.Account, error) {
+	account, err := repo.GetByID(accountID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, models.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// CreatePayment debits the account and hands the payment to the rail. The
+// fraud rules see the beneficiary as the payee; a challenge needs
+// opts.StepUpVerified and a hold declines the payment.
+func (s *outgoingPaymentService) CreatePayment(req *models.CreateOutgoingPaymentRequest, customerID, accountID int, opts TransferOptions) (*models.OutgoingPayment, error) {
+	if s.clearingAccountID == 0 {
+		return nil, models.ErrClearingAccountNotConfigured
+	}
+
+	account, err := OwnedAccount(s.accountRepo, accountID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	beneficiary, err := s.beneficiarySvc.GetBeneficiary(req.BeneficiaryID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.beneficiarySvc.CheckTransferAllowed(beneficiary, req.Amount); err != nil {
+		return nil, err
+	}
+
+	payment := &models.OutgoingPayment{
+		AccountID:     accountID,
+		BeneficiaryID: beneficiary.ID,
+		Amount:        req.Amount,
+		Currency:      account.Currency,
+		Reference:     req.Reference,
+		Status:        models.PaymentStatusInitiated,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// held until commit so the beneficiary is not deleted under us
+		if _, err := s.beneficiarySvc.LockBeneficiary(tx, beneficiary.ID, customerID); err != nil {
+			return err
+		}
+		if err := screenDebit(tx, s.fraud, FraudInput{
+			Operation:     models.FraudOpTransfer,
+			AccountID:     accountID,
+			BeneficiaryID: beneficiary.ID,
+			Amount:        req.Amount,
+		}, opts.StepUpVerified); err != nil {
+			return err
+		}
+		debit, err := s.moveFunds(tx, accountID, s.clearingAccountID, req.Amount, beneficiary.ID)
+		if err != nil {
+			return err
+		}
+		payment.DebitTransactionID = &debit.ID
+		return s.repo.WithTx(tx).Create(payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.submit(payment.ID, time.Time{}); err != nil {
+		log.Printf("outgoing payment %d: %v", payment.ID, err)
+	}
+	if submitted, err := s.repo.GetByID(payment.ID); err == nil && submitted != nil {
+		return submitted, nil
+	}
+	payment.Beneficiary = beneficiary
+	return payment, nil
+}
+
+// moveFunds debits fromID and credits toID inside tx, recording a
+// transaction linked to the beneficiary.
+func (s *outgoingPaymentService) moveFunds(tx *gorm.DB, fromID, toID int, amount float64, beneficiaryID int) (*models.Transaction, error) {
+	repo := s.accountRepo.WithTx(tx)
+
+	from, to, err := lockAccountPair(repo, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Balance-from.HeldAmount < amount {
+		return nil, models.ErrInsufficientFunds
+	}
+
+	from.Balance -= amount
+	to.Balance += amount
+	if err := repo.UpdateBalance(from); err != nil {
+		return nil, err
+	}
+	if err := repo.UpdateBalance(to); err != nil {
+		return nil, err
+	}
+
+	record := &models.Transaction{
+		FromAccountID: &fromID,
+		ToAccountID:   &toID,
+		BeneficiaryID: &beneficiaryID,
+		Amount:        amount,
+	}
+	if err := s.txRepo.WithTx(tx).Create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// lockAccountPair locks two accounts in ascending ID order, whichever way
+// the money moves, so payments and returns through the same clearing
+// account cannot deadlock each other. It returns them in argument order.
+func lockAccountPair(repo repositories.AccountRepository, firstID, secondID int) (*models.Account, *models.Account, error) {
+	lowID, highID := firstID, secondID
+	if lowID > highID {
+		lowID, highID = highID, lowID
+	}
+	low, err := repo.GetForUpdate(lowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	high, err := repo.GetForUpdate(highID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if firstID == lowID {
+		return low, high, nil
+	}
+	return high, low, nil
+}
+
+// submitClaimTimeout is how long a payment may stay submitting before
+// SubmitPending assumes the submitter died and submits it again.
+const submitClaimTimeout = 5 * time.Minute
+
+// submit claims an initiated payment, or one left submitting since before
+// staleBefore, saving the reference it goes to the rail under; only then
+// does it hand the payment over. Neither a concurrent submit nor a rail
+// update arriving early can miss it. A failed hand-over puts the payment
+// back to initiated for SubmitPending to retry. It reports whether the
+// payment was claimed.
+func (s *outgoingPaymentService) submit(id int, staleBefore time.Time) (bool, error) {
+	if s.rail == nil {
+		return false, nil
+	}
+
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		payment, err := repo.GetForUpdate(id)
+		if err != nil || payment == nil {
+			return err
+		}
+		stale := payment.Status == models.PaymentStatusSubmitting && payment.UpdatedAt.Before(staleBefore)
+		if payment.Status != models.PaymentStatusInitiated && !stale {
+			return nil
+		}
+		payment.Status = models.PaymentStatusSubmitting
+		payment.Rail = s.rail.Name()
+		payment.RailReference = endToEndID(payment.ID)
+		claimed = true
+		return repo.Update(payment)
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	payment, err := s.repo.GetByID(id)
+	if err != nil {
+		return true, err
+	}
+	if payment.Beneficiary == nil {
+		err = fmt.Errorf("beneficiary %d is gone", payment.BeneficiaryID)
+	} else {
+		err = s.rail.Submit(payment, payment.Beneficiary)
+	}
+	if err != nil {
+		if releaseErr := s.afterSubmit(id, models.PaymentStatusInitiated); releaseErr != nil {
+			log.Printf("outgoing payment %d: failed to release after failed submit: %v", id, releaseErr)
+		}
+		return true, fmt.Errorf("submit to %s failed: %w", s.rail.Name(), err)
+	}
+	return true, s.afterSubmit(id, models.PaymentStatusSubmitted)
+}
+
+// afterSubmit moves a payment still submitting to status. One the rail
+// has already settled or returned is left alone.
+func (s *outgoingPaymentService) afterSubmit(id int, status string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		payment, err := repo.GetForUpdate(id)
+		if err != nil || payment == nil || payment.Status != models.PaymentStatusSubmitting {
+			return err
+		}
+		payment.Status = status
+		if status == models.PaymentStatusSubmitted {
+			now := time.Now()
+			payment.SubmittedAt = &now
+		}
+		return repo.Update(payment)
+	})
+}
+
+func (s *outgoingPaymentService) GetPayment(id, customerID int) (*models.OutgoingPayment, error) {
+	payment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, models.ErrPaymentNotFound
+	}
+	if _, err := OwnedAccount(s.accountRepo, payment.AccountID, customerID); err != nil {
+		return nil, models.ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+func (s *outgoingPaymentService) ListPayments(accountID, customerID int) ([]models.OutgoingPayment, error) {
+	if _, err := OwnedAccount(s.accountRepo, accountID, customerID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByAccountID(accountID)
+}
+
+// SubmitPending submits payments still initiated, and those a crashed
+// submitter left submitting, and returns how many it claimed.
+func (s *outgoingPaymentService) SubmitPending() (int, error) {
+	if s.rail == nil {
+		return 0, nil
+	}
+
+	staleBefore := time.Now().Add(-submitClaimTimeout)
+	payments, err := s.repo.ListSubmittable(staleBefore, 100)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, p := range payments {
+		claimed, err := s.submit(p.ID, staleBefore)
+		if err != nil {
+			log.Printf("outgoing payment %d: %v", p.ID, err)
+		}
+		if claimed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ApplyStatusUpdate moves a submitted payment to settled or returned. A
+// return re-credits the customer's account from the clearing account.
+func (s *outgoingPaymentService) ApplyStatusUpdate(update paymentrail.StatusUpdate) error {
+	existing, err := s.repo.GetByRailReference(update.RailReference)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return models.ErrPaymentNotFound
+	}
+
+	err = s.applyStatus(existing.ID, update)
+	if err != nil && !errors.Is(err, models.ErrInvalidPaymentTransition) {
+		if keepErr := s.repo.KeepStatusUpdate(existing.ID, update.Status, update.Reason); keepErr != nil {
+			log.Printf("outgoing payment %d: keep %s update: %v", existing.ID, update.Status, keepErr)
+		}
+	}
+	return err
+}
+
+func (s *outgoingPaymentService) RetryStatusUpdates() (int, error) {
+	payments, err := s.repo.ListPendingStatus(100)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, p := range payments {
+		err := s.applyStatus(p.ID, paymentrail.StatusUpdate{
+			RailReference: p.RailReference,
+			Status:        p.PendingStatus,
+			Reason:        p.PendingReason,
+		})
+		switch {
+		case errors.Is(err, models.ErrInvalidPaymentTransition):
+			// overtaken by a later update; there is nothing left to apply
+			log.Printf("outgoing payment %d: dropping kept %s update: %v", p.ID, p.PendingStatus, err)
+			if err := s.repo.KeepStatusUpdate(p.ID, "", ""); err != nil {
+				return count, err
+			}
+		case err != nil:
+			log.Printf("outgoing payment %d: retry %s update: %v", p.ID, p.PendingStatus, err)
+		default:
+			count++
+		}
+	}
+	return count, nil
+}
+
+// applyStatus applies update to the payment in one transaction, clearing
+// any kept update it supersedes.
+func (s *outgoingPaymentService) applyStatus(id int, update paymentrail.StatusUpdate) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+
+		payment, err := repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if payment.Status == update.Status {
+			// duplicate notification
+			if payment.PendingStatus == "" {
+				return nil
+			}
+			payment.PendingStatus, payment.PendingReason = "", ""
+			return repo.Update(payment)
+		}
+
+		now := time.Now()
+		switch {
+		// a rail may report before submit has recorded the hand-over
+		case update.Status == models.PaymentStatusSettled &&
+			(payment.Status == models.PaymentStatusSubmitting || payment.Status == models.PaymentStatusSubmitted):
+			payment.SettledAt = &now
+
+		case update.Status == models.PaymentStatusReturned &&
+			(payment.Status == models.PaymentStatusSubmitting || payment.Status == models.PaymentStatusSubmitted ||
+				payment.Status == models.PaymentStatusSettled):
+			credit, err := s.moveFunds(tx, s.clearingAccountID, payment.AccountID, payment.Amount, payment.BeneficiaryID)
+			if err != nil {
+				return err
+			}
+			payment.ReturnTransactionID = &credit.ID
+			payment.ReturnReason = update.Reason
+			payment.ReturnedAt = &now
+
+		default:
+			return models.ErrInvalidPaymentTransition
+		}
+
+		payment.Status = update.Status
+		payment.PendingStatus, payment.PendingReason = "", ""
+		return repo.Update(payment)
+	})
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Mahesh252k/banking-api/internal/models"
+)
+
+// ValidatePassword applies the password policy to a new password: 8 to
+// 72 characters (bcrypt ignores anything longer), at least one letter and
+// one digit, and not containing the username.
+func ValidatePassword(password, username string) error {
+	if len(password) < 8 {
+		return &models.PasswordPolicyError{Reason: "must be at least 8 characters"}
+	}
+	if len(password) > 72 {
+		return &models.PasswordPolicyError{Reason: "must be at most 72 bytes"}
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return &models.PasswordPolicyError{Reason: "must contain a letter and a digit"}
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return &models.PasswordPolicyError{Reason: "must not contain the username"}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type PasswordResetService interface {
+	// RequestReset sends a reset token to the customer with the given
+	// username or email. It says nothing about whether one exists.
+	RequestReset(login string) error
+	// ResetPassword sets a new password with a token and signs the
+	// customer out everywhere.
+	ResetPassword(token, newPassword string) error
+
+	WithAudit(meta audit.Meta) PasswordResetService
+}
+
+// ResetSender delivers a password reset token to the customer.
+type ResetSender interface {
+	SendReset(customer *models.Customer, token string, expiresAt time.Time) error
+}
+
+type passwordResetService struct {
+	db        *gorm.DB
+	repo      repositories.PasswordResetRepository
+	customers repositories.CustomerRepository
+	history   repositories.ProfileRepository
+	sessions  repositories.SessionRepository
+	sender    ResetSender
+	events    events.Recorder
+	audit     audit.Recorder
+	meta      audit.Meta
+	ttl       time.Duration
+}
+
+// NewPasswordResetService issues tokens that are good for ttl.
+func NewPasswordResetService(
+	db *gorm.DB,
+	repo repositories.PasswordResetRepository,
+	customers repositories.CustomerRepository,
+	history repositories.ProfileRepository,
+	sessions repositories.SessionRepository,
+	sender ResetSender,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+	ttl time.Duration,
+) PasswordResetService {
+	return &passwordResetService{
+		db:        db,
+		repo:      repo,
+		customers: customers,
+		history:   history,
+		sessions:  sessions,
+		sender:    sender,
+		events:    recorder,
+		audit:     auditor,
+		ttl:       ttl,
+	}
+}
+
+func (s *passwordResetService) WithAudit(meta audit.Meta) PasswordResetService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *passwordResetService) RequestReset(login string) error {
+	customer, err := s.customers.GetByLogin(strings.TrimSpace(login))
+	if err != nil || customer == nil {
+		return err
+	}
+
+	var raw [32]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw[:])
+	now := time.Now()
+	record := &models.PasswordResetToken{
+		CustomerID: customer.ID,
+		TokenHash:  hashResetToken(token),
+		ExpiresAt:  now.Add(s.ttl),
+		IP:         s.meta.IP,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.UseAll(customer.ID, now); err != nil {
+			return err
+		}
+		if err := repo.Create(record); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "auth.password_reset_requested",
+			EntityType: "customer",
+			EntityID:   customer.ID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// sent in the background so the response takes as long whether or not
+	// the account exists
+	go func() {
+		if err := s.sender.SendReset(customer, token, record.ExpiresAt); err != nil {
+			log.Printf("send password reset to customer %d: %v", customer.ID, err)
+		}
+	}()
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		record, err := repo.GetByHashForUpdate(hashResetToken(token))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if record == nil || record.UsedAt != nil || now.After(record.ExpiresAt) {
+			return models.ErrInvalidResetToken
+		}
+
+		customers := s.customers.WithTx(tx)
+		customer, err := customers.GetForUpdate(record.CustomerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrInvalidResetToken
+		}
+		if err := ValidatePassword(newPassword, customer.Username); err != nil {
+			return err
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), 14)
+		if err != nil {
+			return err
+		}
+		customer.PasswordHash = string(hashed)
+		customer.SessionsRevokedAt = &now
+		if err := customers.Update(customer, "PasswordHash", "SessionsRevokedAt"); err != nil {
+			return err
+		}
+		if err := repo.UseAll(customer.ID, now); err != nil {
+			return err
+		}
+		if err := s.sessions.WithTx(tx).RevokeAll(customer.ID, models.SessionPasswordReset, now); err != nil {
+			return err
+		}
+
+		change := models.ProfileChange{CustomerID: customer.ID, Field: "password", RequestID: s.meta.RequestID}
+		if err := s.history.WithTx(tx).AddChanges([]models.ProfileChange{change}); err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "auth.password_reset",
+			EntityType: "customer",
+			EntityID:   customer.ID,
+		}); err != nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.PasswordChanged, customer.ID, nil))
+	})
+}
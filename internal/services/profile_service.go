@@ -0,0 +1,381 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type ProfileService interface {
+	GetProfile(customerID int) (*models.Customer, error)
+	// UpdateProfile changes the fields that need no verification. A new
+	// name is screened against the sanctions list.
+	UpdateProfile(customerID int, req *models.UpdateProfileRequest) (*models.Customer, error)
+	// RequestContactChange sends a code to the new email address or phone
+	// number; ConfirmContactChange applies it once the code is entered.
+	RequestContactChange(customerID int, channel, value string) (*models.ContactChange, error)
+	ConfirmContactChange(customerID, changeID int, code string) (*models.Customer, error)
+	ChangePassword(customerID int, current, next string) error
+	ListChanges(customerID, limit int) ([]models.ProfileChange, error)
+
+	WithAudit(meta audit.Meta) ProfileService
+}
+
+// CodeSender delivers a one-time code to an address that is not on the
+// customer's profile yet.
+type CodeSender interface {
+	SendCode(customerID int, channel, to, code string) error
+}
+
+// ProfileConfig bounds the contact verification codes.
+type ProfileConfig struct {
+	CodeTTL         time.Duration
+	MaxCodeAttempts int
+}
+
+type profileService struct {
+	db        *gorm.DB
+	repo      repositories.ProfileRepository
+	customers repositories.CustomerRepository
+	sender    CodeSender
+	sanctions SanctionsService
+	events    events.Recorder
+	audit     audit.Recorder
+	meta      audit.Meta
+	cfg       ProfileConfig
+}
+
+// NewProfileService builds the service. sanctions may be nil, in which
+// case name changes are not screened.
+func NewProfileService(
+	db *gorm.DB,
+	repo repositories.ProfileRepository,
+	customers repositories.CustomerRepository,
+	sender CodeSender,
+	sanctions SanctionsService,
+	recorder events.Recorder,
+	auditor audit.Recorder,
+	cfg ProfileConfig,
+) ProfileService {
+	return &profileService{
+		db:        db,
+		repo:      repo,
+		customers: customers,
+		sender:    sender,
+		sanctions: sanctions,
+		events:    recorder,
+		audit:     auditor,
+		cfg:       cfg,
+	}
+}
+
+func (s *profileService) WithAudit(meta audit.Meta) ProfileService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *profileService) GetProfile(customerID int) (*models.Customer, error) {
+	customer, err := s.customers.GetByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, models.ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+// profileUpdate collects changed fields for one save, its history and
+// its audit entry.
+type profileUpdate struct {
+	columns []string
+	changes []models.ProfileChange
+}
+
+func (u *profileUpdate) set(field, column string, target *string, value string) {
+	if *target == value {
+		return
+	}
+	u.changes = append(u.changes, models.ProfileChange{Field: field, OldValue: *target, NewValue: value})
+	u.columns = append(u.columns, column)
+	*target = value
+}
+
+func (u *profileUpdate) fields() string {
+	names := make([]string, len(u.changes))
+	for i, c := range u.changes {
+		names[i] = strings.ReplaceAll(c.Field, "_", " ")
+	}
+	return strings.Join(names, ", ")
+}
+
+// save writes the customer's changed columns, the history, the audit entry
+// and the notification in tx.
+func (s *profileService) save(tx *gorm.DB, customer *models.Customer, before models.Customer, u *profileUpdate) error {
+	if len(u.changes) == 0 {
+		return nil
+	}
+	if err := s.customers.WithTx(tx).Update(customer, u.columns...); err != nil {
+		return err
+	}
+	for i := range u.changes {
+		u.changes[i].CustomerID = customer.ID
+		u.changes[i].RequestID = s.meta.RequestID
+	}
+	if err := s.repo.WithTx(tx).AddChanges(u.changes); err != nil {
+		return err
+	}
+	if err := s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "customer.update",
+		EntityType: "customer",
+		EntityID:   customer.ID,
+		Before:     before,
+		After:      customer,
+	}); err != nil {
+		return err
+	}
+	return s.events.Record(tx, events.New(events.ProfileUpdated, customer.ID, map[string]any{
+		"fields": u.fields(),
+	}))
+}
+
+func (s *profileService) UpdateProfile(customerID int, req *models.UpdateProfileRequest) (*models.Customer, error) {
+	var customer *models.Customer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		customer, err = s.customers.WithTx(tx).GetForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrCustomerNotFound
+		}
+		before := *customer
+
+		u := &profileUpdate{}
+		if req.FirstName != nil {
+			u.set("first_name", "FirstName", &customer.FirstName, strings.TrimSpace(*req.FirstName))
+		}
+		if req.LastName != nil {
+			u.set("last_name", "LastName", &customer.LastName, strings.TrimSpace(*req.LastName))
+		}
+		if req.Address != nil {
+			u.set("address", "Address", &customer.Address, strings.TrimSpace(*req.Address))
+		}
+		if customer.FirstName == "" || customer.LastName == "" {
+			return models.ErrBlankName
+		}
+
+		if (customer.FirstName != before.FirstName || customer.LastName != before.LastName) && s.sanctions != nil {
+			if err := s.screenName(tx, customer, u); err != nil {
+				return err
+			}
+		}
+		return s.save(tx, customer, before, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// screenName screens a new name. As with re-screening, an existing
+// customer is put under review rather than blocked outright.
+func (s *profileService) screenName(tx *gorm.DB, customer *models.Customer, u *profileUpdate) error {
+	screening := s.sanctions.Screen(customer.FirstName + " " + customer.LastName)
+	created, err := s.sanctions.RecordHits(tx, models.SanctionsSubjectCustomer, customer.ID, customer.ID, screening, models.SanctionsHitPending)
+	if err != nil {
+		return err
+	}
+	if created > 0 && customer.ScreeningStatus == models.ScreeningClear {
+		customer.ScreeningStatus = models.ScreeningReview
+		u.columns = append(u.columns, "ScreeningStatus")
+	}
+	return nil
+}
+
+func (s *profileService) RequestContactChange(customerID int, channel, value string) (*models.ContactChange, error) {
+	value = strings.TrimSpace(value)
+	if channel == models.ChannelEmail {
+		value = strings.ToLower(value)
+		owner, err := s.customers.GetByEmail(value)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			return nil, models.ErrEmailInUse
+		}
+	}
+
+	code, err := verificationCode()
+	if err != nil {
+		return nil, err
+	}
+	change := &models.ContactChange{
+		CustomerID: customerID,
+		Channel:    channel,
+		NewValue:   value,
+		Status:     models.ContactChangePending,
+		ExpiresAt:  time.Now().Add(s.cfg.CodeTTL),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.CancelPendingContactChanges(customerID, channel); err != nil {
+			return err
+		}
+		if err := repo.CreateContactChange(change); err != nil {
+			return err
+		}
+		// the hash covers the change id so a code is only good for its own change
+		change.CodeHash = hashCode(change.ID, code)
+		if err := repo.UpdateContactChange(change); err != nil {
+			return err
+		}
+		// sent last: if queueing fails nothing is kept
+		return s.sender.SendCode(customerID, channel, value, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (s *profileService) ConfirmContactChange(customerID, changeID int, code string) (*models.Customer, error) {
+	var customer *models.Customer
+	var codeErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		change, err := repo.GetContactChangeForUpdate(changeID)
+		if err != nil {
+			return err
+		}
+		if change == nil || change.CustomerID != customerID {
+			return models.ErrContactChangeNotFound
+		}
+		if change.Status != models.ContactChangePending {
+			return models.ErrContactChangeNotPending
+		}
+		now := time.Now()
+		if now.After(change.ExpiresAt) || change.Attempts >= s.cfg.MaxCodeAttempts {
+			return models.ErrContactChangeExpired
+		}
+
+		if subtle.ConstantTimeCompare([]byte(change.CodeHash), []byte(hashCode(change.ID, code))) != 1 {
+			// count the attempt, and commit it, before reporting the bad code
+			change.Attempts++
+			codeErr = models.ErrInvalidVerificationCode
+			return repo.UpdateContactChange(change)
+		}
+
+		customer, err = s.customers.WithTx(tx).GetForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrCustomerNotFound
+		}
+		before := *customer
+
+		u := &profileUpdate{}
+		if change.Channel == models.ChannelEmail {
+			owner, err := s.customers.WithTx(tx).GetByEmail(change.NewValue)
+			if err != nil {
+				return err
+			}
+			if owner != nil && owner.ID != customerID {
+				return models.ErrEmailInUse
+			}
+			u.set("email", "Email", &customer.Email, change.NewValue)
+		} else {
+			u.set("phone", "Phone", &customer.Phone, change.NewValue)
+		}
+
+		change.Status = models.ContactChangeConfirmed
+		change.ConfirmedAt = &now
+		if err := repo.UpdateContactChange(change); err != nil {
+			return err
+		}
+		return s.save(tx, customer, before, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if codeErr != nil {
+		return nil, codeErr
+	}
+	return customer, nil
+}
+
+func (s *profileService) ChangePassword(customerID int, current, next string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		customer, err := s.customers.WithTx(tx).GetForUpdate(customerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrCustomerNotFound
+		}
+		if bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(current)) != nil {
+			return models.ErrIncorrectPassword
+		}
+		if current == next {
+			return models.ErrSamePassword
+		}
+		if err := ValidatePassword(next, customer.Username); err != nil {
+			return err
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(next), 14)
+		if err != nil {
+			return err
+		}
+		customer.PasswordHash = string(hashed)
+		if err := s.customers.WithTx(tx).Update(customer, "PasswordHash"); err != nil {
+			return err
+		}
+		change := models.ProfileChange{CustomerID: customer.ID, Field: "password", RequestID: s.meta.RequestID}
+		if err := s.repo.WithTx(tx).AddChanges([]models.ProfileChange{change}); err != nil {
+			return err
+		}
+		if err := s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "customer.change_password",
+			EntityType: "customer",
+			EntityID:   customer.ID,
+		}); err != nil {
+			return err
+		}
+		return s.events.Record(tx, events.New(events.PasswordChanged, customer.ID, nil))
+	})
+}
+
+func (s *profileService) ListChanges(customerID, limit int) ([]models.ProfileChange, error) {
+	return s.repo.ListChanges(customerID, limit)
+}
+
+// verificationCode returns six random digits.
+func verificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashCode(changeID int, code string) string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(changeID) + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/sanctions"
+	"gorm.io/gorm"
+)
+
+type SanctionsService interface {
+	// Reload reads the list file again and reports whether it changed.
+	Reload() (bool, error)
+	// CurrentList describes the loaded list.
+	CurrentList() (*models.SanctionsList, error)
+	// Screen checks names against the loaded list. Without a list every
+	// subject is clear.
+	Screen(names ...string) *models.ScreeningResult
+	// RecordHits stores the result's matches for a subject with the given
+	// hit status, returning how many were new. It takes part in the
+	// caller's transaction.
+	RecordHits(tx *gorm.DB, subjectType string, subjectID, customerID int, result *models.ScreeningResult, status string) (int, error)
+	// Rescreen screens every customer and beneficiary against the loaded
+	// list. New hits are queued for review.
+	Rescreen() (*models.RescreenResult, error)
+	// RescreenIfChanged reloads the list and re-screens when this version
+	// has not been screened yet. It returns nil when there was nothing to do.
+	RescreenIfChanged() (*models.RescreenResult, error)
+
+	ListHits(status string, limit int) ([]models.SanctionsHit, error)
+	GetHit(id int) (*models.SanctionsHit, error)
+	// ReviewHit confirms a pending hit, which blocks the subject, or
+	// dismisses it, which clears the subject once nothing else is pending.
+	ReviewHit(id int, confirm bool, note string) (*models.SanctionsHit, error)
+
+	WithAudit(meta audit.Meta) SanctionsService
+}
+
+// SanctionsConfig sets where the list lives and how close a name must be
+// to a listed one. Scores run from 0 to 1; at ReviewThreshold a match is
+// queued for staff, at BlockThreshold onboarding is refused outright.
+type SanctionsConfig struct {
+	Path            string
+	ReviewThreshold float64
+	BlockThreshold  float64
+}
+
+// sanctionsState is shared by every copy WithAudit makes, so a reload is
+// seen by all of them.
+type sanctionsState struct {
+	mu   sync.RWMutex
+	list *sanctions.List
+}
+
+type sanctionsService struct {
+	db            *gorm.DB
+	repo          repositories.SanctionsRepository
+	customers     repositories.CustomerRepository
+	beneficiaries repositories.BeneficiaryRepository
+	audit         audit.Recorder
+	meta          audit.Meta
+	cfg           SanctionsConfig
+	state         *sanctionsState
+}
+
+const rescreenBatchSize = 500
+
+func NewSanctionsService(
+	db *gorm.DB,
+	repo repositories.SanctionsRepository,
+	customers repositories.CustomerRepository,
+	beneficiaries repositories.BeneficiaryRepository,
+	auditor audit.Recorder,
+	cfg SanctionsConfig,
+) SanctionsService {
+	return &sanctionsService{
+		db:            db,
+		repo:          repo,
+		customers:     customers,
+		beneficiaries: beneficiaries,
+		audit:         auditor,
+		cfg:           cfg,
+		state:         &sanctionsState{},
+	}
+}
+
+func (s *sanctionsService) WithAudit(meta audit.Meta) SanctionsService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *sanctionsService) loaded() *sanctions.List {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+	return s.state.list
+}
+
+func (s *sanctionsService) Reload() (bool, error) {
+	if s.cfg.Path == "" {
+		return false, nil
+	}
+	list, err := sanctions.Load(s.cfg.Path)
+	if err != nil {
+		return false, err
+	}
+	if current := s.loaded(); current != nil && current.Version == list.Version {
+		return false, nil
+	}
+
+	existing, err := s.repo.GetList(list.Version)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		err = s.repo.SaveList(&models.SanctionsList{
+			Version:  list.Version,
+			Source:   list.Source,
+			Entries:  len(list.Entries),
+			LoadedAt: time.Now(),
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	s.state.mu.Lock()
+	s.state.list = list
+	s.state.mu.Unlock()
+	return true, nil
+}
+
+func (s *sanctionsService) CurrentList() (*models.SanctionsList, error) {
+	list := s.loaded()
+	if list == nil {
+		return nil, models.ErrSanctionsListNotLoaded
+	}
+	stored, err := s.repo.GetList(list.Version)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, models.ErrSanctionsListNotLoaded
+	}
+	return stored, nil
+}
+
+func (s *sanctionsService) Screen(names ...string) *models.ScreeningResult {
+	list := s.loaded()
+	if list == nil {
+		return &models.ScreeningResult{Status: models.ScreeningClear}
+	}
+
+	result := &models.ScreeningResult{
+		Status:      models.ScreeningClear,
+		ListVersion: list.Version,
+		Matches:     list.Screen(s.cfg.ReviewThreshold, names...),
+	}
+	if len(result.Matches) > 0 {
+		// matches are best first
+		result.Status = models.ScreeningReview
+		if result.Matches[0].Score >= s.cfg.BlockThreshold {
+			result.Status = models.ScreeningBlocked
+		}
+	}
+	return result
+}
+
+func (s *sanctionsService) RecordHits(tx *gorm.DB, subjectType string, subjectID, customerID int, result *models.ScreeningResult, status string) (int, error) {
+	repo := s.repo.WithTx(tx)
+	created := 0
+	for _, match := range result.Matches {
+		hit := &models.SanctionsHit{
+			SubjectType:  subjectType,
+			SubjectID:    subjectID,
+			CustomerID:   customerID,
+			ScreenedName: match.ScreenedName,
+			EntryID:      match.EntryID,
+			EntryName:    match.EntryName,
+			MatchedName:  match.MatchedName,
+			Score:        match.Score,
+			ListVersion:  result.ListVersion,
+			Status:       status,
+		}
+		ok, err := repo.CreateHit(hit)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+func (s *sanctionsService) RescreenIfChanged() (*models.RescreenResult, error) {
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	current, err := s.CurrentList()
+	if err == models.ErrSanctionsListNotLoaded {
+		return nil, nil
+	}
+	if err != nil || current.ScreenedAt != nil {
+		return nil, err
+	}
+	return s.Rescreen()
+}
+
+func (s *sanctionsService) Rescreen() (*models.RescreenResult, error) {
+	list := s.loaded()
+	if list == nil {
+		return nil, models.ErrSanctionsListNotLoaded
+	}
+	result := &models.RescreenResult{ListVersion: list.Version}
+
+	for after := 0; ; {
+		customers, err := s.customers.ListAfter(after, rescreenBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, customer := range customers {
+			name := strings.TrimSpace(customer.FirstName + " " + customer.LastName)
+			created, err := s.queueHits(models.SanctionsSubjectCustomer, customer.ID, customer.ID, customer.ScreeningStatus, name)
+			if err != nil {
+				return nil, err
+			}
+			result.NewHits += created
+			after = customer.ID
+		}
+		result.Customers += len(customers)
+		if len(customers) < rescreenBatchSize {
+			break
+		}
+	}
+
+	for after := 0; ; {
+		beneficiaries, err := s.beneficiaries.ListAfter(after, rescreenBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range beneficiaries {
+			created, err := s.queueHits(models.SanctionsSubjectBeneficiary, b.ID, b.CustomerID, b.ScreeningStatus, b.Name, b.BankName)
+			if err != nil {
+				return nil, err
+			}
+			result.NewHits += created
+			after = b.ID
+		}
+		result.Beneficiaries += len(beneficiaries)
+		if len(beneficiaries) < rescreenBatchSize {
+			break
+		}
+	}
+
+	if err := s.repo.MarkListScreened(list.Version, time.Now()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// queueHits screens an existing subject. Customers and beneficiaries who
+// are already on the books are never blocked automatically; new hits put
+// them under review until staff decide.
+func (s *sanctionsService) queueHits(subjectType string, subjectID, customerID int, status string, names ...string) (int, error) {
+	screening := s.Screen(names...)
+	if len(screening.Matches) == 0 {
+		return 0, nil
+	}
+
+	created := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = s.RecordHits(tx, subjectType, subjectID, customerID, screening, models.SanctionsHitPending)
+		if err != nil || created == 0 || status != models.ScreeningClear {
+			return err
+		}
+		return s.setSubjectStatus(tx, subjectType, subjectID, models.ScreeningReview)
+	})
+	return created, err
+}
+
+func (s *sanctionsService) setSubjectStatus(tx *gorm.DB, subjectType string, subjectID int, status string) error {
+	if subjectType == models.SanctionsSubjectBeneficiary {
+		return s.beneficiaries.WithTx(tx).UpdateScreeningStatus(subjectID, status)
+	}
+	return s.customers.WithTx(tx).UpdateScreeningStatus(subjectID, status)
+}
+
+func (s *sanctionsService) ListHits(status string, limit int) ([]models.SanctionsHit, error) {
+	return s.repo.ListHits(status, limit)
+}
+
+func (s *sanctionsService) GetHit(id int) (*models.SanctionsHit, error) {
+	hit, err := s.repo.GetHit(id)
+	if err != nil {
+		return nil, err
+	}
+	if hit == nil {
+		return nil, models.ErrSanctionsHitNotFound
+	}
+	return hit, nil
+}
+
+func (s *sanctionsService) ReviewHit(id int, confirm bool, note string) (*models.SanctionsHit, error) {
+	var hit *models.SanctionsHit
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		hit, err = repo.GetHitForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if hit == nil {
+			return models.ErrSanctionsHitNotFound
+		}
+		if hit.Status != models.SanctionsHitPending {
+			return models.ErrSanctionsHitNotPending
+		}
+		before := *hit
+
+		now := time.Now()
+		hit.ReviewNote = note
+		hit.ReviewedBy = s.meta.ActorID
+		hit.ReviewedAt = &now
+		hit.Status = models.SanctionsHitDismissed
+		if confirm {
+			hit.Status = models.SanctionsHitConfirmed
+		}
+		if err := repo.UpdateHit(hit); err != nil {
+			return err
+		}
+
+		if confirm {
+			if err := s.setSubjectStatus(tx, hit.SubjectType, hit.SubjectID, models.ScreeningBlocked); err != nil {
+				return err
+			}
+		} else if err := s.clearIfResolved(tx, hit); err != nil {
+			return err
+		}
+
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "sanctions.review",
+			EntityType: "sanctions_hit",
+			EntityID:   hit.ID,
+			Before:     before,
+			After:      hit,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hit, nil
+}
+
+// clearIfResolved clears a subject under review once its last pending hit
+// is dismissed. A subject already blocked by a confirmed hit stays blocked.
+func (s *sanctionsService) clearIfResolved(tx *gorm.DB, hit *models.SanctionsHit) error {
+	pending, err := s.repo.WithTx(tx).CountPendingHits(hit.SubjectType, hit.SubjectID)
+	if err != nil || pending > 0 {
+		return err
+	}
+
+	var status string
+	if hit.SubjectType == models.SanctionsSubjectBeneficiary {
+		b, err := s.beneficiaries.WithTx(tx).GetByID(hit.SubjectID)
+		if err != nil || b == nil {
+			return err
+		}
+		status = b.ScreeningStatus
+	} else {
+		c, err := s.customers.WithTx(tx).GetByID(hit.SubjectID)
+		if err != nil || c == nil {
+			return err
+		}
+		status = c.ScreeningStatus
+	}
+	if status != models.ScreeningReview {
+		return nil
+	}
+	return s.setSubjectStatus(tx, hit.SubjectType, hit.SubjectID, models.ScreeningClear)
+}
+
+// CheckScreening refuses to move money for a customer who is blocked or
+// awaiting sanctions review. Every path that debits a customer calls it,
+// including those no customer request is behind: card and terminal
+// authorizations and scheduled payments.
+func CheckScreening(customers repositories.CustomerRepository, customerID int) error {
+	customer, err := customers.GetByID(customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return models.ErrCustomerNotFound
+	}
+	switch customer.ScreeningStatus {
+	case models.ScreeningBlocked:
+		return models.ErrSanctionsBlocked
+	case models.ScreeningReview:
+		return models.ErrSanctionsUnderReview
+	}
+	return nil
+}
+
+// screeningDecline reports whether err is CheckScreening turning the
+// customer away, as opposed to failing to look.
+func screeningDecline(err error) bool {
+	return errors.Is(err, models.ErrSanctionsBlocked) || errors.Is(err, models.ErrSanctionsUnderReview)
+}
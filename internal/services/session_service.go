@@ -0,0 +1,331 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+	"gorm.io/gorm"
+)
+
+type SessionService interface {
+	// Start signs the customer in on a new session and returns its first
+	// token pair. amr lists the methods the customer authenticated with.
+	Start(customerID int, amr []string) (*models.TokenPair, error)
+	// Refresh exchanges a refresh token for a new pair. A token that was
+	// already exchanged revokes its whole session.
+	Refresh(refreshToken string) (*models.TokenPair, error)
+	// StepUp records that the customer just authenticated again with
+	// method and returns an access token that says so.
+	StepUp(customerID int, sessionID, method string) (*models.TokenPair, error)
+	// Check reports whether access tokens from the session are still good.
+	Check(customerID int, sessionID string) error
+	// Logout ends one session of the customer, or all of them when
+	// sessionID is empty.
+	Logout(customerID int, sessionID string) error
+	ListSessions(customerID int) ([]models.Session, error)
+	Prune(before time.Time) (int64, error)
+
+	WithAudit(meta audit.Meta) SessionService
+}
+
+// SessionConfig sets token lifetimes. A session lasts as long as its
+// refresh tokens keep being used within RefreshTTL.
+type SessionConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type sessionService struct {
+	db        *gorm.DB
+	repo      repositories.SessionRepository
+	customers repositories.CustomerRepository
+	audit     audit.Recorder
+	meta      audit.Meta
+	cfg       SessionConfig
+}
+
+func NewSessionService(
+	db *gorm.DB,
+	repo repositories.SessionRepository,
+	customers repositories.CustomerRepository,
+	auditor audit.Recorder,
+	cfg SessionConfig,
+) SessionService {
+	return &sessionService{
+		db:        db,
+		repo:      repo,
+		customers: customers,
+		audit:     auditor,
+		cfg:       cfg,
+	}
+}
+
+func (s *sessionService) WithAudit(meta audit.Meta) SessionService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func randomToken(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueRefreshToken stores a new link in the session's chain and returns
+// the raw token.
+func (s *sessionService) issueRefreshToken(repo repositories.SessionRepository, session *models.Session) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = repo.CreateRefreshToken(&models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: session.ExpiresAt,
+	})
+	return token, err
+}
+
+func (s *sessionService) pair(session *models.Session, refreshToken string) (*models.TokenPair, error) {
+	var amr []string
+	if session.AMR != "" {
+		amr = strings.Split(session.AMR, ",")
+	}
+	access, err := auth.GenerateToken(session.CustomerID, session.ID, amr, session.AuthTime, s.cfg.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenPair{
+		Token:        access,
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.cfg.AccessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		SessionID:    session.ID,
+	}, nil
+}
+
+func (s *sessionService) Start(customerID int, amr []string) (*models.TokenPair, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         hex.EncodeToString(id[:]),
+		CustomerID: customerID,
+		IP:         s.meta.IP,
+		UserAgent:  s.meta.UserAgent,
+		AMR:        strings.Join(amr, ","),
+		AuthTime:   now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.cfg.RefreshTTL),
+	}
+
+	var refresh string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Create(session); err != nil {
+			return err
+		}
+		var err error
+		refresh, err = s.issueRefreshToken(repo, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(session, refresh)
+}
+
+func (s *sessionService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	var session *models.Session
+	var next string
+	var reuseErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		token, err := repo.GetRefreshTokenForUpdate(hashRefreshToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			return models.ErrInvalidRefreshToken
+		}
+		session, err = repo.GetForUpdate(token.SessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return models.ErrInvalidRefreshToken
+		}
+		now := time.Now()
+
+		if token.UsedAt != nil {
+			// either the customer or a thief holds a newer token; we cannot
+			// tell which, so the session ends for both. The revocation is
+			// committed before the error is reported.
+			reuseErr = models.ErrRefreshTokenReused
+			if session.RevokedAt != nil {
+				return nil
+			}
+			return s.revoke(tx, session, models.SessionTokenReuse, now)
+		}
+		if session.RevokedAt != nil || now.After(token.ExpiresAt) {
+			return models.ErrInvalidRefreshToken
+		}
+
+		customer, err := s.customers.WithTx(tx).GetByID(session.CustomerID)
+		if err != nil {
+			return err
+		}
+		if customer == nil {
+			return models.ErrInvalidRefreshToken
+		}
+		if customer.ScreeningStatus == models.ScreeningBlocked {
+			return models.ErrSanctionsBlocked
+		}
+
+		if err := repo.MarkRefreshTokenUsed(token.ID, now); err != nil {
+			return err
+		}
+		session.LastUsedAt = now
+		session.ExpiresAt = now.Add(s.cfg.RefreshTTL)
+		if s.meta.IP != "" {
+			session.IP = s.meta.IP
+		}
+		if err := repo.Update(session); err != nil {
+			return err
+		}
+		next, err = s.issueRefreshToken(repo, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reuseErr != nil {
+		return nil, reuseErr
+	}
+	return s.pair(session, next)
+}
+
+func (s *sessionService) StepUp(customerID int, sessionID, method string) (*models.TokenPair, error) {
+	var session *models.Session
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		session, err = repo.GetForUpdate(sessionID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if session == nil || session.CustomerID != customerID || session.RevokedAt != nil || now.After(session.ExpiresAt) {
+			return models.ErrSessionRevoked
+		}
+
+		amr := []string{}
+		if session.AMR != "" {
+			amr = strings.Split(session.AMR, ",")
+		}
+		known := false
+		for _, m := range amr {
+			known = known || m == method
+		}
+		if !known {
+			amr = append(amr, method)
+		}
+		session.AMR = strings.Join(amr, ",")
+		session.AuthTime = now
+		if err := repo.Update(session); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "auth.step_up",
+			EntityType: "customer",
+			EntityID:   customerID,
+			After:      map[string]any{"session_id": session.ID, "method": method},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(session, "")
+}
+
+// revoke ends the session and records why.
+func (s *sessionService) revoke(tx *gorm.DB, session *models.Session, reason string, at time.Time) error {
+	session.RevokedAt = &at
+	session.RevokeReason = reason
+	if err := s.repo.WithTx(tx).Update(session); err != nil {
+		return err
+	}
+	return s.audit.Record(tx, s.meta, audit.Entry{
+		Action:     "auth.session_revoked",
+		EntityType: "session",
+		EntityID:   session.CustomerID,
+		After:      map[string]any{"session_id": session.ID, "reason": reason},
+	})
+}
+
+func (s *sessionService) Check(customerID int, sessionID string) error {
+	session, err := s.repo.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.CustomerID != customerID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return models.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *sessionService) Logout(customerID int, sessionID string) error {
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if sessionID == "" {
+			if err := repo.RevokeAll(customerID, models.SessionLogout, now); err != nil {
+				return err
+			}
+			return s.audit.Record(tx, s.meta, audit.Entry{
+				Action:     "auth.logout_all",
+				EntityType: "customer",
+				EntityID:   customerID,
+			})
+		}
+
+		session, err := repo.GetForUpdate(sessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil || session.CustomerID != customerID {
+			return models.ErrSessionNotFound
+		}
+		if session.RevokedAt != nil {
+			return nil
+		}
+		return s.revoke(tx, session, models.SessionLogout, now)
+	})
+}
+
+func (s *sessionService) ListSessions(customerID int) ([]models.Session, error) {
+	return s.repo.ListActive(customerID, time.Now())
+}
+
+func (s *sessionService) Prune(before time.Time) (int64, error) {
+	return s.repo.Prune(before)
+}
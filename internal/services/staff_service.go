@@ -0,0 +1,455 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/audit"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type StaffService interface {
+	// SeedRoles creates any built-in role that does not exist yet, and
+	// gives the admin role any permission added since it was created.
+	SeedRoles() error
+	// Bootstrap creates the first staff user, as an admin, when there are
+	// none. It does nothing once any staff user exists.
+	Bootstrap(username, password string) error
+
+	Login(username, password string) (*models.StaffToken, error)
+	// Check reports whether a staff token issued at issuedAt is still good.
+	Check(staffID int, issuedAt time.Time) error
+
+	ListStaff() ([]models.StaffUser, error)
+	GetStaff(id int) (*models.StaffUser, error)
+	CreateStaff(req *models.CreateStaffRequest) (*models.StaffUser, error)
+	// UpdateStaff edits a staff user. Changing roles or deactivating signs
+	// them out; nobody can do either to themselves.
+	UpdateStaff(id int, req *models.UpdateStaffRequest) (*models.StaffUser, error)
+
+	ListRoles() ([]models.Role, error)
+	CreateRole(req *models.CreateRoleRequest) (*models.Role, error)
+	// UpdateRole edits a role. Changing its permissions signs out everyone
+	// who holds it.
+	UpdateRole(id int, req *models.UpdateRoleRequest) (*models.Role, error)
+
+	WithAudit(meta audit.Meta) StaffService
+}
+
+// BuiltInRoles are seeded at start. Admins adjust them, or add roles,
+// through the API.
+var BuiltInRoles = []models.Role{
+	{Name: "admin", Description: "Full back-office access", Permissions: models.AllPermissions},
+	{Name: "operations", Description: "Inbound payment files and billers",
+		Permissions: []string{models.PermPaymentsOperate, models.PermBillersManage}},
+	{Name: "compliance", Description: "KYC, sanctions and held-transfer review",
+		Permissions: []string{models.PermKYCReview, models.PermSanctionsReview, models.PermFraudReview, models.PermAuditRead}},
+	{Name: "risk", Description: "Fraud rules and held-transfer review",
+		Permissions: []string{models.PermFraudRules, models.PermFraudReview}},
+	{Name: "auditor", Description: "Read-only audit log and journal verification",
+		Permissions: []string{models.PermAuditRead, models.PermJournalRead}},
+}
+
+type staffService struct {
+	db       *gorm.DB
+	repo     repositories.StaffRepository
+	audit    audit.Recorder
+	meta     audit.Meta
+	tokenTTL time.Duration
+}
+
+// NewStaffService issues staff tokens that last tokenTTL.
+func NewStaffService(db *gorm.DB, repo repositories.StaffRepository, auditor audit.Recorder, tokenTTL time.Duration) StaffService {
+	return &staffService{db: db, repo: repo, audit: auditor, tokenTTL: tokenTTL}
+}
+
+func (s *staffService) WithAudit(meta audit.Meta) StaffService {
+	scoped := *s
+	scoped.meta = meta
+	return &scoped
+}
+
+func (s *staffService) SeedRoles() error {
+	for _, builtIn := range BuiltInRoles {
+		existing, err := s.repo.GetRoleByName(builtIn.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.Name == "admin" && len(existing.Permissions) < len(models.AllPermissions) {
+				if err := s.repo.SetPermissions(existing.ID, models.AllPermissions); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		role := builtIn
+		role.BuiltIn = true
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			repo := s.repo.WithTx(tx)
+			if err := repo.CreateRole(&role); err != nil {
+				return err
+			}
+			return repo.SetPermissions(role.ID, role.Permissions)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *staffService) Bootstrap(username, password string) error {
+	n, err := s.repo.Count()
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = s.CreateStaff(&models.CreateStaffRequest{
+		Username:  username,
+		Password:  password,
+		FirstName: "Bootstrap",
+		LastName:  "Admin",
+		Roles:     []string{"admin"},
+	})
+	return err
+}
+
+func (s *staffService) Login(username, password string) (*models.StaffToken, error) {
+	staff, err := s.repo.GetByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil || !staff.Active || bcrypt.CompareHashAndPassword([]byte(staff.PasswordHash), []byte(password)) != nil {
+		entry := audit.Entry{Action: "staff.login_failed", EntityType: "staff"}
+		if staff != nil {
+			entry.EntityID = staff.ID
+		}
+		if err := s.audit.Record(s.db, s.meta, entry); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrInvalidCredentials
+	}
+
+	permissions, err := s.repo.Permissions(staff.Roles)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	staff.LastLoginAt = &now
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Update(staff); err != nil {
+			return err
+		}
+		meta := s.meta
+		meta.ActorType, meta.ActorID = models.ActorStaff, staff.ID
+		return s.audit.Record(tx, meta, audit.Entry{Action: "staff.login", EntityType: "staff", EntityID: staff.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateStaffToken(staff.ID, staff.Roles, permissions, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StaffToken{
+		Token:       token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.tokenTTL.Seconds()),
+		Roles:       staff.Roles,
+		Permissions: permissions,
+	}, nil
+}
+
+func (s *staffService) Check(staffID int, issuedAt time.Time) error {
+	staff, err := s.repo.Get(staffID)
+	if err != nil {
+		return err
+	}
+	// iat has one-second resolution, so a token from the same second as
+	// the revocation is refused too
+	if staff == nil || !staff.Active || (staff.TokensRevokedAt != nil && issuedAt.Unix() <= staff.TokensRevokedAt.Unix()) {
+		return models.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *staffService) ListStaff() ([]models.StaffUser, error) {
+	return s.repo.List()
+}
+
+func (s *staffService) GetStaff(id int) (*models.StaffUser, error) {
+	staff, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, models.ErrStaffNotFound
+	}
+	return staff, nil
+}
+
+// resolveRoles looks up roles by name, returning their IDs and the names
+// sorted and without duplicates.
+func resolveRoles(repo repositories.StaffRepository, names []string) ([]int, []string, error) {
+	seen := map[string]bool{}
+	var ids []int
+	var resolved []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		role, err := repo.GetRoleByName(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if role == nil {
+			return nil, nil, fmt.Errorf("%w: %s", models.ErrRoleNotFound, name)
+		}
+		ids = append(ids, role.ID)
+		resolved = append(resolved, role.Name)
+	}
+	sort.Strings(resolved)
+	return ids, resolved, nil
+}
+
+func (s *staffService) CreateStaff(req *models.CreateStaffRequest) (*models.StaffUser, error) {
+	username := strings.TrimSpace(req.Username)
+	if err := ValidatePassword(req.Password, username); err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), 14)
+	if err != nil {
+		return nil, err
+	}
+	staff := &models.StaffUser{
+		Username:     username,
+		PasswordHash: string(hashed),
+		FirstName:    strings.TrimSpace(req.FirstName),
+		LastName:     strings.TrimSpace(req.LastName),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		Active:       true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		existing, err := repo.GetByUsername(username)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.ErrStaffUsernameTaken
+		}
+		roleIDs, roles, err := resolveRoles(repo, req.Roles)
+		if err != nil {
+			return err
+		}
+		if err := repo.Create(staff); err != nil {
+			return err
+		}
+		if err := repo.SetRoles(staff.ID, roleIDs); err != nil {
+			return err
+		}
+		staff.Roles = roles
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "staff.create",
+			EntityType: "staff",
+			EntityID:   staff.ID,
+			After:      staff,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *staffService) UpdateStaff(id int, req *models.UpdateStaffRequest) (*models.StaffUser, error) {
+	if id == s.meta.ActorID && (req.Roles != nil || (req.Active != nil && !*req.Active)) {
+		return nil, models.ErrCannotModifySelf
+	}
+
+	var staff *models.StaffUser
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		staff, err = repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if staff == nil {
+			return models.ErrStaffNotFound
+		}
+		before := *staff
+
+		if req.FirstName != nil {
+			staff.FirstName = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			staff.LastName = strings.TrimSpace(*req.LastName)
+		}
+		if req.Email != nil {
+			staff.Email = strings.ToLower(strings.TrimSpace(*req.Email))
+		}
+		revoke := false
+		if req.Active != nil && *req.Active != staff.Active {
+			staff.Active = *req.Active
+			revoke = !staff.Active
+		}
+		if req.Roles != nil {
+			roleIDs, roles, err := resolveRoles(repo, *req.Roles)
+			if err != nil {
+				return err
+			}
+			if strings.Join(roles, ",") != strings.Join(staff.Roles, ",") {
+				if err := repo.SetRoles(staff.ID, roleIDs); err != nil {
+					return err
+				}
+				staff.Roles = roles
+				revoke = true
+			}
+		}
+		if revoke {
+			now := time.Now()
+			staff.TokensRevokedAt = &now
+		}
+
+		if err := repo.Update(staff); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "staff.update",
+			EntityType: "staff",
+			EntityID:   staff.ID,
+			Before:     before,
+			After:      staff,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *staffService) ListRoles() ([]models.Role, error) {
+	return s.repo.ListRoles()
+}
+
+// validPermissions checks the permissions exist and returns them sorted
+// without duplicates.
+func validPermissions(permissions []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, p := range models.AllPermissions {
+		known[p] = true
+	}
+	seen := map[string]bool{}
+	var valid []string
+	for _, p := range permissions {
+		if !known[p] {
+			return nil, fmt.Errorf("%w: %s", models.ErrUnknownPermission, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			valid = append(valid, p)
+		}
+	}
+	sort.Strings(valid)
+	return valid, nil
+}
+
+func (s *staffService) CreateRole(req *models.CreateRoleRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if name == models.RoleCustomer {
+		return nil, models.ErrReservedRoleName
+	}
+	permissions, err := validPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	role := &models.Role{Name: name, Description: strings.TrimSpace(req.Description), Permissions: permissions}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		existing, err := repo.GetRoleByName(name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return models.ErrDuplicateRole
+		}
+		if err := repo.CreateRole(role); err != nil {
+			return err
+		}
+		if err := repo.SetPermissions(role.ID, permissions); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "role.create",
+			EntityType: "role",
+			EntityID:   role.ID,
+			After:      role,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *staffService) UpdateRole(id int, req *models.UpdateRoleRequest) (*models.Role, error) {
+	var role *models.Role
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		role, err = repo.GetRole(id)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return models.ErrRoleNotFound
+		}
+		before := *role
+
+		if req.Description != nil {
+			role.Description = strings.TrimSpace(*req.Description)
+		}
+		if req.Permissions != nil {
+			permissions, err := validPermissions(*req.Permissions)
+			if err != nil {
+				return err
+			}
+			if strings.Join(permissions, ",") != strings.Join(role.Permissions, ",") {
+				if err := repo.SetPermissions(role.ID, permissions); err != nil {
+					return err
+				}
+				if err := repo.RevokeTokensForRole(role.ID, time.Now()); err != nil {
+					return err
+				}
+				role.Permissions = permissions
+			}
+		}
+
+		if err := repo.UpdateRole(role); err != nil {
+			return err
+		}
+		return s.audit.Record(tx, s.meta, audit.Entry{
+			Action:     "role.update",
+			EntityType: "role",
+			EntityID:   role.ID,
+			Before:     before,
+			After:      role,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mahesh252k/banking-api/internal/events"
+	"github.com/Mahesh252k/banking-api/internal/models"
+	"github.com/Mahesh252k/banking-api/internal/repositories"
+	"github.com/Mahesh252k/banking-api/internal/webhooks"
+)
+
+type WebhookConfig struct {
+	// AllowHTTP permits plain-http endpoints, for local development.
+	AllowHTTP bool
+	// AllowPrivate permits endpoints on loopback, private and link-local
+	// addresses, for local development. The sender's client must be built
+	// to match.
+	AllowPrivate bool
+	// MaxAttempts before a delivery is dead-lettered.
+	MaxAttempts int
+	// Retries wait RetryBackoff, then twice that, and so on up to MaxBackoff.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	SendTimeout  time.Duration
+	// Workers is how many deliveries DeliverDue sends at once.
+	Workers int
+}
+
+type WebhookService interface {
+	CreateSubscription(req *models.CreateWebhookRequest, customerID int) (*models.CreatedWebhook, error)
+	ListSubscriptions(customerID int) ([]models.WebhookSubscription, error)
+	DeleteSubscription(id, customerID int) error
+	// ListDeliveries is the delivery log; status "dead" gives the dead letters.
+	ListDeliveries(subscriptionID, customerID int, status string) ([]models.WebhookDelivery, error)
+	GetDelivery(id, customerID int) (*models.WebhookDelivery, error)
+	// Redeliver queues a delivery again with a fresh set of attempts.
+	Redeliver(id, customerID int) (*models.WebhookDelivery, error)
+
+	// Enqueue records a delivery of e for every matching subscription.
+	// Enqueueing the same event twice is harmless.
+	Enqueue(e events.Event) error
+	// DeliverDue sends the deliveries whose next attempt is due.
+	DeliverDue() (int, error)
+}
+
+type webhookService struct {
+	repo   repositories.WebhookRepository
+	sender *webhooks.Sender
+	config WebhookConfig
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, sender *webhooks.Sender, config WebhookConfig) WebhookService {
+	return &webhookService{repo: repo, sender: sender, config: config}
+}
+
+func (s *webhookService) CreateSubscription(req *models.CreateWebhookRequest, customerID int) (*models.CreatedWebhook, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" || !(u.Scheme == "https" || (u.Scheme == "http" && s.config.AllowHTTP)) {
+		return nil, models.ErrInvalidWebhookURL
+	}
+	if !s.config.AllowPrivate {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.SendTimeout)
+		err := webhooks.CheckHost(ctx, u.Hostname())
+		cancel()
+		if err != nil {
+			return nil, models.ErrWebhookURLNotPublic
+		}
+	}
+
+	types := make([]string, 0, len(req.EventTypes))
+	for _, t := range req.EventTypes {
+		t = strings.TrimSpace(t)
+		if !isWebhookType(t) {
+			return nil, fmt.Errorf("%w: %q", models.ErrUnknownEventType, t)
+		}
+		types = append(types, t)
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	sub := &models.WebhookSubscription{
+		CustomerID: customerID,
+		URL:        u.String(),
+		Secret:     secret,
+		EventTypes: strings.Join(types, ","),
+		Active:     true,
+	}
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return &models.CreatedWebhook{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+func isWebhookType(t string) bool {
+	for _, known := range events.WebhookTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *webhookService) ListSubscriptions(customerID int) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptionsByCustomerID(customerID)
+}
+
+func (s *webhookService) ownedSubscription(id, customerID int) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil || sub.CustomerID != customerID {
+		return nil, models.ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+func (s *webhookService) DeleteSubscription(id, customerID int) error {
+	if _, err := s.ownedSubscription(id, customerID); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+func (s *webhookService) ListDeliveries(subscriptionID, customerID int, status string) ([]models.WebhookDelivery, error) {
+	if _, err := s.ownedSubscription(subscriptionID, customerID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(subscriptionID, status, 100)
+}
+
+func (s *webhookService) GetDelivery(id, customerID int) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.Subscription == nil || delivery.Subscription.CustomerID != customerID {
+		return nil, models.ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func (s *webhookService) Redeliver(id, customerID int) (*models.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *webhookService) Enqueue(e events.Event) error {
+	if !isWebhookType(e.Type) {
+		return nil
+	}
+
+	subs, err := s.repo.ListActiveSubscriptions(e.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	// the outbox ID makes the event ID stable across relay retries
+	eventID := fmt.Sprintf("evt_%d", e.ID)
+	var payload []byte
+	for _, sub := range subs {
+		if !subscribedTo(&sub, e.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(map[string]any{
+				"id":          eventID,
+				"type":        e.Type,
+				"created_at":  e.OccurredAt,
+				"customer_id": e.CustomerID,
+				"data":        e.Data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      e.Type,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		}
+		if err := s.repo.CreateDelivery(delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func subscribedTo(sub *models.WebhookSubscription, eventType string) bool {
+	for _, t := range strings.Split(sub.EventTypes, ",") {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *webhookService) DeliverDue() (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListDue(now, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := 0
+	slots := make(chan struct{}, s.config.Workers)
+	for i := range due {
+		delivery := &due[i]
+		// hold it for the send plus margin; a crashed worker's claim
+		// simply expires and the delivery is retried
+		claimed, err := s.repo.Claim(delivery, now.Add(s.config.SendTimeout+time.Minute))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := s.attempt(delivery); err != nil {
+				log.Printf("webhooks: delivery %d: %v", delivery.ID, err)
+				return
+			}
+			mu.Lock()
+			sent++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return sent, nil
+}
+
+// attempt makes one HTTP call for delivery and records the outcome.
+func (s *webhookService) attempt(delivery *models.WebhookDelivery) error {
+	sub := delivery.Subscription
+	if sub == nil || !sub.Active {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "subscription removed"
+		return s.repo.UpdateDelivery(delivery)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.SendTimeout)
+	started := time.Now()
+	code, sendErr := s.sender.Send(ctx, sub.URL, sub.Secret, delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+	cancel()
+
+	record := &models.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		StatusCode:  code,
+		DurationMS:  time.Since(started).Milliseconds(),
+		AttemptedAt: started,
+	}
+	if sendErr != nil {
+		record.Error = truncate(webhooks.Describe(code, sendErr), 500)
+	}
+	if err := s.repo.CreateAttempt(record); err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = code
+	delivery.LastError = record.Error
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.config.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryDead
+	default:
+		delivery.NextAttemptAt = time.Now().Add(s.backoff(delivery.Attempts))
+	}
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (s *webhookService) backoff(attempts int) time.Duration {
+	wait := s.config.RetryBackoff
+	for i := 1; i < attempts && wait < s.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.config.MaxBackoff {
+		wait = s.config.MaxBackoff
+	}
+	return wait
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for an endpoint on a loopback, private,
+// link-local or otherwise internal address. Subscribers choose the URL,
+// so without this check deliveries could be aimed at the bank's own
+// network.
+var ErrPrivateAddress = errors.New("webhooks: endpoint address is not public")
+
+// carrierNAT is the shared address space of RFC 6598, which net.IP does
+// not count as private.
+var carrierNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP reports whether ip may be delivered to.
+func PublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !carrierNAT.Contains(ip)
+}
+
+// CheckHost resolves host and refuses it unless every address is public.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !PublicIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// NewClient returns the client deliveries are sent with. Unless
+// allowPrivate is set it refuses to connect to anything but public
+// addresses, checked on the resolved address at dial time so a name that
+// changes after the subscription was created is caught too. Redirects are
+// not followed; a 3xx is a failed attempt like any other non-2xx.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would make the dialled address the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// Describe turns a failed send into what the subscriber sees in the
+// delivery log. Statuses returned by the endpoint are shown as they are;
+// failures to reach it are not, since the raw errors name addresses and
+// resolver details from inside the bank's network.
+func Describe(status int, err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case status != 0:
+		return err.Error()
+	case errors.Is(err, ErrPrivateAddress):
+		return "endpoint address is not public"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timed out"
+	default:
+		return "could not connect to endpoint"
+	}
+}
@@ -1,7 +1,10 @@
 package auth
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
 	"strings"
 	"time"
 
@@ -9,37 +12,301 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// Token subjects. Customer and staff IDs overlap, so every check on a
+// token starts with whose it is.
+const (
+	KindCustomer = "customer"
+	KindStaff    = "staff"
+	// KindMFA tokens only show that the password was right; they are
+	// exchanged for a customer token once the second factor is passed.
+	KindMFA = "mfa"
+	// KindClient tokens are issued to API clients. UserID is the customer
+	// the client acts for, on routes that grant one of its scopes.
+	KindClient = "client"
+	// KindConsent tokens let a third-party provider use one consent of
+	// the customer in UserID.
+	KindConsent = "consent"
+)
+
+// Authentication methods recorded in the amr claim (RFC 8176).
+const (
+	MethodPassword = "pwd"
+	MethodOTP      = "otp"
+)
+
+// Claims are what a valid token says about its holder.
+type Claims struct {
+	UserID int
+	// Kind is KindCustomer for tokens issued before it was recorded.
+	Kind        string
+	Roles       []string
+	Permissions []string
+	// ID is the token's jti and SessionID the session it was issued in;
+	// both are empty for tokens issued before sessions existed.
+	ID        string
+	SessionID string
+	// AMR lists how the holder authenticated, and AuthTime when they last
+	// did so, for step-up checks. Both are unset on older tokens.
+	AMR      []string
+	AuthTime time.Time
+	// ClientID and Scopes are set on client and consent tokens only; a
+	// consent token's scopes are the consent's permissions.
+	ClientID  string
+	Scopes    []string
+	ConsentID string
+	// IssuedAt is zero for tokens issued before it was recorded.
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
 
-func GenerateToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
+// GenerateToken issues a customer access token for the session that
+// lasts ttl. amr and authTime describe the session's last authentication.
+func GenerateToken(userID int, sessionID string, amr []string, authTime time.Time, ttl time.Duration) (string, error) {
+	return sign(jwt.MapClaims{
+		"user_id":   userID,
+		"kind":      KindCustomer,
+		"roles":     []string{KindCustomer},
+		"sid":       sessionID,
+		"amr":       amr,
+		"auth_time": authTime.Unix(),
+	}, ttl)
+}
+
+// GenerateMFAToken issues the limited token a customer holds between
+// passing the password and passing the second factor.
+func GenerateMFAToken(userID int, ttl time.Duration) (string, error) {
+	return sign(jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"kind":    KindMFA,
+		"amr":     []string{MethodPassword},
+	}, ttl)
+}
+
+// GenerateStaffToken issues a staff token carrying the staff user's roles
+// and the permissions they grant.
+func GenerateStaffToken(staffID int, roles, permissions []string, ttl time.Duration) (string, error) {
+	return sign(jwt.MapClaims{
+		"user_id": staffID,
+		"kind":    KindStaff,
+		"roles":   roles,
+		"perms":   permissions,
+	}, ttl)
+}
+
+// GenerateClientToken issues an API client token acting for customerID
+// within scopes. The scope claim is space-separated as in OAuth2.
+func GenerateClientToken(customerID int, clientID string, scopes []string, ttl time.Duration) (string, error) {
+	return sign(jwt.MapClaims{
+		"user_id":   customerID,
+		"kind":      KindClient,
+		"client_id": clientID,
+		"scope":     strings.Join(scopes, " "),
+	}, ttl)
+}
+
+// GenerateConsentToken issues a third-party provider a token bound to
+// one consent of customerID.
+func GenerateConsentToken(customerID int, clientID, consentID string, permissions []string, ttl time.Duration) (string, error) {
+	return sign(jwt.MapClaims{
+		"user_id":    customerID,
+		"kind":       KindConsent,
+		"client_id":  clientID,
+		"consent_id": consentID,
+		"scope":      strings.Join(permissions, " "),
+	}, ttl)
+}
+
+func sign(claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims["jti"] = hex.EncodeToString(id[:])
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+
+	if keySet == nil {
+		return "", errors.New("no signing keys")
 	}
+	key := keySet.Signing()
+	if key == nil {
+		return "", errors.New("no signing key")
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// HasMethod reports whether the holder authenticated with method.
+func (c *Claims) HasMethod(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
 }
 
-func GetUserID(c *gin.Context) int {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		return 0
+// HasPermission reports whether the token grants permission.
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
 	}
+	return false
+}
 
-	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+// HasScope reports whether a client token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(v any) []string {
+	items, _ := v.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
 
+// ParseToken checks the token's signature against the key its kid names,
+// and its expiry.
+func ParseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.Parse(tokenString, keyFor,
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA, jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	// third-party providers' client tokens act for no customer
+	kind, _ := claims["kind"].(string)
+	userID, ok := claims["user_id"].(float64)
+	if !ok || (userID == 0 && kind != KindClient) {
+		return nil, errors.New("invalid token")
+	}
+	parsed := &Claims{
+		UserID:      int(userID),
+		Kind:        KindCustomer,
+		Roles:       stringList(claims["roles"]),
+		Permissions: stringList(claims["perms"]),
+		AMR:         stringList(claims["amr"]),
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		parsed.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	if kind != "" {
+		parsed.Kind = kind
+	}
+	parsed.ID, _ = claims["jti"].(string)
+	parsed.SessionID, _ = claims["sid"].(string)
+	parsed.ClientID, _ = claims["client_id"].(string)
+	parsed.ConsentID, _ = claims["consent_id"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		parsed.Scopes = strings.Fields(scope)
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		parsed.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		parsed.ExpiresAt = exp.Time
+	}
+	return parsed, nil
+}
+
+// BearerToken returns the token from the Authorization header.
+func BearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// GetUserID returns the customer the request's token belongs to, or 0.
+// Staff tokens give 0, and so do client tokens except on routes where
+// RequireScope admitted them.
+func GetUserID(c *gin.Context) int {
+	tokenString := BearerToken(c)
+	if tokenString == "" {
 		return 0
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		if userID, ok := claims["user_id"].(float64); ok {
-			return int(userID)
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return 0
+	}
+	switch claims.Kind {
+	case KindCustomer:
+		return claims.UserID
+	case KindClient:
+		if c.GetBool(scopeGrantedKey) {
+			return claims.UserID
 		}
 	}
 	return 0
 }
+
+const (
+	claimsKey       = "auth.claims"
+	scopeGrantedKey = "auth.scope_granted"
+)
+
+// SetClaims stores the verified claims for RequirePermission and the
+// handlers after it.
+func SetClaims(c *gin.Context, claims *Claims) {
+	c.Set(claimsKey, claims)
+}
+
+// ClaimsFrom returns the claims stored by SetClaims, or nil.
+func ClaimsFrom(c *gin.Context) *Claims {
+	if v, ok := c.Get(claimsKey); ok {
+		return v.(*Claims)
+	}
+	return nil
+}
+
+// RequirePermission admits requests whose verified token grants
+// permission. It must run after a middleware that calls SetClaims.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims == nil || !claims.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope lets client tokens granting scope act for their customer
+// on the route; customer tokens pass as they are. Client tokens are
+// refused anywhere else by GetUserID, so routes without it stay
+// customer-only. It must run after a middleware that calls SetClaims.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		if claims.Kind == KindClient {
+			if !claims.HasScope(scope) {
+				c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "scope": scope})
+				return
+			}
+			c.Set(scopeGrantedKey, true)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,465 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms for new keys.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is one key tokens are signed or verified with.
+type Key struct {
+	ID  string
+	Alg string
+	// Private is nil for keys that only verify, such as another
+	// service's.
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	// Created is when the key file was written; the newest private key
+	// signs.
+	Created time.Time
+	path    string
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Alg == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// KeyID names a public key in token headers: the first 8 bytes of the
+// SHA-256 of its DER encoding, in hex.
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// KeySet holds the keys in a directory of PEM files. Private keys
+// (PKCS#8, or PKCS#1 for RSA) sign and verify; public keys only verify.
+type KeySet struct {
+	dir string
+	alg string
+
+	mu       sync.RWMutex
+	keys     map[string]*Key
+	signing  *Key
+	legacy   []byte
+	reloaded time.Time
+}
+
+// reloadEvery bounds how often an unknown key ID rereads the directory.
+const reloadEvery = 10 * time.Second
+
+// rotationLock is the file an instance holds in the key directory while
+// it decides whether to rotate. A lock older than staleLock was left by
+// an instance that died holding it.
+const (
+	rotationLock = ".rotate.lock"
+	staleLock    = time.Minute
+)
+
+// LoadKeySet reads the keys in dir, creating the directory and a first
+// key of alg when there is no private key yet.
+func LoadKeySet(dir, alg string) (*KeySet, error) {
+	if alg != AlgRS256 && alg != AlgEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	ks := &KeySet{dir: dir, alg: alg}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	if ks.Signing() == nil {
+		// instances starting together write one first key between them
+		locked, err := ks.withRotationLock(func() error {
+			if err := ks.Reload(); err != nil || ks.Signing() != nil {
+				return err
+			}
+			_, err := ks.Rotate()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		// another instance holds the lock; wait for the key it writes
+		for deadline := time.Now().Add(staleLock); !locked && ks.Signing() == nil; {
+			if time.Now().After(deadline) {
+				return nil, errors.New("timed out waiting for another instance to write the first signing key")
+			}
+			time.Sleep(time.Second)
+			if err := ks.Reload(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ks, nil
+}
+
+// Reload rereads the directory, picking up keys another instance sharing
+// it has written or removed.
+func (ks *KeySet) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	keys := map[string]*Key{}
+	var signing *Key
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			// one bad file must not take the other keys down with it
+			log.Printf("signing keys: skipping %s: %v", path, err)
+			continue
+		}
+		keys[key.ID] = key
+		if key.Private != nil && (signing == nil || key.Created.After(signing.Created)) {
+			signing = key
+		}
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	ks.signing = signing
+	ks.reloaded = time.Now()
+	return nil
+}
+
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	key := &Key{Created: info.ModTime(), path: path}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = parsed
+		key.Public = parsed.Public()
+	case "PUBLIC KEY":
+		key.Public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Alg = AlgRS256
+	case ed25519.PublicKey:
+		key.Alg = AlgEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	if key.ID, err = KeyID(key.Public); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Rotate writes a new key of the set's algorithm and signs with it from
+// now on. Older keys keep verifying until Prune removes them.
+func (ks *KeySet) Rotate() (*Key, error) {
+	var private crypto.Signer
+	var err error
+	if ks.alg == AlgRS256 {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	id, err := KeyID(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	// write under a temporary name so no instance reads half a key
+	path := filepath.Join(ks.dir, id+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id, Alg: ks.alg, Private: private, Public: private.Public(), Created: time.Now(), path: path}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[id] = key
+	ks.signing = key
+	return key, nil
+}
+
+// Prune removes private keys that were replaced more than overlap ago,
+// when no token they signed can still be unexpired. Public keys are left
+// to whoever put them there.
+func (ks *KeySet) Prune(overlap time.Duration) (int, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	var private []*Key
+	for _, key := range ks.keys {
+		if key.Private != nil {
+			private = append(private, key)
+		}
+	}
+	sort.Slice(private, func(i, j int) bool { return private[i].Created.Before(private[j].Created) })
+
+	removed := 0
+	for i := 0; i < len(private)-1; i++ {
+		// a key is retired when the next one is created
+		if time.Since(private[i+1].Created) < overlap {
+			continue
+		}
+		if err := os.Remove(private[i].path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		delete(ks.keys, private[i].ID)
+		removed++
+	}
+	return removed, nil
+}
+
+// RotateDue rotates when the newest private key in the directory is
+// older than maxAge and then prunes keys retired more than overlap ago.
+// Instances sharing the directory take turns through a lock file, so only
+// one of them rotates; the others pick the new key up on their next
+// reload. It reports whether it rotated.
+func (ks *KeySet) RotateDue(maxAge, overlap time.Duration) (bool, error) {
+	rotated := false
+	locked, err := ks.withRotationLock(func() error {
+		if err := ks.Reload(); err != nil {
+			return err
+		}
+		if signing := ks.Signing(); signing == nil || time.Since(signing.Created) >= maxAge {
+			if _, err := ks.Rotate(); err != nil {
+				return err
+			}
+			rotated = true
+		}
+		_, err := ks.Prune(overlap)
+		return err
+	})
+	if err != nil || !locked {
+		return false, err
+	}
+	return rotated, nil
+}
+
+// withRotationLock runs fn while holding the directory's rotation lock.
+// It reports false without running fn when another instance holds it.
+func (ks *KeySet) withRotationLock(fn func() error) (bool, error) {
+	path := filepath.Join(ks.dir, rotationLock)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if os.IsExist(err) {
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < staleLock {
+			return false, nil
+		}
+		log.Printf("signing keys: removing stale %s", path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if os.IsExist(err) {
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	f.Close()
+	defer os.Remove(path)
+	return true, fn()
+}
+
+// Signing returns the key new tokens are signed with.
+func (ks *KeySet) Signing() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.signing
+}
+
+// Key returns the key with the given ID, or nil.
+func (ks *KeySet) Key(id string) *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[id]
+}
+
+// find is Key, rereading the directory when the key is unknown: another
+// instance may have just rotated.
+func (ks *KeySet) find(id string) *Key {
+	if key := ks.Key(id); key != nil {
+		return key
+	}
+	ks.mu.RLock()
+	stale := time.Since(ks.reloaded) >= reloadEvery
+	ks.mu.RUnlock()
+	if !stale || ks.Reload() != nil {
+		return nil
+	}
+	return ks.Key(id)
+}
+
+// AcceptLegacySecret lets tokens signed with the old shared HS256 secret
+// verify, so switching to the key set does not sign everyone out. New
+// tokens are never signed with it.
+func (ks *KeySet) AcceptLegacySecret(secret []byte) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.legacy = secret
+}
+
+func (ks *KeySet) legacySecret() []byte {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.legacy
+}
+
+// JWK is a public key in the form of RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists every key tokens may be verified with, signing key first.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == ks.signing) != (keys[j] == ks.signing) {
+			return keys[i] == ks.signing
+		}
+		return keys[i].Created.After(keys[j].Created)
+	})
+
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	b64 := base64.RawURLEncoding
+	for _, key := range keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Alg}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+var keySet *KeySet
+
+// UseKeys sets the keys tokens are signed and verified with. It must be
+// called before any token is issued or parsed.
+func UseKeys(ks *KeySet) {
+	keySet = ks
+}
+
+// keyFor picks the key a token's header names, refusing one whose
+// algorithm does not match the key's.
+func keyFor(token *jwt.Token) (interface{}, error) {
+	if keySet == nil {
+		return nil, errors.New("no signing keys")
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		secret := keySet.legacySecret()
+		if len(secret) == 0 || token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("token has no key id")
+		}
+		return secret, nil
+	}
+	key := keySet.find(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown key %s", kid)
+	}
+	if token.Method.Alg() != key.Alg {
+		return nil, errors.New("algorithm does not match key")
+	}
+	return key.Public, nil
+}